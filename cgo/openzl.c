@@ -4,47 +4,85 @@
 // C wrapper implementations for OpenZL Go bindings
 #include "openzl.h"
 
-openzl_context_t* openzl_context_create() {
-    openzl_context_t* ctx = (openzl_context_t*)malloc(sizeof(openzl_context_t));
-    if (ctx == NULL) {
-        return NULL;
-    }
-    
-    ctx->cctx = ZL_CCtx_create();
-    ctx->dctx = ZL_DCtx_create();
-    
-    if (ctx->cctx == NULL || ctx->dctx == NULL) {
-        openzl_context_free(ctx);
-        return NULL;
+// Applies the sticky compression parameters stored in ctx to its ZL_CCtx.
+static ZL_Report openzl_apply_parameters(openzl_context_t* ctx) {
+    ZL_Report result = ZL_CCtx_resetParameters(ctx->cctx);
+    if (ZL_isError(result)) {
+        return result;
     }
 
     // Enable sticky parameters to allow context reuse across multiple operations
-    ZL_Report result = ZL_CCtx_setParameter(ctx->cctx, ZL_CParam_stickyParameters, 1);
+    result = ZL_CCtx_setParameter(ctx->cctx, ZL_CParam_stickyParameters, 1);
     if (ZL_isError(result)) {
-        openzl_context_free(ctx);
-        return NULL;
+        return result;
     }
 
-    result = ZL_DCtx_setParameter(ctx->dctx, ZL_DParam_stickyParameters, 1);
+    int version = ctx->format_version != 0 ? ctx->format_version : (int)ZL_getDefaultEncodingVersion();
+    result = ZL_CCtx_setParameter(ctx->cctx, ZL_CParam_formatVersion, version);
     if (ZL_isError(result)) {
-        openzl_context_free(ctx);
-        return NULL;
+        return result;
     }
 
-    // Set default compression parameters
-    unsigned defaultVersion = ZL_getDefaultEncodingVersion();
-    result = ZL_CCtx_setParameter(ctx->cctx, ZL_CParam_formatVersion, (int)defaultVersion);
+    int level = ctx->level != 0 ? ctx->level : ZL_COMPRESSIONLEVEL_DEFAULT;
+    result = ZL_CCtx_setParameter(ctx->cctx, ZL_CParam_compressionLevel, level);
     if (ZL_isError(result)) {
-        openzl_context_free(ctx);
+        return result;
+    }
+
+    if (ctx->compressor != NULL) {
+        result = ZL_CCtx_refCompressor(ctx->cctx, ctx->compressor);
+        if (ZL_isError(result)) {
+            return result;
+        }
+    }
+
+    ctx->dirty = 0;
+    return result;
+}
+
+openzl_context_t* openzl_context_create() {
+    return openzl_context_create_mode(OPENZL_MODE_COMPRESS | OPENZL_MODE_DECOMPRESS);
+}
+
+openzl_context_t* openzl_context_create_mode(int mode) {
+    if ((mode & (OPENZL_MODE_COMPRESS | OPENZL_MODE_DECOMPRESS)) == 0) {
         return NULL;
     }
 
-    result = ZL_CCtx_setParameter(ctx->cctx, ZL_CParam_compressionLevel, ZL_COMPRESSIONLEVEL_DEFAULT);
-    if (ZL_isError(result)) {
-        openzl_context_free(ctx);
+    openzl_context_t* ctx = (openzl_context_t*)calloc(1, sizeof(openzl_context_t));
+    if (ctx == NULL) {
         return NULL;
     }
 
+    if (mode & OPENZL_MODE_DECOMPRESS) {
+        ctx->dctx = ZL_DCtx_create();
+        if (ctx->dctx == NULL) {
+            openzl_context_free(ctx);
+            return NULL;
+        }
+
+        ZL_Report result = ZL_DCtx_setParameter(ctx->dctx, ZL_DParam_stickyParameters, 1);
+        if (ZL_isError(result)) {
+            openzl_context_free(ctx);
+            return NULL;
+        }
+    }
+
+    if (mode & OPENZL_MODE_COMPRESS) {
+        ctx->cctx = ZL_CCtx_create();
+        if (ctx->cctx == NULL) {
+            openzl_context_free(ctx);
+            return NULL;
+        }
+
+        // Set default compression parameters
+        ZL_Report result = openzl_apply_parameters(ctx);
+        if (ZL_isError(result)) {
+            openzl_context_free(ctx);
+            return NULL;
+        }
+    }
+
     return ctx;
 }
 
@@ -60,16 +98,108 @@ void openzl_context_free(openzl_context_t* ctx) {
     if (ctx->dctx != NULL) {
         ZL_DCtx_free(ctx->dctx);
     }
+
+    if (ctx->compressor != NULL) {
+        ZL_Compressor_free(ctx->compressor);
+    }
     
     free(ctx);
 }
 
+int openzl_context_set_level(openzl_context_t* ctx, int level) {
+    if (ctx == NULL || ctx->cctx == NULL || level < 0) {
+        return -1;
+    }
+
+    // Apply the level right away so that values the library rejects are
+    // reported here rather than by the next compression.
+    int previous = ctx->level;
+    ctx->level = level;
+    ZL_Report result = openzl_apply_parameters(ctx);
+    if (ZL_isError(result)) {
+        ctx->level = previous;
+        ctx->dirty = 1;
+        return -(int)ZL_errorCode(result);
+    }
+    return 0;
+}
+
+int openzl_context_set_format_version(openzl_context_t* ctx, int version) {
+    if (ctx == NULL || ctx->cctx == NULL || version < 0) {
+        return -1;
+    }
+
+    // As with the level, unsupported versions are reported right away.
+    int previous = ctx->format_version;
+    ctx->format_version = version;
+    ZL_Report result = openzl_apply_parameters(ctx);
+    if (ZL_isError(result)) {
+        ctx->format_version = previous;
+        ctx->dirty = 1;
+        return -(int)ZL_errorCode(result);
+    }
+    return 0;
+}
+
+// Maps an OPENZL_GRAPH_* identifier to a standard OpenZL graph.
+static int openzl_graph_id(int graph, ZL_GraphID* id) {
+    switch (graph) {
+    case OPENZL_GRAPH_STORE:    *id = ZL_GRAPH_STORE; return 1;
+    case OPENZL_GRAPH_ZSTD:     *id = ZL_GRAPH_ZSTD; return 1;
+    case OPENZL_GRAPH_GENERIC:  *id = ZL_GRAPH_COMPRESS_GENERIC; return 1;
+    case OPENZL_GRAPH_FIELD_LZ: *id = ZL_GRAPH_FIELD_LZ; return 1;
+    case OPENZL_GRAPH_ENTROPY:  *id = ZL_GRAPH_ENTROPY; return 1;
+    case OPENZL_GRAPH_HUFFMAN:  *id = ZL_GRAPH_HUFFMAN; return 1;
+    case OPENZL_GRAPH_FSE:      *id = ZL_GRAPH_FSE; return 1;
+    case OPENZL_GRAPH_BITPACK:  *id = ZL_GRAPH_BITPACK; return 1;
+    default:                    return 0;
+    }
+}
+
+int openzl_context_set_graph(openzl_context_t* ctx, int graph) {
+    if (ctx == NULL || ctx->cctx == NULL) {
+        return -1;
+    }
+
+    ZL_Compressor* compressor = NULL;
+    if (graph != OPENZL_GRAPH_DEFAULT) {
+        ZL_GraphID id;
+        if (!openzl_graph_id(graph, &id)) {
+            return -1;
+        }
+        compressor = ZL_Compressor_create();
+        if (compressor == NULL) {
+            return -1;
+        }
+        ZL_Report result = ZL_Compressor_selectStartingGraphID(compressor, id);
+        if (ZL_isError(result)) {
+            ZL_Compressor_free(compressor);
+            return -(int)ZL_errorCode(result);
+        }
+    }
+
+    if (ctx->compressor != NULL) {
+        ZL_Compressor_free(ctx->compressor);
+    }
+    ctx->compressor = compressor;
+    ctx->graph = graph;
+    ctx->dirty = 1;
+    return 0;
+}
+
 long long openzl_compress(openzl_context_t* ctx, 
                          void* dst, size_t dst_capacity,
                          const void* src, size_t src_size) {
     if (ctx == NULL || ctx->cctx == NULL) {
         return -1;
     }
+
+    if (ctx->dirty) {
+        ZL_Report applied = openzl_apply_parameters(ctx);
+        if (ZL_isError(applied)) {
+            return -(long long)ZL_errorCode(applied);
+        }
+    }
     
     ZL_Report result = ZL_CCtx_compress(ctx->cctx, dst, dst_capacity, src, src_size);
     
@@ -87,7 +217,9 @@ long long openzl_decompress(openzl_context_t* ctx,
         return -1;
     }
     
-    ZL_Report result = ZL_decompress(dst, dst_capacity, src, src_size);
+    // Going through the context's ZL_DCtx applies its decompression
+    // parameters and reuses its buffers across frames.
+    ZL_Report result = ZL_DCtx_decompress(ctx->dctx, dst, dst_capacity, src, src_size);
     
     if (ZL_isError(result)) {
         return -(long long)ZL_errorCode(result);
@@ -96,6 +228,417 @@ long long openzl_decompress(openzl_context_t* ctx,
     return (long long)ZL_validResult(result);
 }
 
+long long openzl_compress_typed(openzl_context_t* ctx,
+                               void* dst, size_t dst_capacity,
+                               const openzl_typed_t* inputs, size_t nb_inputs) {
+    if (ctx == NULL || ctx->cctx == NULL || nb_inputs == 0) {
+        return -1;
+    }
+
+    if (ctx->dirty) {
+        ZL_Report applied = openzl_apply_parameters(ctx);
+        if (ZL_isError(applied)) {
+            return -(long long)ZL_errorCode(applied);
+        }
+    }
+
+    const ZL_TypedRef** refs = (const ZL_TypedRef**)calloc(nb_inputs, sizeof(ZL_TypedRef*));
+    if (refs == NULL) {
+        return -1;
+    }
+
+    long long ret = -1;
+    size_t i;
+    for (i = 0; i < nb_inputs; i++) {
+        const openzl_typed_t* in = &inputs[i];
+        ZL_TypedRef* ref = NULL;
+        switch (in->type) {
+        case OPENZL_TYPE_SERIAL:
+            ref = ZL_TypedRef_createSerial(in->data, in->size);
+            break;
+        case OPENZL_TYPE_STRUCT:
+            if (in->width == 0) goto cleanup;
+            ref = ZL_TypedRef_createStruct(in->data, in->width, in->size / in->width);
+            break;
+        case OPENZL_TYPE_NUMERIC:
+            if (in->width == 0) goto cleanup;
+            ref = ZL_TypedRef_createNumeric(in->data, in->width, in->size / in->width);
+            break;
+        case OPENZL_TYPE_STRING:
+            ref = ZL_TypedRef_createString(in->data, in->size, in->lens, in->nb_strings);
+            break;
+        }
+        if (ref == NULL) {
+            goto cleanup;
+        }
+        refs[i] = ref;
+    }
+
+    {
+        ZL_Report result = ZL_CCtx_compressMultiTypedRef(ctx->cctx, dst, dst_capacity, refs, nb_inputs);
+        if (ZL_isError(result)) {
+            ret = -(long long)ZL_errorCode(result);
+        } else {
+            ret = (long long)ZL_validResult(result);
+        }
+    }
+
+cleanup:
+    for (i = 0; i < nb_inputs; i++) {
+        if (refs[i] != NULL) {
+            ZL_TypedRef_free((ZL_TypedRef*)refs[i]);
+        }
+    }
+    free((void*)refs);
+    return ret;
+}
+
+long long openzl_frame_num_outputs(const void* src, size_t src_size) {
+    ZL_FrameInfo* info = ZL_FrameInfo_create(src, src_size);
+    if (info == NULL) {
+        return -1;
+    }
+    ZL_Report result = ZL_FrameInfo_getNumOutputs(info);
+    ZL_FrameInfo_free(info);
+    if (ZL_isError(result)) {
+        return -(long long)ZL_errorCode(result);
+    }
+    return (long long)ZL_validResult(result);
+}
+
+long long openzl_decompress_typed(openzl_context_t* ctx,
+                                 const void* src, size_t src_size,
+                                 ZL_TypedBuffer** outputs, size_t nb_outputs) {
+    if (ctx == NULL || ctx->dctx == NULL) {
+        return -1;
+    }
+
+    size_t i;
+    for (i = 0; i < nb_outputs; i++) {
+        outputs[i] = ZL_TypedBuffer_create();
+        if (outputs[i] == NULL) {
+            return -1;
+        }
+    }
+
+    ZL_Report result = ZL_DCtx_decompressMultiTBuffer(ctx->dctx, outputs, nb_outputs, src, src_size);
+    if (ZL_isError(result)) {
+        return -(long long)ZL_errorCode(result);
+    }
+    return (long long)ZL_validResult(result);
+}
+
+// Maps a ZL_Type to an OPENZL_TYPE_* constant.
+static int openzl_type_of(ZL_Type type) {
+    switch (type) {
+    case ZL_Type_struct:  return OPENZL_TYPE_STRUCT;
+    case ZL_Type_numeric: return OPENZL_TYPE_NUMERIC;
+    case ZL_Type_string:  return OPENZL_TYPE_STRING;
+    default:              return OPENZL_TYPE_SERIAL;
+    }
+}
+
+void openzl_typed_buffer_describe(const ZL_TypedBuffer* buffer, openzl_typed_t* out) {
+    memset(out, 0, sizeof(*out));
+    out->data = ZL_TypedBuffer_rPtr(buffer);
+    out->size = ZL_TypedBuffer_byteSize(buffer);
+    switch (ZL_TypedBuffer_type(buffer)) {
+    case ZL_Type_struct:
+        out->type = OPENZL_TYPE_STRUCT;
+        out->width = ZL_TypedBuffer_eltWidth(buffer);
+        break;
+    case ZL_Type_numeric:
+        out->type = OPENZL_TYPE_NUMERIC;
+        out->width = ZL_TypedBuffer_eltWidth(buffer);
+        break;
+    case ZL_Type_string:
+        out->type = OPENZL_TYPE_STRING;
+        out->lens = ZL_TypedBuffer_rStringLens(buffer);
+        out->nb_strings = ZL_TypedBuffer_numElts(buffer);
+        break;
+    default:
+        out->type = OPENZL_TYPE_SERIAL;
+        break;
+    }
+}
+
 size_t openzl_compress_bound(size_t src_size) {
     return ZL_compressBound(src_size);
 }
+
+size_t openzl_compress_bound_batch(const openzl_buffer_t* srcs, size_t nb_srcs) {
+    size_t total = 0;
+    size_t i;
+    for (i = 0; i < nb_srcs; i++) {
+        if (srcs[i].size > 0) {
+            total += ZL_compressBound(srcs[i].size);
+        }
+    }
+    return total;
+}
+
+long long openzl_compress_batch(openzl_context_t* ctx,
+                               void* dst, size_t dst_capacity,
+                               const openzl_buffer_t* srcs, size_t nb_srcs,
+                               size_t* dst_sizes, size_t* failed) {
+    *failed = 0;
+    if (ctx == NULL || ctx->cctx == NULL) {
+        return -1;
+    }
+
+    char* out = (char*)dst;
+    size_t written = 0;
+    size_t i;
+    for (i = 0; i < nb_srcs; i++) {
+        dst_sizes[i] = 0;
+        if (srcs[i].size == 0) {
+            continue;
+        }
+        long long result = openzl_compress(ctx, out + written, dst_capacity - written,
+                                           srcs[i].data, srcs[i].size);
+        if (result < 0) {
+            *failed = i;
+            return result;
+        }
+        dst_sizes[i] = (size_t)result;
+        written += (size_t)result;
+    }
+    return (long long)written;
+}
+
+long long openzl_decompressed_size_batch(const openzl_buffer_t* srcs, size_t nb_srcs,
+                                         size_t* sizes, size_t* failed) {
+    *failed = 0;
+    size_t total = 0;
+    size_t i;
+    for (i = 0; i < nb_srcs; i++) {
+        sizes[i] = 0;
+        if (srcs[i].size == 0) {
+            continue;
+        }
+        ZL_Report result = ZL_getDecompressedSize(srcs[i].data, srcs[i].size);
+        if (ZL_isError(result)) {
+            *failed = i;
+            return -(long long)ZL_errorCode(result);
+        }
+        sizes[i] = ZL_validResult(result);
+        total += sizes[i];
+    }
+    return (long long)total;
+}
+
+long long openzl_decompress_batch(openzl_context_t* ctx,
+                                 void* dst, size_t dst_capacity,
+                                 const openzl_buffer_t* srcs, size_t nb_srcs,
+                                 const size_t* sizes, size_t* failed) {
+    *failed = 0;
+    if (ctx == NULL || ctx->dctx == NULL) {
+        return -1;
+    }
+
+    char* out = (char*)dst;
+    size_t written = 0;
+    size_t i;
+    for (i = 0; i < nb_srcs; i++) {
+        if (srcs[i].size == 0) {
+            continue;
+        }
+        if (sizes[i] > dst_capacity - written) {
+            *failed = i;
+            return -1;
+        }
+        long long result = openzl_decompress(ctx, out + written, sizes[i],
+                                             srcs[i].data, srcs[i].size);
+        if (result < 0) {
+            *failed = i;
+            return result;
+        }
+        if ((size_t)result != sizes[i]) {
+            // The frame header claimed a different size than it decoded to.
+            *failed = i;
+            return -1;
+        }
+        written += (size_t)result;
+    }
+    return (long long)written;
+}
+
+openzl_explain_t* openzl_explain_create() {
+    openzl_explain_t* explain = (openzl_explain_t*)calloc(1, sizeof(openzl_explain_t));
+    if (explain != NULL) {
+        explain->codec = -1;
+    }
+    return explain;
+}
+
+void openzl_explain_free(openzl_explain_t* explain) {
+    if (explain == NULL) {
+        return;
+    }
+    free(explain->nodes);
+    free(explain);
+}
+
+// Appends a node and returns its index, or -1 if it cannot be stored.
+static int openzl_explain_add(openzl_explain_t* explain, int kind, int parent, const char* name) {
+    if (explain->nb_nodes == explain->capacity) {
+        size_t capacity = explain->capacity ? 2 * explain->capacity : 32;
+        openzl_explain_node_t* nodes = (openzl_explain_node_t*)realloc(
+            explain->nodes, capacity * sizeof(openzl_explain_node_t));
+        if (nodes == NULL) {
+            explain->oom = 1;
+            return -1;
+        }
+        explain->nodes = nodes;
+        explain->capacity = capacity;
+    }
+    openzl_explain_node_t* node = &explain->nodes[explain->nb_nodes];
+    memset(node, 0, sizeof(*node));
+    node->kind = kind;
+    node->parent = parent;
+    if (name != NULL) {
+        strncpy(node->name, name, sizeof(node->name) - 1);
+    }
+    return (int)explain->nb_nodes++;
+}
+
+// Returns the innermost running graph that fits on the stack, or -1 if none
+// is running. Graphs nested deeper than the stack are attributed to it.
+static int openzl_explain_graph(const openzl_explain_t* explain) {
+    int max = (int)(sizeof(explain->graphs) / sizeof(explain->graphs[0]));
+    int depth = explain->depth < max ? explain->depth : max;
+    return depth > 0 ? explain->graphs[depth - 1] : -1;
+}
+
+static void openzl_on_graph_start(void* opaque, ZL_Graph* graph,
+                                  const ZL_Compressor* compressor, ZL_GraphID gid,
+                                  ZL_Edge* inputs[], size_t nb_inputs) {
+    (void)graph;
+    openzl_explain_t* explain = (openzl_explain_t*)opaque;
+
+    // A graph processing a stream produced by a codec becomes that stream's
+    // child; otherwise it belongs to the graph that is running it.
+    int parent = openzl_explain_graph(explain);
+    size_t size = 0;
+    int type = OPENZL_TYPE_SERIAL;
+    size_t i;
+    for (i = 0; i < nb_inputs; i++) {
+        const ZL_Input* in = ZL_Edge_getData(inputs[i]);
+        size += ZL_Input_contentSize(in);
+        if (i == 0) {
+            type = openzl_type_of(ZL_Input_type(in));
+            size_t n;
+            for (n = explain->nb_nodes; n > 0; n--) {
+                if (explain->nodes[n - 1].stream == (const void*)in) {
+                    parent = (int)(n - 1);
+                    break;
+                }
+            }
+        }
+    }
+
+    const char* name = compressor != NULL ? ZL_Compressor_Graph_getName(compressor, gid) : NULL;
+    int index = openzl_explain_add(explain, OPENZL_EXPLAIN_GRAPH, parent, name);
+    if (index >= 0) {
+        explain->nodes[index].type = type;
+        explain->nodes[index].size = size;
+    }
+    // Graphs nested deeper than the stack are not pushed; see
+    // openzl_explain_graph.
+    if (explain->depth < (int)(sizeof(explain->graphs) / sizeof(explain->graphs[0]))) {
+        explain->graphs[explain->depth] = index >= 0 ? index : parent;
+    }
+    explain->depth++;
+}
+
+static void openzl_on_graph_end(void* opaque, ZL_Graph* graph,
+                                ZL_GraphID successors[], size_t nb_successors,
+                                ZL_Report result) {
+    (void)graph;
+    (void)successors;
+    (void)nb_successors;
+    (void)result;
+    openzl_explain_t* explain = (openzl_explain_t*)opaque;
+    if (explain->depth > 0) {
+        explain->depth--;
+    }
+}
+
+static void openzl_on_codec_start(void* opaque, ZL_Encoder* encoder,
+                                  const ZL_Compressor* compressor, ZL_NodeID nid,
+                                  const ZL_Input* inputs[], size_t nb_inputs) {
+    (void)encoder;
+    openzl_explain_t* explain = (openzl_explain_t*)opaque;
+    int parent = openzl_explain_graph(explain);
+    const char* name = compressor != NULL ? ZL_Compressor_Node_getName(compressor, nid) : NULL;
+    int index = openzl_explain_add(explain, OPENZL_EXPLAIN_CODEC, parent, name);
+    explain->codec = index;
+    if (index < 0) {
+        return;
+    }
+    size_t i;
+    for (i = 0; i < nb_inputs; i++) {
+        explain->nodes[index].size += ZL_Input_contentSize(inputs[i]);
+    }
+    if (nb_inputs > 0) {
+        explain->nodes[index].type = openzl_type_of(ZL_Input_type(inputs[0]));
+    }
+}
+
+static void openzl_on_codec_end(void* opaque, ZL_Encoder* encoder,
+                                const ZL_Output* outputs[], size_t nb_outputs,
+                                ZL_Report result) {
+    (void)encoder;
+    openzl_explain_t* explain = (openzl_explain_t*)opaque;
+    int codec = explain->codec;
+    explain->codec = -1;
+    if (codec < 0 || ZL_isError(result)) {
+        return;
+    }
+    size_t i;
+    for (i = 0; i < nb_outputs; i++) {
+        int index = openzl_explain_add(explain, OPENZL_EXPLAIN_STREAM, codec, NULL);
+        if (index < 0) {
+            return;
+        }
+        ZL_Report size = ZL_Output_contentSize((ZL_Output*)outputs[i]);
+        explain->nodes[index].type = openzl_type_of(ZL_Output_type((ZL_Output*)outputs[i]));
+        explain->nodes[index].size = ZL_isError(size) ? 0 : ZL_validResult(size);
+        explain->nodes[index].stream = (const void*)outputs[i];
+    }
+}
+
+int openzl_explain_attach(openzl_context_t* ctx, openzl_explain_t* explain) {
+    if (ctx == NULL || ctx->cctx == NULL || explain == NULL) {
+        return -1;
+    }
+    ZL_CompressIntrospectionHooks hooks;
+    memset(&hooks, 0, sizeof(hooks));
+    hooks.opaque = explain;
+    hooks.on_migraphEncode_start = openzl_on_graph_start;
+    hooks.on_migraphEncode_end = openzl_on_graph_end;
+    hooks.on_codecEncode_start = openzl_on_codec_start;
+    hooks.on_codecEncode_end = openzl_on_codec_end;
+    ZL_Report result = ZL_CCtx_attachIntrospectionHooks(ctx->cctx, &hooks);
+    if (ZL_isError(result)) {
+        return -(int)ZL_errorCode(result);
+    }
+    return 0;
+}
+
+void openzl_explain_detach(openzl_context_t* ctx) {
+    if (ctx != NULL && ctx->cctx != NULL) {
+        ZL_CCtx_detachAllIntrospectionHooks(ctx->cctx);
+    }
+}
+
+void openzl_library_version(int* major, int* minor, int* patch) {
+    *major = ZL_LIBRARY_VERSION_MAJOR;
+    *minor = ZL_LIBRARY_VERSION_MINOR;
+    *patch = ZL_LIBRARY_VERSION_PATCH;
+}
+
+void openzl_format_versions(int* min, int* max) {
+    *min = ZL_MIN_FORMAT_VERSION;
+    *max = ZL_MAX_FORMAT_VERSION;
+}
@@ -2,21 +2,86 @@
 #ifndef OPENZL_H
 #define OPENZL_H
 
+// Fail early with instructions rather than with a missing-header error.
+#if defined(__has_include)
+#if !__has_include("openzl/openzl.h")
+#error "OpenZL headers not found. Run 'make build-openzl' to build the bundled library, or install OpenZL and build with '-tags openzl_system' (see README)."
+#endif
+#endif
+
 #include "openzl/openzl.h"
 #include "openzl/zl_compress.h"
 #include "openzl/zl_decompress.h"
 #include "openzl/zl_compressor.h"
+#include "openzl/zl_introspection.h"
+#include <stdint.h>
 #include <stdlib.h>
+#include <string.h>
+
+// Graph identifiers understood by openzl_context_set_graph. They are mapped
+// to OpenZL's standard graphs in openzl.c so that Go never depends on the
+// numeric values of ZL_GraphID.
+enum {
+    OPENZL_GRAPH_DEFAULT = 0,
+    OPENZL_GRAPH_STORE,
+    OPENZL_GRAPH_ZSTD,
+    OPENZL_GRAPH_GENERIC,
+    OPENZL_GRAPH_FIELD_LZ,
+    OPENZL_GRAPH_ENTROPY,
+    OPENZL_GRAPH_HUFFMAN,
+    OPENZL_GRAPH_FSE,
+    OPENZL_GRAPH_BITPACK,
+};
+
+// Input types understood by the typed compression functions. They are mapped
+// to ZL_Type in openzl.c.
+enum {
+    OPENZL_TYPE_SERIAL = 0,
+    OPENZL_TYPE_STRUCT,
+    OPENZL_TYPE_NUMERIC,
+    OPENZL_TYPE_STRING,
+};
+
+// Describes one typed input or output. For inputs the pointers refer to
+// (pinned) Go memory; for outputs they refer to a ZL_TypedBuffer.
+typedef struct {
+    int type;
+    const void* data;
+    size_t size;            // size of data in bytes
+    size_t width;           // element width for struct and numeric types
+    const uint32_t* lens;   // string lengths for the string type
+    size_t nb_strings;
+} openzl_typed_t;
+
+// Modes for openzl_context_create_mode, selecting which native contexts are
+// allocated. Functions that need a missing context fail with -1.
+enum {
+    OPENZL_MODE_COMPRESS = 1,
+    OPENZL_MODE_DECOMPRESS = 2,
+};
 
 typedef struct {
-    ZL_CCtx* cctx;
-    ZL_DCtx* dctx;
+    ZL_CCtx* cctx;             // NULL without OPENZL_MODE_COMPRESS
+    ZL_DCtx* dctx;             // NULL without OPENZL_MODE_DECOMPRESS
+    ZL_Compressor* compressor; // NULL when the default graph is selected
+    int level;                 // 0 selects ZL_COMPRESSIONLEVEL_DEFAULT
+    int format_version;        // 0 selects ZL_getDefaultEncodingVersion()
+    int graph;
+    int dirty;                 // compression parameters must be re-applied
 } openzl_context_t;
 
 openzl_context_t* openzl_context_create();
 
+openzl_context_t* openzl_context_create_mode(int mode);
+
 void openzl_context_free(openzl_context_t* ctx);
 
+int openzl_context_set_level(openzl_context_t* ctx, int level);
+
+int openzl_context_set_graph(openzl_context_t* ctx, int graph);
+
+int openzl_context_set_format_version(openzl_context_t* ctx, int version);
+
 long long openzl_compress(openzl_context_t* ctx, 
                          void* dst, size_t dst_capacity,
                          const void* src, size_t src_size);
@@ -25,6 +90,94 @@ long long openzl_decompress(openzl_context_t* ctx,
                            void* dst, size_t dst_capacity, 
                            const void* src, size_t src_size);
 
+long long openzl_compress_typed(openzl_context_t* ctx,
+                               void* dst, size_t dst_capacity,
+                               const openzl_typed_t* inputs, size_t nb_inputs);
+
+long long openzl_frame_num_outputs(const void* src, size_t src_size);
+
+long long openzl_decompress_typed(openzl_context_t* ctx,
+                                 const void* src, size_t src_size,
+                                 ZL_TypedBuffer** outputs, size_t nb_outputs);
+
+void openzl_typed_buffer_describe(const ZL_TypedBuffer* buffer, openzl_typed_t* out);
+
 size_t openzl_compress_bound(size_t src_size);
 
+// One buffer of a batch. data refers to (pinned) Go memory.
+typedef struct {
+    const void* data;
+    size_t size;
+} openzl_buffer_t;
+
+// Returns the sum of the compression bounds of srcs.
+size_t openzl_compress_bound_batch(const openzl_buffer_t* srcs, size_t nb_srcs);
+
+// Compresses each of srcs into its own frame, writing the frames one after
+// another into dst and their sizes into dst_sizes. Empty buffers produce
+// empty frames. Returns the total size written or a negative error code, in
+// which case *failed is the index of the buffer that could not be compressed.
+long long openzl_compress_batch(openzl_context_t* ctx,
+                               void* dst, size_t dst_capacity,
+                               const openzl_buffer_t* srcs, size_t nb_srcs,
+                               size_t* dst_sizes, size_t* failed);
+
+// Stores the decompressed size of each frame in sizes and returns their sum,
+// or a negative error code with *failed set as for openzl_compress_batch.
+long long openzl_decompressed_size_batch(const openzl_buffer_t* srcs, size_t nb_srcs,
+                                         size_t* sizes, size_t* failed);
+
+// Decompresses each frame of srcs into dst one after another; sizes holds
+// the decompressed sizes from openzl_decompressed_size_batch. Returns the
+// total size written or a negative error code with *failed set.
+long long openzl_decompress_batch(openzl_context_t* ctx,
+                                 void* dst, size_t dst_capacity,
+                                 const openzl_buffer_t* srcs, size_t nb_srcs,
+                                 const size_t* sizes, size_t* failed);
+
+// Kinds of openzl_explain_node_t.
+enum {
+    OPENZL_EXPLAIN_GRAPH = 0,
+    OPENZL_EXPLAIN_CODEC,
+    OPENZL_EXPLAIN_STREAM,
+};
+
+// One step of a compression recorded by the introspection hooks: a graph, a
+// codec run by a graph, or a stream produced by a codec.
+typedef struct {
+    int kind;
+    int parent;          // index of the parent node, -1 for roots
+    char name[64];       // graph or codec name; empty for streams
+    int type;            // OPENZL_TYPE_* of the stream, or of the first input
+    size_t size;         // stream bytes, or total input bytes of graphs and codecs
+    const void* stream;  // identity of a stream node, used to find its successor graphs
+} openzl_explain_node_t;
+
+typedef struct {
+    openzl_explain_node_t* nodes;
+    size_t nb_nodes;
+    size_t capacity;
+    int graphs[64];      // stack of running graphs
+    int depth;
+    int codec;           // running codec, -1 if none
+    int oom;             // a node could not be recorded
+} openzl_explain_t;
+
+openzl_explain_t* openzl_explain_create();
+
+void openzl_explain_free(openzl_explain_t* explain);
+
+// Records the graphs, codecs and streams of subsequent compressions with ctx
+// in explain. Fails if the library was built without introspection support
+// (ZL_ALLOW_INTROSPECTION).
+int openzl_explain_attach(openzl_context_t* ctx, openzl_explain_t* explain);
+
+void openzl_explain_detach(openzl_context_t* ctx);
+
+// Version of the OpenZL library the shim was compiled against.
+void openzl_library_version(int* major, int* minor, int* patch);
+
+// Range of frame format versions the library can read and write.
+void openzl_format_versions(int* min, int* max);
+
 #endif // OPENZL_H
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/gus3inov/openzl-go/openzl"
+	"github.com/gus3inov/openzl-go/openzl/archive"
+)
+
+var archiveCommands = []command{
+	{"create", "archive the contents of a directory", (*cli).archiveCreate},
+	{"list", "list the entries of an archive", (*cli).archiveList},
+	{"extract", "extract an archive or selected entries", (*cli).archiveExtract},
+}
+
+func (c *cli) archive(args []string) error {
+	if len(args) > 0 {
+		for _, cmd := range archiveCommands {
+			if cmd.name == args[0] {
+				return cmd.run(c, args[1:])
+			}
+		}
+	}
+	fmt.Fprintln(c.stderr, "Usage: openzl archive <command> [flags] archive ...")
+	fmt.Fprintln(c.stderr)
+	fmt.Fprintln(c.stderr, "Commands:")
+	for _, cmd := range archiveCommands {
+		fmt.Fprintf(c.stderr, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	return errUsage
+}
+
+func (c *cli) archiveCreate(args []string) error {
+	fs := c.newFlagSet("archive create", "archive dir")
+	var cf codecFlags
+	cf.register(fs, true)
+	force := fs.Bool("f", false, "overwrite an existing archive")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errUsage
+	}
+	opts, err := cf.options()
+	if err != nil {
+		return err
+	}
+	pool := openzl.NewPool(opts...)
+	defer pool.Close()
+
+	name, dir := fs.Arg(0), fs.Arg(1)
+	return c.writeOutput(name, *force, func(dst io.Writer) error {
+		w := archive.NewWriter(dst, archive.WithPool(pool),
+			archive.WithConcurrency(cf.jobs), archive.WithChunkSize(cf.chunkSize))
+		if err := w.AddDir(dir); err != nil {
+			w.Close()
+			return err
+		}
+		return w.Close()
+	})
+}
+
+// writeOutput creates the file out ("-" selects stdout) and runs fn over it.
+// A partially written file is removed on failure.
+func (c *cli) writeOutput(out string, force bool, fn func(dst io.Writer) error) error {
+	if out == "-" {
+		return fn(c.stdout)
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+	dst, err := os.OpenFile(out, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	err = fn(dst)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(out)
+	}
+	return err
+}
+
+func (c *cli) archiveList(args []string) error {
+	fs := c.newFlagSet("archive list", "archive")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	return openArchive(fs.Arg(0), 1, func(r *archive.Reader) error {
+		tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "mode\tsize\tcompressed\tmodified\tname")
+		for _, e := range r.Entries() {
+			name := e.Name
+			if e.Linkname != "" {
+				name += " -> " + e.Linkname
+			}
+			fmt.Fprintf(tw, "%v\t%d\t%d\t%s\t%s\n", e.Mode, e.Size, e.CompressedSize,
+				e.ModTime.Format("2006-01-02 15:04"), name)
+		}
+		return tw.Flush()
+	})
+}
+
+func (c *cli) archiveExtract(args []string) error {
+	fs := c.newFlagSet("archive extract", "archive [name ...]")
+	dir := fs.String("C", ".", "extract into `dir`")
+	jobs := fs.Int("j", 1, "number of concurrent `workers` (0 uses all cores)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	return openArchive(fs.Arg(0), *jobs, func(r *archive.Reader) error {
+		names := fs.Args()[1:]
+		if len(names) == 0 {
+			return r.ExtractAll(*dir)
+		}
+		for _, name := range names {
+			if err := r.Extract(name, *dir); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// openArchive opens the archive file name and runs fn over it.
+func openArchive(name string, jobs int, fn func(r *archive.Reader) error) error {
+	if name == "-" {
+		return errors.New("archives cannot be read from stdin")
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	r, err := archive.NewReader(f, info.Size(), archive.WithConcurrency(jobs))
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	defer r.Close()
+	return fn(r)
+}
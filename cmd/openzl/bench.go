@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gus3inov/openzl-go/openzl"
+	"github.com/gus3inov/openzl-go/openzl/bench"
+)
+
+func (c *cli) bench(args []string) error {
+	fs := c.newFlagSet("bench", "file|dir ...")
+	levels := fs.String("levels", "0", "comma-separated compression `levels`")
+	graphs := fs.String("graphs", "default", "comma-separated `graphs`: "+graphList())
+	modes := fs.String("modes", "serial", "comma-separated input `modes`: serial, numeric<1|2|4|8>, struct<width>")
+	compare := fs.String("compare", "", "comma-separated baseline `codecs` to compare against: gzip[:level], flate[:level]")
+	iterations := fs.Int("n", 3, "`iterations` per measurement; the fastest is reported")
+	asJSON := fs.Bool("json", false, "write results as JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+	if *iterations < 1 {
+		return errors.New("-n must be at least 1")
+	}
+
+	cfg := bench.Config{Iterations: *iterations}
+	for _, s := range splitList(*levels) {
+		level, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid level %q", s)
+		}
+		cfg.Levels = append(cfg.Levels, level)
+	}
+	for _, s := range splitList(*graphs) {
+		g, err := openzl.ParseGraph(s)
+		if err != nil {
+			return err
+		}
+		cfg.Graphs = append(cfg.Graphs, g)
+	}
+	for _, s := range splitList(*modes) {
+		m, err := bench.ParseMode(s)
+		if err != nil {
+			return err
+		}
+		cfg.Modes = append(cfg.Modes, m)
+	}
+	cfg.Baselines = splitList(*compare)
+
+	files, err := bench.LoadCorpus(fs.Args()...)
+	if err != nil {
+		return err
+	}
+	results, err := bench.Run(files, cfg)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return bench.WriteJSON(c.stdout, results)
+	}
+	return bench.WriteTable(c.stdout, results)
+}
+
+// splitList splits a comma-separated flag value, ignoring empty elements.
+func splitList(s string) []string {
+	var list []string
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			list = append(list, e)
+		}
+	}
+	return list
+}
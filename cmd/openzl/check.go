@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+// errTestFailed reports that at least one stream failed verification; the
+// individual failures have already been printed.
+var errTestFailed = errors.New("one or more streams are corrupt")
+
+func (c *cli) test(args []string) error {
+	fs := c.newFlagSet("test", "[file ...]")
+	jobs := fs.Int("j", 1, "number of concurrent `workers` (0 uses all cores)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	files, err := inputFiles(fs, "")
+	if err != nil {
+		return err
+	}
+	failed := false
+	for _, name := range files {
+		n, err := c.testFile(name, *jobs)
+		if err != nil {
+			failed = true
+			fmt.Fprintf(c.stdout, "%s: FAILED: %v\n", displayName(name), err)
+			continue
+		}
+		fmt.Fprintf(c.stdout, "%s: OK (%d bytes)\n", displayName(name), n)
+	}
+	if failed {
+		return errTestFailed
+	}
+	return nil
+}
+
+// testFile decompresses name, discarding the output, and returns the number
+// of decompressed bytes.
+func (c *cli) testFile(name string, jobs int) (int64, error) {
+	src, err := c.openInput(name)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	r, err := openzl.NewReader(src, openzl.WithConcurrency(jobs))
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	return io.Copy(io.Discard, r)
+}
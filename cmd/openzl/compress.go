@@ -0,0 +1,339 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/trace"
+	"strings"
+
+	"github.com/gus3inov/openzl-go/openzl"
+	"github.com/gus3inov/openzl-go/openzl/observe"
+)
+
+// suffix is appended to compressed file names.
+const suffix = ".ozl"
+
+// codecFlags holds the flags shared by commands that create contexts.
+type codecFlags struct {
+	level     int
+	graph     string
+	jobs      int
+	chunkSize int
+	progress  bool
+	trace     string
+	remove    bool
+	verify    bool
+}
+
+func (f *codecFlags) register(fs *flag.FlagSet, compress bool) {
+	if compress {
+		fs.IntVar(&f.level, "level", 0, "compression `level` (0 selects the library default)")
+		fs.StringVar(&f.graph, "graph", "default", "starting `graph`: "+graphList())
+		fs.IntVar(&f.chunkSize, "chunk-size", openzl.DefaultChunkSize, "uncompressed `bytes` per frame")
+	}
+	fs.IntVar(&f.jobs, "j", 1, "number of concurrent `workers` (0 uses all cores)")
+	fs.BoolVar(&f.progress, "progress", false, "report progress on stderr")
+	fs.StringVar(&f.trace, "trace", "", "write a runtime execution trace to `file`")
+	fs.BoolVar(&f.remove, "rm", false, "remove input files once their output is written")
+	fs.BoolVar(&f.verify, "verify", false, "check that the output round-trips before keeping it")
+}
+
+// checkFileOnly rejects -rm and -verify unless every input and output is a
+// file, as there is nothing to remove or read back otherwise.
+func (f *codecFlags) checkFileOnly(files []string, output string, toStdout bool) error {
+	if !f.remove && !f.verify {
+		return nil
+	}
+	if toStdout || output == "-" {
+		return errors.New("-rm and -verify cannot be used when writing to stdout")
+	}
+	for _, name := range files {
+		if name == "-" {
+			return errors.New("-rm and -verify cannot be used when reading stdin")
+		}
+	}
+	return nil
+}
+
+// fileOptions returns the options for compressing or decompressing one file
+// into another.
+func (f *codecFlags) fileOptions(force bool) []openzl.Option {
+	return []openzl.Option{
+		openzl.WithOverwrite(force),
+		openzl.WithRemoveSource(f.remove),
+		openzl.WithVerify(f.verify),
+	}
+}
+
+func (f *codecFlags) options() ([]openzl.Option, error) {
+	graph, err := openzl.ParseGraph(f.graph)
+	if err != nil {
+		return nil, err
+	}
+	return []openzl.Option{
+		openzl.WithLevel(f.level),
+		openzl.WithGraph(graph),
+		openzl.WithConcurrency(f.jobs),
+		openzl.WithChunkSize(f.chunkSize),
+	}, nil
+}
+
+// startTrace starts collecting a runtime trace if -trace is set. It returns
+// the options recording OpenZL operations in the trace and a function that
+// stops the trace and closes the file.
+func (f *codecFlags) startTrace() ([]openzl.Option, func() error, error) {
+	if f.trace == "" {
+		return nil, func() error { return nil }, nil
+	}
+	file, err := os.Create(f.trace)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := trace.Start(file); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	stop := func() error {
+		trace.Stop()
+		return file.Close()
+	}
+	return []openzl.Option{openzl.WithObserver(observe.Trace{})}, stop, nil
+}
+
+func graphList() string {
+	var names []string
+	for _, g := range openzl.Graphs() {
+		names = append(names, g.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+func (c *cli) compress(args []string) (err error) {
+	fs := c.newFlagSet("compress", "[file ...]")
+	var cf codecFlags
+	cf.register(fs, true)
+	output := fs.String("o", "", "write output to `file` (\"-\" for stdout)")
+	toStdout := fs.Bool("c", false, "write to stdout")
+	force := fs.Bool("f", false, "overwrite existing output files")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	opts, err := cf.options()
+	if err != nil {
+		return err
+	}
+	traceOpts, stopTrace, err := cf.startTrace()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if serr := stopTrace(); err == nil {
+			err = serr
+		}
+	}()
+	opts = append(opts, traceOpts...)
+
+	files, err := inputFiles(fs, *output)
+	if err != nil {
+		return err
+	}
+	if err := cf.checkFileOnly(files, *output, *toStdout); err != nil {
+		return err
+	}
+	for _, name := range files {
+		out := *output
+		if out == "" {
+			if *toStdout || name == "-" {
+				out = "-"
+			} else {
+				out = name + suffix
+			}
+		}
+		opts := append(opts, c.progress(&cf, name)...)
+		err := c.transform(name, out, *force, func(dst io.Writer, src io.Reader) error {
+			w, err := openzl.NewWriter(dst, opts...)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(w, src); err != nil {
+				w.Close()
+				return err
+			}
+			return w.Close()
+		}, func() error {
+			return openzl.CompressFile(name, out, append(opts, cf.fileOptions(*force)...)...)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *cli) decompress(args []string) (err error) {
+	fs := c.newFlagSet("decompress", "[file ...]")
+	var cf codecFlags
+	cf.register(fs, false)
+	output := fs.String("o", "", "write output to `file` (\"-\" for stdout)")
+	toStdout := fs.Bool("c", false, "write to stdout")
+	force := fs.Bool("f", false, "overwrite existing output files")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	files, err := inputFiles(fs, *output)
+	if err != nil {
+		return err
+	}
+	if err := cf.checkFileOnly(files, *output, *toStdout); err != nil {
+		return err
+	}
+	opts, stopTrace, err := cf.startTrace()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if serr := stopTrace(); err == nil {
+			err = serr
+		}
+	}()
+	opts = append(opts, openzl.WithConcurrency(cf.jobs))
+	for _, name := range files {
+		out := *output
+		if out == "" {
+			switch {
+			case *toStdout || name == "-":
+				out = "-"
+			case strings.HasSuffix(name, suffix) && len(name) > len(suffix):
+				out = strings.TrimSuffix(name, suffix)
+			default:
+				return fmt.Errorf("%s: unknown suffix, use -o or -c", name)
+			}
+		}
+		opts := append(c.progress(&cf, name), opts...)
+		err := c.transform(name, out, *force, func(dst io.Writer, src io.Reader) error {
+			r, err := openzl.NewReader(src, opts...)
+			if err != nil {
+				return err
+			}
+			defer r.Close()
+			_, err = io.Copy(dst, r)
+			return err
+		}, func() error {
+			return openzl.DecompressFile(name, out, append(opts, cf.fileOptions(*force)...)...)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// progress returns the options reporting the progress of processing name on
+// stderr if -progress is set.
+func (c *cli) progress(f *codecFlags, name string) []openzl.Option {
+	if !f.progress {
+		return nil
+	}
+	name = displayName(name)
+	return []openzl.Option{openzl.WithProgress(func(p openzl.Progress) {
+		end := ""
+		if p.Done {
+			end = "\n"
+		}
+		factor := "-"
+		if p.Ratio > 0 {
+			factor = fmt.Sprintf("%.2fx", p.Ratio)
+		}
+		fmt.Fprintf(c.stderr, "\r%s: %d -> %d bytes (%s)%s", name, p.BytesIn, p.BytesOut, factor, end)
+	})}
+}
+
+// inputFiles returns the positional arguments, defaulting to stdin.
+func inputFiles(fs *flag.FlagSet, output string) ([]string, error) {
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	if output != "" && len(files) > 1 {
+		return nil, errors.New("-o requires a single input file")
+	}
+	return files, nil
+}
+
+// transform converts the file in into the file out with file, which writes
+// the output atomically and preserves file metadata. If either is "-", for
+// stdin or stdout, it runs stream over them instead, writing a file output
+// through a temporary file that replaces out only on success.
+func (c *cli) transform(in, out string, force bool, stream func(dst io.Writer, src io.Reader) error, file func() error) error {
+	if in != "-" && out != "-" {
+		if err := file(); err != nil {
+			return fmt.Errorf("%s: %w", displayName(in), err)
+		}
+		return nil
+	}
+
+	src, err := c.openInput(in)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if out == "-" {
+		err = stream(c.stdout, src)
+	} else {
+		err = writeFile(out, force, func(dst io.Writer) error { return stream(dst, src) })
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", displayName(in), err)
+	}
+	return nil
+}
+
+// writeFile writes the output of fn to a temporary file next to name and
+// renames it to name once fn succeeds. Unless force is set, it fails if name
+// exists.
+func writeFile(name string, force bool, fn func(io.Writer) error) (err error) {
+	if _, err := os.Stat(name); err == nil && !force {
+		return &os.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	if err = fn(tmp); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(0o644); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (c *cli) openInput(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(c.stdin), nil
+	}
+	return os.Open(name)
+}
+
+func displayName(name string) string {
+	if name == "-" {
+		return "<stdin>"
+	}
+	return name
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+func (c *cli) inspect(args []string) error {
+	fs := c.newFlagSet("inspect", "[file ...]")
+	explain := fs.Bool("explain", false, "compress the (decompressed) data and show the graphs, codecs and streams used")
+	asJSON := fs.Bool("json", false, "print -explain output as JSON")
+	level := fs.Int("level", 0, "compression `level` for -explain (0 selects the library default)")
+	graphName := fs.String("graph", "default", "starting `graph` for -explain: "+graphList())
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	graph, err := openzl.ParseGraph(*graphName)
+	if err != nil {
+		return err
+	}
+	opts := []openzl.Option{openzl.WithLevel(*level), openzl.WithGraph(graph)}
+
+	files, err := inputFiles(fs, "")
+	if err != nil {
+		return err
+	}
+	for i, name := range files {
+		if i > 0 && !*asJSON {
+			fmt.Fprintln(c.stdout)
+		}
+		inspect := c.inspectFile
+		if *explain {
+			inspect = func(name string) error { return c.explainFile(name, *asJSON, opts) }
+		}
+		if err := inspect(name); err != nil {
+			return fmt.Errorf("%s: %w", displayName(name), err)
+		}
+	}
+	return nil
+}
+
+func (c *cli) inspectFile(name string) error {
+	src, err := c.openInput(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// Anything that does not start like a stream is treated as a single
+	// frame, such as the output of Context.Compress.
+	br := bufio.NewReader(src)
+	if prefix, _ := br.Peek(16); !openzl.IsStream(prefix) {
+		return c.inspectFrame(name, br)
+	}
+
+	info, err := openzl.InspectStream(br)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "file:\t%s\n", displayName(name))
+	fmt.Fprintf(tw, "stream version:\t%d\n", info.Version)
+	fmt.Fprintf(tw, "frames:\t%d\n", len(info.Blocks))
+	fmt.Fprintf(tw, "compressed size:\t%d bytes\n", info.CompressedSize)
+	fmt.Fprintf(tw, "decompressed size:\t%d bytes\n", info.DecompressedSize)
+	fmt.Fprintf(tw, "ratio:\t%s\n", ratio(info.DecompressedSize, info.CompressedSize))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(info.Blocks) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(c.stdout)
+	tw = tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "frame\tcompressed\tdecompressed\tratio\t")
+	for i, b := range info.Blocks {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%s\t\n", i, b.CompressedSize, b.DecompressedSize,
+			ratio(int64(b.DecompressedSize), int64(b.CompressedSize)))
+	}
+	return tw.Flush()
+}
+
+// explainFile compresses the contents of name, decompressing them first if
+// they are a stream or frame, and prints how OpenZL compressed them.
+func (c *cli) explainFile(name string, asJSON bool, opts []openzl.Option) error {
+	src, err := c.openInput(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return err
+	}
+	if data, err = decoded(data); err != nil {
+		return err
+	}
+
+	ctx, err := openzl.NewContext(opts...)
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+	_, explanation, err := ctx.CompressExplain(data)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			File string `json:"file"`
+			*openzl.Explanation
+		}{displayName(name), explanation})
+	}
+	fmt.Fprintf(c.stdout, "file: %s\n", displayName(name))
+	return explanation.WriteText(c.stdout)
+}
+
+// decoded returns the decompressed contents of a stream or frame, and any
+// other data unchanged.
+func decoded(data []byte) ([]byte, error) {
+	if openzl.IsStream(data) {
+		r, err := openzl.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	}
+	if _, err := openzl.DecompressedSize(data); err != nil {
+		return data, nil
+	}
+	ctx, err := openzl.NewDecompressor()
+	if err != nil {
+		return nil, err
+	}
+	defer ctx.Close()
+	if out, err := ctx.Decompress(data); err == nil {
+		return out, nil
+	}
+	return data, nil
+}
+
+// inspectFrame describes a bare OpenZL frame from its header.
+func (c *cli) inspectFrame(name string, r io.Reader) error {
+	frame, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	size, err := openzl.DecompressedSize(frame)
+	if err != nil {
+		return openzl.ErrInvalidStream
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "file:\t%s\n", displayName(name))
+	fmt.Fprintf(tw, "format:\tsingle frame\n")
+	fmt.Fprintf(tw, "compressed size:\t%d bytes\n", len(frame))
+	fmt.Fprintf(tw, "decompressed size:\t%d bytes\n", size)
+	fmt.Fprintf(tw, "ratio:\t%s\n", ratio(int64(size), int64(len(frame))))
+	return tw.Flush()
+}
+
+// ratio formats original/compressed as a compression factor.
+func ratio(original, compressed int64) string {
+	if compressed == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2fx", float64(original)/float64(compressed))
+}
@@ -0,0 +1,128 @@
+// Command openzl compresses, decompresses and inspects data in the OpenZL
+// stream format.
+//
+// Usage:
+//
+//	openzl <command> [flags] [file ...]
+//
+// The commands are:
+//
+//	compress    compress files or stdin
+//	decompress  decompress files or stdin
+//	inspect     print the frame layout of a stream
+//	bench       measure compression ratio and speed
+//	test        verify that streams decompress cleanly
+//	archive     create, list and extract archives
+//	version     print library versions and features
+//
+// A file name of "-", or no file at all, reads from stdin. Compressed files
+// get the ".ozl" suffix unless -o or -c is given.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// cli holds the standard streams so commands can be exercised in tests.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command struct {
+	name    string
+	summary string
+	run     func(c *cli, args []string) error
+}
+
+var commands = []command{
+	{"compress", "compress files or stdin", (*cli).compress},
+	{"decompress", "decompress files or stdin", (*cli).decompress},
+	{"inspect", "print the frame layout of a stream", (*cli).inspect},
+	{"bench", "measure compression ratio and speed", (*cli).bench},
+	{"test", "verify that streams decompress cleanly", (*cli).test},
+	{"archive", "create, list and extract archives", (*cli).archive},
+	{"version", "print library versions and features", (*cli).version},
+}
+
+// errUsage signals that usage information has already been printed.
+var errUsage = errors.New("usage")
+
+func main() {
+	c := &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(c.run(os.Args[1:]))
+}
+
+// run executes the command line and returns the process exit code.
+func (c *cli) run(args []string) int {
+	if len(args) == 0 {
+		c.usage(c.stderr)
+		return 2
+	}
+
+	name := args[0]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		c.usage(c.stdout)
+		return 0
+	}
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		err := cmd.run(c, args[1:])
+		switch {
+		case err == nil:
+			return 0
+		case errors.Is(err, flag.ErrHelp):
+			return 0
+		case errors.Is(err, errUsage):
+			return 2
+		default:
+			fmt.Fprintf(c.stderr, "openzl %s: %v\n", name, err)
+			return 1
+		}
+	}
+
+	fmt.Fprintf(c.stderr, "openzl: unknown command %q\n", name)
+	fmt.Fprintln(c.stderr, "Run 'openzl help' for usage.")
+	return 2
+}
+
+func (c *cli) usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: openzl <command> [flags] [file ...]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-11s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'openzl <command> -h' for command flags.")
+}
+
+// newFlagSet returns a flag set whose errors and usage go to stderr.
+func (c *cli) newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: openzl %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args, mapping flag errors to errUsage since the flag
+// package has already reported them.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
@@ -0,0 +1,444 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// runCLI runs the command line with the given stdin and returns the exit
+// code and the captured stdout and stderr.
+func runCLI(t *testing.T, stdin []byte, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	c := &cli{stdin: bytes.NewReader(stdin), stdout: &stdout, stderr: &stderr}
+	code := c.run(args)
+	return code, stdout.String(), stderr.String()
+}
+
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if got != string(want) {
+		t.Fatalf("output does not match %s\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	hello, err := os.ReadFile("testdata/hello.txt")
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	_, compressed, _ := runCLI(t, hello, "compress")
+
+	testCases := []struct {
+		name  string
+		args  []string
+		stdin []byte
+		code  int
+	}{
+		{name: "no-args", args: nil, code: 2},
+		{name: "help", args: []string{"help"}, code: 0},
+		{name: "unknown-command", args: []string{"frobnicate"}, code: 2},
+		{name: "inspect-invalid", args: []string{"inspect", "testdata/hello.txt"}, code: 1},
+		{name: "compress-bad-graph", args: []string{"compress", "-graph", "nope"}, code: 1},
+		{name: "compress-bad-flag", args: []string{"compress", "-nope"}, code: 2},
+		{name: "decompress-bad-suffix", args: []string{"decompress", "testdata/hello.txt"}, code: 1},
+		{name: "test-stdin", args: []string{"test"}, stdin: []byte(compressed), code: 0},
+		{name: "test-corrupt", args: []string{"test", "testdata/truncated.ozl", "testdata/hello.txt"}, code: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, stdout, stderr := runCLI(t, tc.stdin, tc.args...)
+			if code != tc.code {
+				t.Fatalf("exit code = %d, want %d (stderr: %s)", code, tc.code, stderr)
+			}
+			checkGolden(t, tc.name, "stdout:\n"+stdout+"stderr:\n"+stderr)
+		})
+	}
+}
+
+func TestRoundTripStdio(t *testing.T) {
+	data := bytes.Repeat([]byte("openzl command-line round trip\n"), 5000)
+
+	for _, args := range [][]string{
+		{"compress"},
+		{"compress", "-graph", "zstd", "-level", "3"},
+		{"compress", "-j", "4", "-chunk-size", "4096"},
+	} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			code, compressed, stderr := runCLI(t, data, args...)
+			if code != 0 {
+				t.Fatalf("%v failed: %s", args, stderr)
+			}
+			code, decompressed, stderr := runCLI(t, []byte(compressed), "decompress", "-j", "2")
+			if code != 0 {
+				t.Fatalf("decompress failed: %s", stderr)
+			}
+			if decompressed != string(data) {
+				t.Fatal("Data integrity check failed")
+			}
+		})
+	}
+}
+
+func TestRoundTripFiles(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "data.txt")
+	data := bytes.Repeat([]byte("file round trip\n"), 1000)
+	if err := os.WriteFile(input, data, 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	if code, _, stderr := runCLI(t, nil, "compress", input); code != 0 {
+		t.Fatalf("compress failed: %s", stderr)
+	}
+	if code, _, _ := runCLI(t, nil, "compress", input); code != 1 {
+		t.Fatal("compress should refuse to overwrite an existing output without -f")
+	}
+	if code, _, stderr := runCLI(t, nil, "compress", "-f", input); code != 0 {
+		t.Fatalf("compress -f failed: %s", stderr)
+	}
+
+	if err := os.Remove(input); err != nil {
+		t.Fatalf("Remove() failed: %v", err)
+	}
+	if code, _, stderr := runCLI(t, nil, "decompress", input+suffix); code != 0 {
+		t.Fatalf("decompress failed: %s", stderr)
+	}
+	got, err := os.ReadFile(input)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data integrity check failed")
+	}
+}
+
+func TestRemoveVerify(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "data.txt")
+	data := bytes.Repeat([]byte("remove and verify\n"), 1000)
+	if err := os.WriteFile(input, data, 0o600); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	if code, _, stderr := runCLI(t, nil, "compress", "-rm", "-verify", input); code != 0 {
+		t.Fatalf("compress -rm -verify failed: %s", stderr)
+	}
+	if _, err := os.Stat(input); !os.IsNotExist(err) {
+		t.Fatalf("input still exists after compress -rm: %v", err)
+	}
+	if code, _, stderr := runCLI(t, nil, "decompress", "-rm", "-verify", input+suffix); code != 0 {
+		t.Fatalf("decompress -rm -verify failed: %s", stderr)
+	}
+	if _, err := os.Stat(input + suffix); !os.IsNotExist(err) {
+		t.Fatalf("compressed file still exists after decompress -rm: %v", err)
+	}
+	got, err := os.ReadFile(input)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data integrity check failed")
+	}
+	if info, err := os.Stat(input); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("restored file has mode %v (%v), want 0600", info.Mode(), err)
+	}
+
+	// Without files on both sides there is nothing to remove or read back.
+	for _, args := range [][]string{
+		{"compress", "-rm"},
+		{"compress", "-verify", "-c", input},
+		{"decompress", "-verify", "-o", "-", input + suffix},
+	} {
+		if code, _, _ := runCLI(t, data, args...); code != 1 {
+			t.Errorf("%v should fail", args)
+		}
+	}
+	if _, err := os.Stat(input); err != nil {
+		t.Fatalf("input removed by a rejected command: %v", err)
+	}
+}
+
+func TestStdinToFile(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "out.ozl")
+	data := []byte("from stdin\n")
+	if code, _, stderr := runCLI(t, data, "compress", "-o", output); code != 0 {
+		t.Fatalf("compress -o failed: %s", stderr)
+	}
+	if code, _, _ := runCLI(t, data, "compress", "-o", output); code != 1 {
+		t.Fatal("compress should refuse to overwrite an existing output without -f")
+	}
+	if code, _, stderr := runCLI(t, data, "compress", "-f", "-o", output); code != 0 {
+		t.Fatalf("compress -f -o failed: %s", stderr)
+	}
+	code, stdout, stderr := runCLI(t, nil, "decompress", "-c", output)
+	if code != 0 || stdout != string(data) {
+		t.Fatalf("decompress -c = %q (%s), want %q", stdout, stderr, data)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	data := bytes.Repeat([]byte("progress\n"), 1000)
+	code, compressed, stderr := runCLI(t, data, "compress", "-progress")
+	if code != 0 {
+		t.Fatalf("compress -progress failed: %s", stderr)
+	}
+	want := fmt.Sprintf("<stdin>: %d -> %d bytes (", len(data), len(compressed))
+	if !strings.Contains(stderr, want) || !strings.HasSuffix(stderr, "x)\n") {
+		t.Fatalf("stderr = %q, want a final report containing %q", stderr, want)
+	}
+
+	code, _, stderr = runCLI(t, []byte(compressed), "decompress", "-progress")
+	if code != 0 {
+		t.Fatalf("decompress -progress failed: %s", stderr)
+	}
+	want = fmt.Sprintf("<stdin>: %d -> %d bytes (", len(compressed), len(data))
+	if !strings.Contains(stderr, want) {
+		t.Fatalf("stderr = %q, want a final report containing %q", stderr, want)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.out")
+	code, _, stderr := runCLI(t, []byte("traced\n"), "compress", "-trace", path)
+	if code != 0 {
+		t.Fatalf("compress -trace failed: %s", stderr)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() == 0 {
+		t.Error("trace file is empty")
+	}
+}
+
+func TestBench(t *testing.T) {
+	code, stdout, stderr := runCLI(t, nil, "bench", "-n", "1", "-graphs", "default,store", "testdata/hello.txt")
+	if code != 0 {
+		t.Fatalf("bench failed: %s", stderr)
+	}
+	if got := strings.Count(stdout, "testdata/hello.txt"); got != 2 {
+		t.Fatalf("bench printed %d result rows, want 2:\n%s", got, stdout)
+	}
+}
+
+func TestBenchJSON(t *testing.T) {
+	code, stdout, stderr := runCLI(t, nil, "bench", "-n", "1", "-compare", "gzip,flate:1", "-json", "testdata/hello.txt")
+	if code != 0 {
+		t.Fatalf("bench failed: %s", stderr)
+	}
+	var results []struct {
+		Codec string `json:"codec"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+		t.Fatalf("bench -json output is not JSON: %v\n%s", err, stdout)
+	}
+	if len(results) != 3 {
+		t.Fatalf("bench -json returned %d results, want 3", len(results))
+	}
+	for _, r := range results {
+		if r.Error != "" {
+			t.Fatalf("%s: %s", r.Codec, r.Error)
+		}
+	}
+}
+
+// writeSampleStream writes a three-frame stream produced by openzl.Writer to
+// a temporary directory and returns its path and uncompressed contents.
+func writeSampleStream(t *testing.T) (string, []byte) {
+	t.Helper()
+	hello, err := os.ReadFile("testdata/hello.txt")
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	data := bytes.Repeat(hello, 4196/len(hello)+1)[:4196]
+
+	var buf bytes.Buffer
+	w, err := openzl.NewWriter(&buf, openzl.WithChunkSize(2048))
+	if err != nil {
+		t.Fatalf("NewWriter() failed: %v", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "sample.ozl")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	return path, data
+}
+
+// inspectFields parses the "key: value" summary printed by inspect.
+func inspectFields(out string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			fields[key] = strings.TrimSpace(value)
+		}
+	}
+	return fields
+}
+
+func TestInspect(t *testing.T) {
+	path, data := writeSampleStream(t)
+
+	code, stdout, stderr := runCLI(t, nil, "inspect", path)
+	if code != 0 {
+		t.Fatalf("inspect failed: %s", stderr)
+	}
+	fields := inspectFields(stdout)
+	if fields["frames"] != "3" {
+		t.Fatalf("inspect reported %q frames, want 3:\n%s", fields["frames"], stdout)
+	}
+	if want := fmt.Sprintf("%d bytes", len(data)); fields["decompressed size"] != want {
+		t.Fatalf("inspect reported decompressed size %q, want %q", fields["decompressed size"], want)
+	}
+	if !strings.Contains(stdout, "frame  compressed  decompressed") {
+		t.Fatalf("inspect did not list frames:\n%s", stdout)
+	}
+
+	// The sample is a real stream, so it also passes the integrity check.
+	if code, _, stderr := runCLI(t, nil, "test", path); code != 0 {
+		t.Fatalf("test failed on sample stream: %s", stderr)
+	}
+}
+
+func TestInspectFrame(t *testing.T) {
+	ctx, err := openzl.NewContext()
+	if err != nil {
+		t.Fatalf("NewContext() failed: %v", err)
+	}
+	defer ctx.Close()
+
+	data := bytes.Repeat([]byte("a bare frame from Context.Compress\n"), 100)
+	frame, err := ctx.Compress(data)
+	if err != nil {
+		t.Fatalf("Compress() failed: %v", err)
+	}
+
+	code, stdout, stderr := runCLI(t, frame, "inspect")
+	if code != 0 {
+		t.Fatalf("inspect failed: %s", stderr)
+	}
+	fields := inspectFields(stdout)
+	if fields["format"] != "single frame" {
+		t.Fatalf("inspect reported format %q, want single frame:\n%s", fields["format"], stdout)
+	}
+	if want := fmt.Sprintf("%d bytes", len(data)); fields["decompressed size"] != want {
+		t.Fatalf("inspect reported decompressed size %q, want %q", fields["decompressed size"], want)
+	}
+}
+
+func TestInspectExplain(t *testing.T) {
+	path, _ := writeSampleStream(t)
+
+	code, stdout, stderr := runCLI(t, nil, "inspect", "-explain", path)
+	if code != 0 {
+		if strings.Contains(stderr, "introspection") {
+			t.Skip(stderr)
+		}
+		t.Fatalf("inspect -explain failed: %s", stderr)
+	}
+	if !strings.Contains(stdout, "frame: ") || !strings.Contains(stdout, "graph ") {
+		t.Fatalf("inspect -explain printed no tree:\n%s", stdout)
+	}
+
+	code, stdout, stderr = runCLI(t, nil, "inspect", "-explain", "-json", path)
+	if code != 0 {
+		t.Fatalf("inspect -explain -json failed: %s", stderr)
+	}
+	var out struct {
+		File  string            `json:"file"`
+		Nodes []json.RawMessage `json:"nodes"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &out); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if out.File != path || len(out.Nodes) == 0 {
+		t.Fatalf("unexpected JSON:\n%s", stdout)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	src := t.TempDir()
+	data := bytes.Repeat([]byte("archived by the openzl command\n"), 500)
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "data.txt"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "test.ozla")
+
+	if code, _, stderr := runCLI(t, nil, "archive", "create", "-j", "2", path, src); code != 0 {
+		t.Fatalf("archive create failed: %s", stderr)
+	}
+	if code, _, _ := runCLI(t, nil, "archive", "create", path, src); code != 1 {
+		t.Fatal("archive create should refuse to overwrite an existing archive without -f")
+	}
+
+	code, stdout, stderr := runCLI(t, nil, "archive", "list", path)
+	if code != 0 {
+		t.Fatalf("archive list failed: %s", stderr)
+	}
+	if !strings.Contains(stdout, "-rw-------") || !strings.Contains(stdout, "sub/data.txt") {
+		t.Fatalf("archive list output is missing the file:\n%s", stdout)
+	}
+
+	dst := t.TempDir()
+	if code, _, stderr := runCLI(t, nil, "archive", "extract", "-C", dst, path, "sub/data.txt"); code != 0 {
+		t.Fatalf("archive extract failed: %s", stderr)
+	}
+	got, err := os.ReadFile(filepath.Join(dst, "sub", "data.txt"))
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data integrity check failed")
+	}
+
+	if code, _, _ := runCLI(t, nil, "archive", "frobnicate"); code != 2 {
+		t.Fatal("archive with an unknown command should exit with status 2")
+	}
+}
+
+func TestVersion(t *testing.T) {
+	code, stdout, stderr := runCLI(t, nil, "version")
+	if code != 0 {
+		t.Fatalf("version failed: %s", stderr)
+	}
+	for _, want := range []string{openzl.BindingVersion, openzl.Version().Library(), "format versions:", "graphs:", "zstd", "codecs:"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("version output does not contain %q:\n%s", want, stdout)
+		}
+	}
+	if code, _, _ := runCLI(t, nil, "version", "extra"); code != 2 {
+		t.Fatalf("version with arguments exited with %d, want 2", code)
+	}
+}
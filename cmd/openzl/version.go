@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+func (c *cli) version(args []string) error {
+	fs := c.newFlagSet("version", "")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	v := openzl.Version()
+	f := openzl.Features()
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "openzl-go:\t%s\n", v.Binding)
+	fmt.Fprintf(tw, "library:\t%s\n", v.Library())
+	if f.Native {
+		fmt.Fprintf(tw, "format versions:\t%d-%d\n", v.MinFormatVersion, v.MaxFormatVersion)
+	}
+	fmt.Fprintf(tw, "graphs:\t%s\n", join(f.Graphs))
+	codecs := "none"
+	if len(f.Codecs) > 0 {
+		codecs = strings.Join(f.Codecs, ", ")
+	}
+	fmt.Fprintf(tw, "codecs:\t%s\n", codecs)
+	fmt.Fprintf(tw, "input types:\t%s\n", join(f.Types))
+	return tw.Flush()
+}
+
+// join formats values as a comma-separated list, or "none".
+func join[T fmt.Stringer](values []T) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	names := make([]string, len(values))
+	for i, v := range values {
+		names[i] = v.String()
+	}
+	return strings.Join(names, ", ")
+}
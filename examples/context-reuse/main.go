@@ -19,7 +19,8 @@ import (
 
 func main() {
 	fmt.Println("OpenZL Context Reuse Performance Demonstration")
-	fmt.Println("===============================================\n")
+	fmt.Println("===============================================")
+	fmt.Println()
 
 	// Prepare test data
 	data := bytes.Repeat([]byte("Performance test data for OpenZL context reuse demonstration. "), 1000)
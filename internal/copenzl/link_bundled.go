@@ -0,0 +1,13 @@
+//go:build cgo && !openzl_system && !openzl_static
+
+package copenzl
+
+// By default the bindings link the shared library built from the
+// third_party/openzl submodule by scripts/build-openzl.sh, found at run time
+// through an rpath into the module directory.
+
+/*
+#cgo CFLAGS: -I${SRCDIR}/../../third_party/openzl/include
+#cgo LDFLAGS: -L${SRCDIR}/../../third_party/openzl/build -lopenzl -Wl,-rpath,${SRCDIR}/../../third_party/openzl/build
+*/
+import "C"
@@ -0,0 +1,14 @@
+//go:build cgo && openzl_static && !openzl_system
+
+package copenzl
+
+// The openzl_static tag links the static libraries built from the submodule
+// by "scripts/build-openzl.sh --static", producing binaries that do not
+// depend on libopenzl at run time. The build/static directory holds only
+// archives, so -l resolves to them on every platform.
+
+/*
+#cgo CFLAGS: -I${SRCDIR}/../../third_party/openzl/include
+#cgo LDFLAGS: -L${SRCDIR}/../../third_party/openzl/build/static -lopenzl -lzstd -lm
+*/
+import "C"
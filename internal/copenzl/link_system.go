@@ -0,0 +1,12 @@
+//go:build cgo && openzl_system
+
+package copenzl
+
+// The openzl_system tag links a system-wide OpenZL installation found through
+// pkg-config, which makes the module usable with "go get" without building
+// the submodule. PKG_CONFIG_PATH selects non-standard install prefixes.
+
+/*
+#cgo pkg-config: openzl
+*/
+import "C"
@@ -0,0 +1,106 @@
+//go:build !cgo
+
+package copenzl
+
+import (
+	"errors"
+
+	"github.com/gus3inov/openzl-go/openzl/purezl"
+)
+
+// Without cgo the OpenZL library cannot be called. Decompression falls back
+// to the pure-Go decoder, which returns purezl.ErrUnsupported for frames it
+// cannot decode; everything else fails with errNoCgo.
+
+var errNoCgo = errors.New("openzl: built without cgo; compression requires the OpenZL library (set CGO_ENABLED=1)")
+
+// Native reports whether the package calls the native OpenZL library.
+const Native = false
+
+type OpenZLContext struct{}
+
+func NewOpenZLContext() (*OpenZLContext, error) {
+	return &OpenZLContext{}, nil
+}
+
+func NewOpenZLContextMode(mode int) (*OpenZLContext, error) {
+	if mode&(ModeCompress|ModeDecompress) == 0 {
+		return nil, errors.New("failed to create OpenZL context")
+	}
+	return &OpenZLContext{}, nil
+}
+
+func (c *OpenZLContext) Close() {}
+
+func (c *OpenZLContext) SetLevel(level int) error {
+	return errNoCgo
+}
+
+func (c *OpenZLContext) SetGraph(graph int) error {
+	return errNoCgo
+}
+
+func (c *OpenZLContext) SetFormatVersion(version int) error {
+	return errNoCgo
+}
+
+func OpenZLCompress(ctx *OpenZLContext, data []byte) ([]byte, error) {
+	return nil, errNoCgo
+}
+
+func OpenZLCompressExplain(ctx *OpenZLContext, data []byte) ([]byte, []ExplainNode, error) {
+	return nil, nil, errNoCgo
+}
+
+func OpenZLDecompressedSize(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, errors.New("empty frame")
+	}
+	return purezl.DecompressedSize(data)
+}
+
+func OpenZLFrameNumOutputs(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, errors.New("empty frame")
+	}
+	return 0, purezl.ErrUnsupported
+}
+
+func OpenZLDecompress(ctx *OpenZLContext, data []byte) ([]byte, error) {
+	return purezl.Decompress(data)
+}
+
+func OpenZLCompressBatch(ctx *OpenZLContext, srcs [][]byte) ([][]byte, error) {
+	return nil, errNoCgo
+}
+
+func OpenZLDecompressBatch(ctx *OpenZLContext, frames [][]byte) ([][]byte, error) {
+	out := make([][]byte, len(frames))
+	for i, frame := range frames {
+		b, err := purezl.Decompress(frame)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = b
+	}
+	return out, nil
+}
+
+func OpenZLCompressTyped(ctx *OpenZLContext, inputs []TypedBuffer) ([]byte, error) {
+	return nil, errNoCgo
+}
+
+func OpenZLDecompressTyped(ctx *OpenZLContext, data []byte) ([]TypedBuffer, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty frame")
+	}
+	return nil, purezl.ErrUnsupported
+}
+
+func LibraryVersion() (major, minor, patch int) {
+	return 0, 0, 0
+}
+
+func FormatVersions() (min, max int) {
+	return 0, 0
+}
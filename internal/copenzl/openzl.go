@@ -3,11 +3,12 @@
 // This package contains the cgo declarations and C type mappings
 // for the OpenZL library. It should not be used directly by users
 // of the openzl package.
+//
+// How the library is linked is selected with build tags; see link_*.go.
 package copenzl
 
 /*
-#cgo CFLAGS: -I${SRCDIR}/../../cgo -I${SRCDIR}/../../third_party/openzl/include
-#cgo LDFLAGS: -L${SRCDIR}/../../third_party/openzl/build -lopenzl -Wl,-rpath,${SRCDIR}/../../third_party/openzl/build
+#cgo CFLAGS: -I${SRCDIR}/../../cgo
 
 #include "../../cgo/openzl.h"
 #include "../../cgo/openzl.c"
@@ -16,6 +17,7 @@ import "C"
 import (
 	"errors"
 	"fmt"
+	"runtime"
 	"unsafe"
 )
 
@@ -24,7 +26,13 @@ type OpenZLContext struct {
 }
 
 func NewOpenZLContext() (*OpenZLContext, error) {
-	ctx := C.openzl_context_create()
+	return NewOpenZLContextMode(ModeCompress | ModeDecompress)
+}
+
+// NewOpenZLContextMode creates a context holding only the native state for
+// the given Mode* flags. Operations of a mode that was not requested fail.
+func NewOpenZLContextMode(mode int) (*OpenZLContext, error) {
+	ctx := C.openzl_context_create_mode(C.int(mode))
 	if ctx == nil {
 		return nil, errors.New("failed to create OpenZL context")
 	}
@@ -38,6 +46,42 @@ func (c *OpenZLContext) Close() {
 	}
 }
 
+// SetLevel sets the compression level used by subsequent compressions.
+// Level 0 selects the library default.
+func (c *OpenZLContext) SetLevel(level int) error {
+	if c == nil || c.ctx == nil {
+		return errors.New("invalid context")
+	}
+	if result := C.openzl_context_set_level(c.ctx, C.int(level)); result < 0 {
+		return fmt.Errorf("failed to set compression level %d: error code %d", level, -result)
+	}
+	return nil
+}
+
+// SetGraph selects the OPENZL_GRAPH_* starting graph used by subsequent
+// compressions.
+func (c *OpenZLContext) SetGraph(graph int) error {
+	if c == nil || c.ctx == nil {
+		return errors.New("invalid context")
+	}
+	if result := C.openzl_context_set_graph(c.ctx, C.int(graph)); result < 0 {
+		return fmt.Errorf("failed to select graph %d: error code %d", graph, -result)
+	}
+	return nil
+}
+
+// SetFormatVersion selects the frame format version written by subsequent
+// compressions. Version 0 selects the library default.
+func (c *OpenZLContext) SetFormatVersion(version int) error {
+	if c == nil || c.ctx == nil {
+		return errors.New("invalid context")
+	}
+	if result := C.openzl_context_set_format_version(c.ctx, C.int(version)); result < 0 {
+		return fmt.Errorf("failed to select format version %d: error code %d", version, -result)
+	}
+	return nil
+}
+
 func OpenZLCompress(ctx *OpenZLContext, data []byte) ([]byte, error) {
 	if ctx == nil || ctx.ctx == nil {
 		return nil, errors.New("invalid context")
@@ -78,6 +122,34 @@ func OpenZLCompress(ctx *OpenZLContext, data []byte) ([]byte, error) {
 	return compressed[:actualSize], nil
 }
 
+// OpenZLDecompressedSize returns the decompressed size recorded in a frame
+// header without decompressing it.
+func OpenZLDecompressedSize(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, errors.New("empty frame")
+	}
+
+	sizeResult := C.ZL_getDecompressedSize(unsafe.Pointer(&data[0]), C.size_t(len(data)))
+	if C.ZL_isError(sizeResult) != 0 {
+		return 0, fmt.Errorf("failed to get decompressed size: error code %d", C.ZL_errorCode(sizeResult))
+	}
+	return int(C.ZL_validResult(sizeResult)), nil
+}
+
+// OpenZLFrameNumOutputs returns the number of outputs (streams) recorded in a
+// frame header.
+func OpenZLFrameNumOutputs(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, errors.New("empty frame")
+	}
+
+	n := C.openzl_frame_num_outputs(unsafe.Pointer(&data[0]), C.size_t(len(data)))
+	if n < 0 {
+		return 0, fmt.Errorf("failed to read frame header: error code %d", -n)
+	}
+	return int(n), nil
+}
+
 // OpenZLDecompress decompresses data using the C API.
 func OpenZLDecompress(ctx *OpenZLContext, data []byte) ([]byte, error) {
 	if ctx == nil || ctx.ctx == nil {
@@ -96,17 +168,303 @@ func OpenZLDecompress(ctx *OpenZLContext, data []byte) ([]byte, error) {
 	decompressedSize := int(C.ZL_validResult(sizeResult))
 	decompressed := make([]byte, decompressedSize)
 
-	result := C.ZL_decompress(
-		unsafe.Pointer(&decompressed[0]),
+	var dstPtr unsafe.Pointer
+	if len(decompressed) > 0 {
+		dstPtr = unsafe.Pointer(&decompressed[0])
+	}
+
+	result := C.openzl_decompress(
+		ctx.ctx,
+		dstPtr,
 		C.size_t(len(decompressed)),
 		unsafe.Pointer(&data[0]),
 		C.size_t(len(data)),
 	)
 
-	if C.ZL_isError(result) != 0 {
-		return nil, fmt.Errorf("decompression failed with error code %d", C.ZL_errorCode(result))
+	if result < 0 {
+		return nil, fmt.Errorf("decompression failed with error code %d", -result)
 	}
 
-	actualSize := int(C.ZL_validResult(result))
+	actualSize := int(result)
 	return decompressed[:actualSize], nil
 }
+
+// OpenZLCompressTyped compresses one or more typed inputs into a single frame.
+func OpenZLCompressTyped(ctx *OpenZLContext, inputs []TypedBuffer) ([]byte, error) {
+	if ctx == nil || ctx.ctx == nil {
+		return nil, errors.New("invalid context")
+	}
+	if len(inputs) == 0 {
+		return nil, errors.New("no inputs")
+	}
+
+	// The descriptors live in C memory and point at pinned Go buffers, which
+	// keeps the call within the cgo pointer-passing rules.
+	descs := unsafe.Slice((*C.openzl_typed_t)(C.calloc(C.size_t(len(inputs)), C.sizeof_openzl_typed_t)), len(inputs))
+	defer C.free(unsafe.Pointer(&descs[0]))
+
+	var pinner runtime.Pinner
+	defer pinner.Unpin()
+
+	total := 0
+	for i, in := range inputs {
+		d := &descs[i]
+		d._type = C.int(in.Type)
+		if len(in.Data) > 0 {
+			pinner.Pin(&in.Data[0])
+			d.data = unsafe.Pointer(&in.Data[0])
+		}
+		d.size = C.size_t(len(in.Data))
+		d.width = C.size_t(in.Width)
+		if len(in.Lengths) > 0 {
+			pinner.Pin(&in.Lengths[0])
+			d.lens = (*C.uint32_t)(unsafe.Pointer(&in.Lengths[0]))
+		}
+		d.nb_strings = C.size_t(len(in.Lengths))
+		total += len(in.Data) + 4*len(in.Lengths)
+	}
+
+	// Each input carries its own stream headers on top of the data bound.
+	bound := int(C.openzl_compress_bound(C.size_t(total))) + 256*len(inputs)
+	compressed := make([]byte, bound)
+
+	result := C.openzl_compress_typed(
+		ctx.ctx,
+		unsafe.Pointer(&compressed[0]),
+		C.size_t(len(compressed)),
+		&descs[0],
+		C.size_t(len(inputs)),
+	)
+	if result < 0 {
+		return nil, fmt.Errorf("typed compression failed with error code %d (%d inputs, %d bytes)", -result, len(inputs), total)
+	}
+	return compressed[:int(result)], nil
+}
+
+// OpenZLDecompressTyped decompresses a frame into its typed outputs.
+func OpenZLDecompressTyped(ctx *OpenZLContext, data []byte) ([]TypedBuffer, error) {
+	if ctx == nil || ctx.ctx == nil {
+		return nil, errors.New("invalid context")
+	}
+	if len(data) == 0 {
+		return nil, errors.New("empty frame")
+	}
+
+	src := unsafe.Pointer(&data[0])
+	n := C.openzl_frame_num_outputs(src, C.size_t(len(data)))
+	if n < 0 {
+		return nil, fmt.Errorf("failed to read frame header: error code %d", -n)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+
+	outputs := unsafe.Slice((**C.ZL_TypedBuffer)(C.calloc(C.size_t(n), C.size_t(unsafe.Sizeof(uintptr(0))))), int(n))
+	defer func() {
+		for _, out := range outputs {
+			if out != nil {
+				C.ZL_TypedBuffer_free(out)
+			}
+		}
+		C.free(unsafe.Pointer(&outputs[0]))
+	}()
+
+	result := C.openzl_decompress_typed(ctx.ctx, src, C.size_t(len(data)), &outputs[0], C.size_t(n))
+	if result < 0 {
+		return nil, fmt.Errorf("typed decompression failed with error code %d", -result)
+	}
+
+	buffers := make([]TypedBuffer, n)
+	for i, out := range outputs {
+		var d C.openzl_typed_t
+		C.openzl_typed_buffer_describe(out, &d)
+		b := TypedBuffer{Type: int(d._type), Width: int(d.width)}
+		if d.size > 0 {
+			b.Data = C.GoBytes(d.data, C.int(d.size))
+		} else {
+			b.Data = []byte{}
+		}
+		if d.nb_strings > 0 {
+			b.Lengths = append([]uint32(nil), unsafe.Slice((*uint32)(unsafe.Pointer(d.lens)), int(d.nb_strings))...)
+		}
+		buffers[i] = b
+	}
+	return buffers, nil
+}
+
+// Native reports whether the package calls the native OpenZL library.
+const Native = true
+
+// LibraryVersion returns the version of the OpenZL library the bindings were
+// compiled against.
+func LibraryVersion() (major, minor, patch int) {
+	var ma, mi, pa C.int
+	C.openzl_library_version(&ma, &mi, &pa)
+	return int(ma), int(mi), int(pa)
+}
+
+// FormatVersions returns the range of frame format versions the library
+// supports.
+func FormatVersions() (min, max int) {
+	var lo, hi C.int
+	C.openzl_format_versions(&lo, &hi)
+	return int(lo), int(hi)
+}
+
+// batchBuffers describes bufs in C memory, pinning their data, so that a
+// whole batch can be passed to the C shim in one call. The returned function
+// frees the descriptors and unpins the data.
+func batchBuffers(bufs [][]byte) (*C.openzl_buffer_t, func()) {
+	descs := unsafe.Slice((*C.openzl_buffer_t)(C.calloc(C.size_t(len(bufs)), C.sizeof_openzl_buffer_t)), len(bufs))
+	var pinner runtime.Pinner
+	for i, b := range bufs {
+		if len(b) > 0 {
+			pinner.Pin(&b[0])
+			descs[i].data = unsafe.Pointer(&b[0])
+		}
+		descs[i].size = C.size_t(len(b))
+	}
+	return &descs[0], func() {
+		pinner.Unpin()
+		C.free(unsafe.Pointer(&descs[0]))
+	}
+}
+
+// splitArena slices arena into consecutive parts of the given sizes. Each
+// part's capacity ends at its length so that appending to one part cannot
+// overwrite the next.
+func splitArena(arena []byte, sizes []C.size_t) [][]byte {
+	parts := make([][]byte, len(sizes))
+	off := 0
+	for i, size := range sizes {
+		end := off + int(size)
+		parts[i] = arena[off:end:end]
+		off = end
+	}
+	return parts
+}
+
+// OpenZLCompressBatch compresses each of srcs into its own frame with two
+// cgo calls in total. The frames share one backing array.
+func OpenZLCompressBatch(ctx *OpenZLContext, srcs [][]byte) ([][]byte, error) {
+	if ctx == nil || ctx.ctx == nil {
+		return nil, errors.New("invalid context")
+	}
+	if len(srcs) == 0 {
+		return [][]byte{}, nil
+	}
+
+	descs, free := batchBuffers(srcs)
+	defer free()
+
+	bound := int(C.openzl_compress_bound_batch(descs, C.size_t(len(srcs))))
+	compressed := make([]byte, bound)
+	var dstPtr unsafe.Pointer
+	if bound > 0 {
+		dstPtr = unsafe.Pointer(&compressed[0])
+	}
+	sizes := make([]C.size_t, len(srcs))
+	var failed C.size_t
+
+	result := C.openzl_compress_batch(
+		ctx.ctx,
+		dstPtr,
+		C.size_t(bound),
+		descs,
+		C.size_t(len(srcs)),
+		&sizes[0],
+		&failed,
+	)
+	if result < 0 {
+		return nil, fmt.Errorf("compression of buffer %d failed with error code %d (data size: %d)", int(failed), -result, len(srcs[failed]))
+	}
+
+	// The bound can be far larger than the frames, so they are moved into an
+	// array of their exact total size.
+	arena := make([]byte, int(result))
+	copy(arena, compressed)
+	return splitArena(arena, sizes), nil
+}
+
+// OpenZLDecompressBatch decompresses each of frames with two cgo calls in
+// total. The outputs share one backing array.
+func OpenZLDecompressBatch(ctx *OpenZLContext, frames [][]byte) ([][]byte, error) {
+	if ctx == nil || ctx.ctx == nil {
+		return nil, errors.New("invalid context")
+	}
+	if len(frames) == 0 {
+		return [][]byte{}, nil
+	}
+
+	descs, free := batchBuffers(frames)
+	defer free()
+
+	sizes := make([]C.size_t, len(frames))
+	var failed C.size_t
+	total := C.openzl_decompressed_size_batch(descs, C.size_t(len(frames)), &sizes[0], &failed)
+	if total < 0 {
+		return nil, fmt.Errorf("failed to get decompressed size of frame %d: error code %d", int(failed), -total)
+	}
+
+	decompressed := make([]byte, int(total))
+	var dstPtr unsafe.Pointer
+	if total > 0 {
+		dstPtr = unsafe.Pointer(&decompressed[0])
+	}
+	result := C.openzl_decompress_batch(
+		ctx.ctx,
+		dstPtr,
+		C.size_t(total),
+		descs,
+		C.size_t(len(frames)),
+		&sizes[0],
+		&failed,
+	)
+	if result < 0 {
+		return nil, fmt.Errorf("decompression of frame %d failed with error code %d", int(failed), -result)
+	}
+	return splitArena(decompressed, sizes), nil
+}
+
+// OpenZLCompressExplain compresses data like OpenZLCompress while recording
+// the graphs, codecs and streams involved, in the order they ran.
+func OpenZLCompressExplain(ctx *OpenZLContext, data []byte) ([]byte, []ExplainNode, error) {
+	if ctx == nil || ctx.ctx == nil {
+		return nil, nil, errors.New("invalid context")
+	}
+
+	explain := C.openzl_explain_create()
+	if explain == nil {
+		return nil, nil, errors.New("failed to allocate introspection state")
+	}
+	defer C.openzl_explain_free(explain)
+
+	if C.openzl_explain_attach(ctx.ctx, explain) < 0 {
+		return nil, nil, ErrNoIntrospection
+	}
+	compressed, err := OpenZLCompress(ctx, data)
+	C.openzl_explain_detach(ctx.ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if explain.oom != 0 {
+		return nil, nil, errors.New("failed to record compression steps: out of memory")
+	}
+
+	var nodes []ExplainNode
+	if explain.nb_nodes > 0 {
+		recorded := unsafe.Slice(explain.nodes, int(explain.nb_nodes))
+		nodes = make([]ExplainNode, len(recorded))
+		for i := range recorded {
+			n := &recorded[i]
+			nodes[i] = ExplainNode{
+				Kind:   int(n.kind),
+				Parent: int(n.parent),
+				Name:   C.GoString(&n.name[0]),
+				Type:   int(n._type),
+				Size:   int(n.size),
+			}
+		}
+	}
+	return compressed, nodes, nil
+}
@@ -0,0 +1,46 @@
+package copenzl
+
+import "errors"
+
+// Input types, mirroring the OPENZL_TYPE_* constants of the C shim.
+const (
+	TypeSerial  = 0
+	TypeStruct  = 1
+	TypeNumeric = 2
+	TypeString  = 3
+)
+
+// Context modes, mirroring the OPENZL_MODE_* constants of the C shim.
+const (
+	ModeCompress   = 1
+	ModeDecompress = 2
+)
+
+// Kinds of ExplainNode, mirroring the OPENZL_EXPLAIN_* constants of the C
+// shim.
+const (
+	ExplainGraph  = 0
+	ExplainCodec  = 1
+	ExplainStream = 2
+)
+
+// ExplainNode is one graph, codec or stream recorded while compressing.
+type ExplainNode struct {
+	Kind   int
+	Parent int // index of the parent node, -1 for roots
+	Name   string
+	Type   int
+	Size   int
+}
+
+// ErrNoIntrospection is returned by OpenZLCompressExplain when the library
+// does not support introspection hooks.
+var ErrNoIntrospection = errors.New("openzl: the OpenZL library was built without introspection support (ZL_ALLOW_INTROSPECTION)")
+
+// TypedBuffer is a typed input to, or output from, a multi-input frame.
+type TypedBuffer struct {
+	Type    int
+	Data    []byte
+	Width   int
+	Lengths []uint32
+}
@@ -0,0 +1,162 @@
+// Command genframes adds frames to the golden frame corpus used by the
+// openzl tests.
+//
+// For every frame format version the linked OpenZL library supports, every
+// graph and every file in <dir>/inputs, it writes
+// <dir>/v<version>/<graph>/<input>.ozl. Existing frames are left alone unless
+// -force is given: frames written by older library versions must stay as
+// they are, so that the tests keep checking that newer versions decode them.
+// Combinations the library rejects, such as numeric-only graphs with serial
+// input, are skipped.
+//
+// The extension of an input selects how it is compressed: ".serial" inputs
+// with Context.Compress, ".u32" inputs as a numeric input of width 4.
+//
+// Run it from the openzl directory with
+//
+//	go generate
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+func main() {
+	dir := flag.String("dir", "testdata/frames", "corpus `directory`")
+	force := flag.Bool("force", false, "rewrite existing frames")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("genframes: ")
+
+	if err := writeInputs(filepath.Join(*dir, "inputs")); err != nil {
+		log.Fatal(err)
+	}
+	n, err := generate(*dir, *force)
+	if err != nil {
+		log.Fatal(err)
+	}
+	v := openzl.Version()
+	log.Printf("wrote %d frames (OpenZL %s, format versions %d-%d)", n, v.Library(), v.MinFormatVersion, v.MaxFormatVersion)
+}
+
+// writeInputs creates the corpus inputs that do not exist yet.
+func writeInputs(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for name, data := range inputs() {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inputs returns the initial corpus inputs. They are generated once and
+// then read from disk, so changing this function does not change existing
+// inputs.
+func inputs() map[string][]byte {
+	var text bytes.Buffer
+	for i := 0; text.Len() < 8<<10; i++ {
+		fmt.Fprintf(&text, "2024-03-01T12:%02d:%02d INFO request %d handled in %dms\n", i/60%60, i%60, i, 3+i*7%90)
+	}
+
+	// Pseudo-random bytes with repeats, from a fixed linear congruential
+	// generator.
+	mixed := make([]byte, 0, 4<<10)
+	x := uint32(1)
+	for len(mixed) < cap(mixed) {
+		x = x*1664525 + 1013904223
+		if x>>28 < 4 && len(mixed) >= 16 {
+			mixed = append(mixed, mixed[len(mixed)-16:len(mixed)-8]...)
+			continue
+		}
+		mixed = append(mixed, byte(x>>24))
+	}
+
+	counter := make([]byte, 0, 4*1024)
+	for i := 0; i < 1024; i++ {
+		counter = binary.LittleEndian.AppendUint32(counter, uint32(1000+3*i))
+	}
+
+	return map[string][]byte{
+		"text.serial":  text.Bytes(),
+		"mixed.serial": mixed,
+		"counter.u32":  counter,
+	}
+}
+
+// generate compresses every input at every format version with every graph.
+func generate(dir string, force bool) (int, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "inputs", "*"))
+	if err != nil {
+		return 0, err
+	}
+	sort.Strings(names)
+	data := make(map[string][]byte, len(names))
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".serial") && !strings.HasSuffix(name, ".u32") {
+			return 0, fmt.Errorf("%s: unknown input kind", name)
+		}
+		if data[name], err = os.ReadFile(name); err != nil {
+			return 0, err
+		}
+	}
+
+	v := openzl.Version()
+	written := 0
+	for version := v.MinFormatVersion; version <= v.MaxFormatVersion; version++ {
+		for _, g := range openzl.Graphs() {
+			ctx, err := openzl.NewContext(openzl.WithGraph(g), openzl.WithFormatVersion(version))
+			if err != nil {
+				continue
+			}
+			for _, name := range names {
+				path := filepath.Join(dir, fmt.Sprintf("v%d", version), g.String(), filepath.Base(name)+".ozl")
+				if _, err := os.Stat(path); err == nil && !force {
+					continue
+				}
+				frame, err := compress(ctx, name, data[name])
+				if err != nil {
+					continue
+				}
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					ctx.Close()
+					return written, err
+				}
+				if err := os.WriteFile(path, frame, 0o644); err != nil {
+					ctx.Close()
+					return written, err
+				}
+				written++
+			}
+			ctx.Close()
+		}
+	}
+	return written, nil
+}
+
+func compress(ctx *openzl.Context, name string, data []byte) ([]byte, error) {
+	if strings.HasSuffix(name, ".u32") {
+		return ctx.CompressInputs(openzl.NumericInput(data, 4))
+	}
+	return ctx.Compress(data)
+}
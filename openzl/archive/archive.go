@@ -0,0 +1,185 @@
+// Package archive implements a tar-based archive format whose file contents
+// are compressed with OpenZL.
+//
+// An archive is an ordinary PAX tar file in which the data of every regular
+// file is an OpenZL stream (see openzl.Writer). Directories and symbolic
+// links are stored as plain tar entries. After the last file the archive
+// holds an index entry, the tar end-of-archive marker and a 16-byte footer:
+//
+//	[tar entries...][index entry][end-of-archive][footer]
+//
+// The index records the name, mode, modification time, sizes and data offset
+// of every entry. The footer holds the offset and length of the index data
+// followed by a magic number, so a Reader can list the archive and extract a
+// single file without reading or decompressing anything else. Tools that only
+// understand tar see the compressed streams under the original file names.
+//
+// Creating an archive:
+//
+//	w := archive.NewWriter(f)
+//	if err := w.AddDir("src"); err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := w.Close(); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Extracting one file:
+//
+//	r, err := archive.NewReader(f, size)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer r.Close()
+//	err = r.Extract("docs/README.md", "out")
+//
+// Files are compressed and extracted concurrently (see WithConcurrency) with
+// contexts borrowed from an openzl.Pool.
+package archive
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"runtime"
+	"time"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+// IndexName is the tar name of the index entry.
+const IndexName = ".openzl-index"
+
+// sizeRecord is the PAX record holding the uncompressed size of a file.
+const sizeRecord = "OPENZL.size"
+
+const (
+	footerMagic  = "OZLA"
+	footerSize   = 16
+	indexVersion = 1
+	maxIndexSize = 1 << 30
+)
+
+var (
+	// ErrInvalidArchive is returned when an archive's footer or index is
+	// missing or inconsistent.
+	ErrInvalidArchive = errors.New("archive: invalid archive")
+
+	// ErrNotExist is returned when an archive has no entry with the
+	// requested name.
+	ErrNotExist = errors.New("archive: no such entry")
+
+	// ErrInsecurePath is returned when extracting an entry would write
+	// outside the destination directory.
+	ErrInsecurePath = errors.New("archive: insecure path")
+)
+
+// Entry describes a file, directory or symbolic link in an archive.
+type Entry struct {
+	Name           string      // Slash-separated path within the archive
+	Mode           fs.FileMode // Type and permission bits
+	ModTime        time.Time   // Modification time
+	Size           int64       // Uncompressed size of a regular file
+	CompressedSize int64       // Size of the file's OpenZL stream
+	Linkname       string      // Target of a symbolic link
+
+	offset int64 // Offset of the file's stream within the archive
+}
+
+// IsDir reports whether e describes a directory.
+func (e *Entry) IsDir() bool {
+	return e.Mode.IsDir()
+}
+
+// Option configures a Writer or Reader.
+type Option func(*config)
+
+type config struct {
+	pool        *openzl.Pool
+	concurrency int
+	chunkSize   int
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		concurrency: runtime.GOMAXPROCS(0),
+		chunkSize:   openzl.DefaultChunkSize,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// streamOptions returns the options for the Writers and Readers compressing
+// and decompressing file contents. Each file is a single sequential stream;
+// concurrency comes from handling several files at once.
+func (c *config) streamOptions() []openzl.Option {
+	return []openzl.Option{openzl.WithPool(c.pool), openzl.WithChunkSize(c.chunkSize)}
+}
+
+// WithPool sets the pool contexts are borrowed from. The pool's options, such
+// as its level and graph, determine how files are compressed. By default each
+// Writer and Reader has a pool of its own, closed by its Close method.
+func WithPool(p *openzl.Pool) Option {
+	return func(c *config) {
+		c.pool = p
+	}
+}
+
+// WithConcurrency sets how many files are compressed or extracted at once.
+// Values below 1 select runtime.GOMAXPROCS(0), the default.
+func WithConcurrency(n int) Option {
+	return func(c *config) {
+		if n < 1 {
+			n = runtime.GOMAXPROCS(0)
+		}
+		c.concurrency = n
+	}
+}
+
+// WithChunkSize sets the number of uncompressed bytes compressed into each
+// frame of a file's stream. Non-positive values select
+// openzl.DefaultChunkSize.
+func WithChunkSize(n int) Option {
+	return func(c *config) {
+		if n <= 0 {
+			n = openzl.DefaultChunkSize
+		}
+		c.chunkSize = n
+	}
+}
+
+// index is the serialised form of the archive index.
+type index struct {
+	Version int          `json:"version"`
+	Entries []indexEntry `json:"entries"`
+}
+
+type indexEntry struct {
+	Name           string      `json:"name"`
+	Mode           fs.FileMode `json:"mode"`
+	ModTime        int64       `json:"mtime"` // Unix nanoseconds
+	Size           int64       `json:"size,omitempty"`
+	CompressedSize int64       `json:"csize,omitempty"`
+	Linkname       string      `json:"link,omitempty"`
+	Offset         int64       `json:"offset,omitempty"`
+}
+
+func appendFooter(b []byte, offset int64, length int) []byte {
+	b = binary.LittleEndian.AppendUint64(b, uint64(offset))
+	b = binary.LittleEndian.AppendUint32(b, uint32(length))
+	return append(b, footerMagic...)
+}
+
+func parseFooter(b []byte) (offset int64, length int, err error) {
+	if len(b) != footerSize || string(b[12:]) != footerMagic {
+		return 0, 0, ErrInvalidArchive
+	}
+	offset = int64(binary.LittleEndian.Uint64(b))
+	length = int(binary.LittleEndian.Uint32(b[8:]))
+	if offset < 0 || length > maxIndexSize {
+		return 0, 0, ErrInvalidArchive
+	}
+	return offset, length, nil
+}
@@ -0,0 +1,342 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+var mtime = time.Date(2024, 3, 1, 12, 30, 45, 123456789, time.UTC)
+
+// writeTree creates a small directory tree and returns the contents of its
+// regular files by archive name.
+func writeTree(t *testing.T, dir string) map[string][]byte {
+	t.Helper()
+	files := map[string][]byte{
+		"README.md":        []byte("# OpenZL archive test\n"),
+		"data/log.txt":     bytes.Repeat([]byte("2024-03-01 INFO request served in 12ms\n"), 2000),
+		"data/empty":       nil,
+		"data/nested/x.sh": []byte("#!/bin/sh\necho hello\n"),
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(dir, "data/nested/x.sh"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("nested/x.sh", filepath.Join(dir, "data/run")); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []string{"data/nested", "data"} {
+		if err := os.Chtimes(filepath.Join(dir, d), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return files
+}
+
+func createArchive(t *testing.T, dir string, opts ...Option) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewWriter(&buf, opts...)
+	if err := w.AddDir(dir); err != nil {
+		t.Fatalf("AddDir() failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	files := writeTree(t, src)
+
+	pool := openzl.NewPool()
+	defer pool.Close()
+	data := createArchive(t, src, WithPool(pool), WithConcurrency(3), WithChunkSize(4096))
+
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)), WithPool(pool))
+	if err != nil {
+		t.Fatalf("NewReader() failed: %v", err)
+	}
+	defer r.Close()
+
+	dst := t.TempDir()
+	if err := r.ExtractAll(dst); err != nil {
+		t.Fatalf("ExtractAll() failed: %v", err)
+	}
+	for name, want := range files {
+		path := filepath.Join(dst, filepath.FromSlash(name))
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile() failed: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("%s: data integrity check failed", name)
+		}
+	}
+
+	for _, name := range []string{"README.md", "data/nested/x.sh", "data", "data/nested"} {
+		want, err := os.Stat(filepath.Join(src, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.Stat(filepath.Join(dst, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Mode() != want.Mode() {
+			t.Errorf("%s: mode = %v, want %v", name, got.Mode(), want.Mode())
+		}
+		if !got.ModTime().Equal(want.ModTime()) {
+			t.Errorf("%s: mtime = %v, want %v", name, got.ModTime(), want.ModTime())
+		}
+	}
+
+	link, err := os.Readlink(filepath.Join(dst, "data/run"))
+	if err != nil {
+		t.Fatalf("Readlink() failed: %v", err)
+	}
+	if link != "nested/x.sh" {
+		t.Fatalf("symlink target = %q, want nested/x.sh", link)
+	}
+}
+
+// countingReaderAt records how many bytes are read from an archive.
+type countingReaderAt struct {
+	r io.ReaderAt
+	n atomic.Int64
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+func TestListAndExtractOne(t *testing.T) {
+	src := t.TempDir()
+	files := writeTree(t, src)
+	data := createArchive(t, src)
+
+	ra := &countingReaderAt{r: bytes.NewReader(data)}
+	r, err := NewReader(ra, int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader() failed: %v", err)
+	}
+	defer r.Close()
+
+	var names []string
+	for _, e := range r.Entries() {
+		names = append(names, e.Name)
+	}
+	want := "README.md data data/empty data/log.txt data/nested data/nested/x.sh data/run"
+	if got := strings.Join(names, " "); got != want {
+		t.Fatalf("Entries() = %s, want %s", got, want)
+	}
+
+	e, err := r.Stat("data/log.txt")
+	if err != nil {
+		t.Fatalf("Stat() failed: %v", err)
+	}
+	if e.Size != int64(len(files["data/log.txt"])) || e.CompressedSize >= e.Size {
+		t.Fatalf("Stat() = %d bytes compressed to %d", e.Size, e.CompressedSize)
+	}
+	if !e.ModTime.Equal(mtime) {
+		t.Fatalf("ModTime = %v, want %v", e.ModTime, mtime)
+	}
+	// Listing reads the footer and index only.
+	if n := ra.n.Load(); n >= e.CompressedSize {
+		t.Fatalf("listing read %d bytes, more than a single file's data", n)
+	}
+
+	dst := t.TempDir()
+	if err := r.Extract("data/nested/x.sh", dst); err != nil {
+		t.Fatalf("Extract() failed: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(dst, "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "nested" {
+		t.Fatalf("Extract() wrote more than the requested file: %v", entries)
+	}
+
+	if err := r.Extract("missing", dst); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("Extract(missing) = %v, want %v", err, ErrNotExist)
+	}
+	if _, err := r.Open("data"); err == nil {
+		t.Fatal("Open() of a directory should fail")
+	}
+}
+
+func TestTarCompatible(t *testing.T) {
+	src := t.TempDir()
+	files := writeTree(t, src)
+	data := createArchive(t, src)
+
+	tr := tar.NewReader(bytes.NewReader(data))
+	seen := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() failed: %v", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || hdr.Name == IndexName {
+			continue
+		}
+		stream, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := openzl.DecompressParallel(stream)
+		if err != nil {
+			t.Fatalf("%s: DecompressParallel() failed: %v", hdr.Name, err)
+		}
+		if !bytes.Equal(got, files[hdr.Name]) {
+			t.Fatalf("%s: data integrity check failed", hdr.Name)
+		}
+		if want := fmt.Sprint(len(got)); hdr.PAXRecords[sizeRecord] != want {
+			t.Fatalf("%s: size record = %q, want %q", hdr.Name, hdr.PAXRecords[sizeRecord], want)
+		}
+		seen++
+	}
+	if seen != len(files) {
+		t.Fatalf("tar reader found %d files, want %d", seen, len(files))
+	}
+}
+
+func TestInvalidArchive(t *testing.T) {
+	src := t.TempDir()
+	writeTree(t, src)
+	data := createArchive(t, src)
+
+	offset, _, err := parseFooter(data[len(data)-footerSize:])
+	if err != nil {
+		t.Fatalf("parseFooter() failed: %v", err)
+	}
+	corruptIndex := append([]byte(nil), data...)
+	corruptIndex[offset] ^= 0xff
+
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "no footer", data: data[:len(data)-footerSize]},
+		{name: "truncated", data: data[len(data)/2:]},
+		{name: "index out of range", data: append(append([]byte(nil), data[:len(data)-footerSize]...),
+			appendFooter(nil, int64(len(data)), 100)...)},
+		{name: "corrupt index", data: corruptIndex},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewReader(bytes.NewReader(tc.data), int64(len(tc.data)))
+			if !errors.Is(err, ErrInvalidArchive) {
+				t.Fatalf("NewReader() = %v, want %v", err, ErrInvalidArchive)
+			}
+		})
+	}
+}
+
+func TestWriterRejectsEntries(t *testing.T) {
+	w := NewWriter(io.Discard)
+	defer w.Close()
+
+	for _, e := range []Entry{
+		{Name: "../escape", Mode: 0o644},
+		{Name: "/abs", Mode: 0o644},
+		{Name: IndexName, Mode: 0o644},
+		{Name: "dev", Mode: fs.ModeDevice | 0o644},
+	} {
+		if err := w.Add(e, strings.NewReader("x")); err == nil {
+			t.Errorf("Add(%q) should fail", e.Name)
+		}
+	}
+	if err := w.Add(Entry{Name: "a", Mode: 0o644}, strings.NewReader("x")); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if err := w.Add(Entry{Name: "a", Mode: 0o644}, strings.NewReader("x")); err == nil {
+		t.Fatal("Add() of a duplicate name should fail")
+	}
+}
+
+func TestExtractThroughSymlink(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	outside := t.TempDir()
+	if err := w.Add(Entry{Name: "link", Mode: fs.ModeSymlink | 0o777, Linkname: outside}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Add(Entry{Name: "link/evil", Mode: 0o644}, strings.NewReader("pwned")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader() failed: %v", err)
+	}
+	defer r.Close()
+
+	dst := t.TempDir()
+	if err := r.Extract("link", dst); err != nil {
+		t.Fatalf("Extract(link) failed: %v", err)
+	}
+	if err := r.Extract("link/evil", dst); !errors.Is(err, ErrInsecurePath) {
+		t.Fatalf("Extract(link/evil) = %v, want %v", err, ErrInsecurePath)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "evil")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal("a file was written outside the destination")
+	}
+}
+
+func BenchmarkAddDir(b *testing.B) {
+	src := b.TempDir()
+	data := bytes.Repeat([]byte("archive benchmark data for OpenZL. "), 30000)
+	for i := 0; i < 16; i++ {
+		if err := os.WriteFile(filepath.Join(src, fmt.Sprintf("f%02d", i)), data, 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	pool := openzl.NewPool()
+	defer pool.Close()
+
+	b.SetBytes(int64(16 * len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := NewWriter(io.Discard, WithPool(pool))
+		if err := w.AddDir(src); err != nil {
+			b.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package archive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+// Reader provides access to the entries of an archive.
+//
+// A Reader is safe for concurrent use.
+type Reader struct {
+	r       io.ReaderAt
+	c       *config
+	ownPool bool
+	entries []Entry
+	byName  map[string]int
+}
+
+// NewReader reads the index of the archive in r, which is size bytes long.
+// Only the footer and the index are read.
+func NewReader(r io.ReaderAt, size int64, opts ...Option) (*Reader, error) {
+	if size < footerSize {
+		return nil, ErrInvalidArchive
+	}
+	footer := make([]byte, footerSize)
+	if _, err := r.ReadAt(footer, size-footerSize); err != nil {
+		return nil, err
+	}
+	offset, length, err := parseFooter(footer)
+	if err != nil {
+		return nil, err
+	}
+	if offset > size-footerSize-int64(length) {
+		return nil, ErrInvalidArchive
+	}
+
+	c := newConfig(opts)
+	ar := &Reader{r: r, c: c}
+	if c.pool == nil {
+		c.pool = openzl.NewPool()
+		ar.ownPool = true
+	}
+	if err := ar.readIndex(io.NewSectionReader(r, offset, int64(length)), offset); err != nil {
+		ar.Close()
+		return nil, err
+	}
+	return ar, nil
+}
+
+// readIndex decodes the index stream in sr. Files must lie before the index,
+// which starts at end.
+func (r *Reader) readIndex(sr io.Reader, end int64) error {
+	zr, err := openzl.NewReader(sr, r.c.streamOptions()...)
+	if errors.Is(err, openzl.ErrInvalidStream) {
+		return ErrInvalidArchive
+	}
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	var idx index
+	lr := &io.LimitedReader{R: zr, N: maxIndexSize + 1}
+	if err := json.NewDecoder(lr).Decode(&idx); err != nil {
+		if lr.N == 0 || errors.Is(err, openzl.ErrInvalidStream) {
+			return ErrInvalidArchive
+		}
+		var syntax *json.SyntaxError
+		var typ *json.UnmarshalTypeError
+		if errors.As(err, &syntax) || errors.As(err, &typ) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrInvalidArchive
+		}
+		return err
+	}
+	if idx.Version != indexVersion {
+		return fmt.Errorf("archive: unsupported index version %d", idx.Version)
+	}
+
+	r.entries = make([]Entry, len(idx.Entries))
+	r.byName = make(map[string]int, len(idx.Entries))
+	for i, ie := range idx.Entries {
+		e := Entry{
+			Name:     ie.Name,
+			Mode:     ie.Mode,
+			ModTime:  time.Unix(0, ie.ModTime),
+			Linkname: ie.Linkname,
+		}
+		if !validName(e.Name) {
+			return ErrInvalidArchive
+		}
+		if _, dup := r.byName[e.Name]; dup {
+			return ErrInvalidArchive
+		}
+		switch {
+		case e.Mode.IsRegular():
+			if ie.Size < 0 || ie.CompressedSize < 0 || ie.Offset < 0 || ie.Offset > end-ie.CompressedSize {
+				return ErrInvalidArchive
+			}
+			e.Size, e.CompressedSize, e.offset = ie.Size, ie.CompressedSize, ie.Offset
+		case e.Mode.IsDir(), e.Mode&fs.ModeSymlink != 0:
+		default:
+			return ErrInvalidArchive
+		}
+		r.entries[i] = e
+		r.byName[e.Name] = i
+	}
+	return nil
+}
+
+// Entries returns the entries of the archive in the order they were added.
+func (r *Reader) Entries() []Entry {
+	return append([]Entry(nil), r.entries...)
+}
+
+// Stat returns the entry with the given name.
+func (r *Reader) Stat(name string) (Entry, error) {
+	i, ok := r.byName[name]
+	if !ok {
+		return Entry{}, fmt.Errorf("%w: %s", ErrNotExist, name)
+	}
+	return r.entries[i], nil
+}
+
+// Open returns a reader of the decompressed contents of the regular file
+// with the given name. The caller must close it.
+func (r *Reader) Open(name string) (io.ReadCloser, error) {
+	e, err := r.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if !e.Mode.IsRegular() {
+		return nil, fmt.Errorf("archive: %s is not a regular file", name)
+	}
+	return r.open(e)
+}
+
+func (r *Reader) open(e Entry) (io.ReadCloser, error) {
+	zr, err := openzl.NewReader(io.NewSectionReader(r.r, e.offset, e.CompressedSize), r.c.streamOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	return &fileReader{Reader: zr, remaining: e.Size}, nil
+}
+
+// fileReader checks that a file decompresses to the size in the index.
+type fileReader struct {
+	*openzl.Reader
+	remaining int64
+}
+
+func (f *fileReader) Read(p []byte) (int, error) {
+	n, err := f.Reader.Read(p)
+	f.remaining -= int64(n)
+	if f.remaining < 0 || (err == io.EOF && f.remaining != 0) {
+		return n, ErrInvalidArchive
+	}
+	return n, err
+}
+
+// Extract writes the entry with the given name below the directory dir,
+// creating parent directories as needed, and restores its mode and
+// modification time. Extracting a directory creates only the directory.
+//
+// Extract refuses to write through a symbolic link within dir and returns
+// ErrInsecurePath instead.
+func (r *Reader) Extract(name, dir string) error {
+	e, err := r.Stat(name)
+	if err != nil {
+		return err
+	}
+	path, err := target(dir, e.Name)
+	if err != nil {
+		return err
+	}
+	switch {
+	case e.Mode.IsDir():
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			return err
+		}
+		return restore(path, e)
+	case e.Mode&fs.ModeSymlink != 0:
+		return r.extractSymlink(path, e)
+	default:
+		return r.extractFile(path, e)
+	}
+}
+
+// ExtractAll writes every entry of the archive below the directory dir,
+// extracting files concurrently (see WithConcurrency). Directory modes and
+// modification times are restored last, so that read-only directories can
+// be populated first.
+func (r *Reader) ExtractAll(dir string) error {
+	var files, links, dirs []Entry
+	for _, e := range r.entries {
+		switch {
+		case e.Mode.IsDir():
+			dirs = append(dirs, e)
+		case e.Mode&fs.ModeSymlink != 0:
+			links = append(links, e)
+		default:
+			files = append(files, e)
+		}
+	}
+
+	for _, e := range dirs {
+		path, err := target(dir, e.Name)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			return err
+		}
+	}
+	err := forEach(len(files), r.c.concurrency, func(i int) error {
+		path, err := target(dir, files[i].Name)
+		if err != nil {
+			return err
+		}
+		return r.extractFile(path, files[i])
+	})
+	if err != nil {
+		return err
+	}
+	// Links are created after the files so that no file is written
+	// through one.
+	for _, e := range links {
+		path, err := target(dir, e.Name)
+		if err != nil {
+			return err
+		}
+		if err := r.extractSymlink(path, e); err != nil {
+			return err
+		}
+	}
+	// Children before parents, since populating a directory changes its
+	// modification time.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		path, err := target(dir, dirs[i].Name)
+		if err != nil {
+			return err
+		}
+		if err := restore(path, dirs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Reader) extractFile(path string, e Entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := removeLink(path); err != nil {
+		return err
+	}
+	src, err := r.open(e)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = restore(path, e)
+	}
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("archive: %s: %w", e.Name, err)
+	}
+	return nil
+}
+
+func (r *Reader) extractSymlink(path string, e Entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := removeLink(path); err != nil {
+		return err
+	}
+	return os.Symlink(e.Linkname, path)
+}
+
+// Close releases the Reader's contexts. It does not close the underlying
+// reader, nor a pool set with WithPool.
+func (r *Reader) Close() error {
+	if r.ownPool {
+		return r.c.pool.Close()
+	}
+	return nil
+}
+
+// target returns the path name is extracted to below dir, checking that no
+// existing parent within dir is a symbolic link.
+func target(dir, name string) (string, error) {
+	if !validName(name) {
+		return "", fmt.Errorf("%w: %s", ErrInsecurePath, name)
+	}
+	path := dir
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		path = filepath.Join(path, part)
+		if i == len(parts)-1 {
+			break
+		}
+		fi, err := os.Lstat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("%w: %s", ErrInsecurePath, name)
+		}
+	}
+	return path, nil
+}
+
+// removeLink removes path if it is a symbolic link, so that extracting over
+// it replaces the link instead of writing to its target.
+func removeLink(path string) error {
+	fi, err := os.Lstat(path)
+	if err != nil || fi.Mode()&fs.ModeSymlink == 0 {
+		return nil
+	}
+	return os.Remove(path)
+}
+
+// restore applies the mode and modification time of e to path.
+func restore(path string, e Entry) error {
+	if err := os.Chmod(path, e.Mode&(fs.ModePerm|fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky)); err != nil {
+		return err
+	}
+	return os.Chtimes(path, e.ModTime, e.ModTime)
+}
+
+// forEach calls fn for 0 <= i < n from up to concurrency goroutines and
+// returns the first error. No new calls start after an error.
+func forEach(n, concurrency int, fn func(i int) error) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		next     int
+		firstErr error
+	)
+	for w := 0; w < min(concurrency, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				if firstErr != nil || next == n {
+					mu.Unlock()
+					return
+				}
+				i := next
+				next++
+				mu.Unlock()
+
+				if err := fn(i); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
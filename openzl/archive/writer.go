@@ -0,0 +1,341 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+// Writer creates an archive.
+//
+// A Writer is not safe for concurrent use; AddDir compresses files
+// concurrently on its own.
+type Writer struct {
+	c       *config
+	ownPool bool
+	cw      *countingWriter
+	tw      *tar.Writer
+	entries []indexEntry
+	names   map[string]bool
+	err     error
+	closed  bool
+}
+
+// NewWriter returns a Writer that writes an archive to w. The archive is not
+// complete until Close is called.
+func NewWriter(w io.Writer, opts ...Option) *Writer {
+	c := newConfig(opts)
+	aw := &Writer{c: c, names: make(map[string]bool)}
+	if c.pool == nil {
+		c.pool = openzl.NewPool()
+		aw.ownPool = true
+	}
+	aw.cw = &countingWriter{w: w}
+	aw.tw = tar.NewWriter(aw.cw)
+	return aw
+}
+
+// Add adds an entry to the archive. For a regular file the contents are read
+// from r until EOF; for directories and symbolic links r is ignored and may
+// be nil. The entry's Size and CompressedSize are ignored.
+func (w *Writer) Add(e Entry, r io.Reader) error {
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.check(e); err != nil {
+		return err
+	}
+	var stream []byte
+	if e.Mode.IsRegular() {
+		var err error
+		stream, e.Size, err = compress(r, w.c)
+		if err != nil {
+			return err
+		}
+	}
+	return w.write(e, stream)
+}
+
+// AddDir adds the contents of the directory dir, with names relative to dir.
+// Symbolic links are stored, not followed. Other special files, such as
+// devices and sockets, are skipped.
+//
+// Files are read and compressed concurrently but stored in the order
+// filepath.WalkDir visits them.
+func (w *Writer) AddDir(dir string) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	type job struct {
+		e    Entry
+		path string
+	}
+	var jobs []job
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		e := Entry{Name: filepath.ToSlash(rel), Mode: info.Mode(), ModTime: info.ModTime()}
+		switch {
+		case e.Mode.IsRegular(), e.Mode.IsDir():
+		case e.Mode&fs.ModeSymlink != 0:
+			if e.Linkname, err = os.Readlink(path); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+		if err := w.check(e); err != nil {
+			return err
+		}
+		jobs = append(jobs, job{e: e, path: path})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Up to c.concurrency files are compressed ahead of the one being
+	// written; a slot is freed once its file is in the archive.
+	type result struct {
+		stream []byte
+		size   int64
+		err    error
+	}
+	results := make([]chan result, len(jobs))
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
+	slots := make(chan struct{}, w.c.concurrency)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for i, j := range jobs {
+			if !j.e.Mode.IsRegular() {
+				continue
+			}
+			select {
+			case slots <- struct{}{}:
+			case <-done:
+				return
+			}
+			go func(i int, path string) {
+				var r result
+				r.stream, r.size, r.err = compressFile(path, w.c)
+				results[i] <- r
+			}(i, j.path)
+		}
+	}()
+
+	for i, j := range jobs {
+		var stream []byte
+		if j.e.Mode.IsRegular() {
+			r := <-results[i]
+			<-slots
+			if r.err != nil {
+				return r.err
+			}
+			stream, j.e.Size = r.stream, r.size
+		}
+		if err := w.write(j.e, stream); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check validates an entry before anything is compressed or written.
+func (w *Writer) check(e Entry) error {
+	if w.closed {
+		return errors.New("archive: write to closed Writer")
+	}
+	if !validName(e.Name) || e.Name == IndexName {
+		return fmt.Errorf("archive: invalid entry name %q", e.Name)
+	}
+	if w.names[e.Name] {
+		return fmt.Errorf("archive: duplicate entry %q", e.Name)
+	}
+	if !e.Mode.IsRegular() && !e.Mode.IsDir() && e.Mode&fs.ModeSymlink == 0 {
+		return fmt.Errorf("archive: %s: unsupported file type %v", e.Name, e.Mode.Type())
+	}
+	return nil
+}
+
+// write stores an entry, with stream as the data of a regular file.
+func (w *Writer) write(e Entry, stream []byte) error {
+	hdr := &tar.Header{
+		Name:    e.Name,
+		Mode:    tarMode(e.Mode),
+		ModTime: e.ModTime,
+		Format:  tar.FormatPAX,
+	}
+	switch {
+	case e.Mode.IsDir():
+		hdr.Typeflag = tar.TypeDir
+		hdr.Name += "/"
+	case e.Mode&fs.ModeSymlink != 0:
+		hdr.Typeflag = tar.TypeSymlink
+		hdr.Linkname = e.Linkname
+	default:
+		hdr.Typeflag = tar.TypeReg
+		hdr.Size = int64(len(stream))
+		hdr.PAXRecords = map[string]string{sizeRecord: strconv.FormatInt(e.Size, 10)}
+	}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		w.err = err
+		return err
+	}
+	offset := w.cw.n
+	if _, err := w.tw.Write(stream); err != nil {
+		w.err = err
+		return err
+	}
+
+	w.names[e.Name] = true
+	ie := indexEntry{
+		Name:     e.Name,
+		Mode:     e.Mode,
+		ModTime:  e.ModTime.UnixNano(),
+		Linkname: e.Linkname,
+	}
+	if e.Mode.IsRegular() {
+		ie.Size = e.Size
+		ie.CompressedSize = int64(len(stream))
+		ie.Offset = offset
+	}
+	w.entries = append(w.entries, ie)
+	return nil
+}
+
+// Close writes the index, the end-of-archive marker and the footer. It does
+// not close the underlying writer, nor a pool set with WithPool.
+//
+// It is safe to call Close multiple times.
+func (w *Writer) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	if w.ownPool {
+		defer w.c.pool.Close()
+	}
+	if w.err != nil {
+		return w.err
+	}
+	w.err = w.finish()
+	return w.err
+}
+
+func (w *Writer) finish() error {
+	data, err := json.Marshal(index{Version: indexVersion, Entries: w.entries})
+	if err != nil {
+		return err
+	}
+	stream, _, err := compress(bytes.NewReader(data), w.c)
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     IndexName,
+		Mode:     0o644,
+		Size:     int64(len(stream)),
+		ModTime:  time.Unix(0, 0),
+		Format:   tar.FormatPAX,
+	}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	offset := w.cw.n
+	if _, err := w.tw.Write(stream); err != nil {
+		return err
+	}
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	_, err = w.cw.Write(appendFooter(nil, offset, len(stream)))
+	return err
+}
+
+// compress reads r to EOF and returns it as an OpenZL stream, along with the
+// number of uncompressed bytes.
+func compress(r io.Reader, c *config) ([]byte, int64, error) {
+	var buf bytes.Buffer
+	zw, err := openzl.NewWriter(&buf, c.streamOptions()...)
+	if err != nil {
+		return nil, 0, err
+	}
+	n, err := io.Copy(zw, r)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), n, nil
+}
+
+func compressFile(path string, c *config) ([]byte, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	return compress(f, c)
+}
+
+// tarMode converts the permission and special bits of m to a tar mode.
+func tarMode(m fs.FileMode) int64 {
+	mode := int64(m.Perm())
+	if m&fs.ModeSetuid != 0 {
+		mode |= 0o4000
+	}
+	if m&fs.ModeSetgid != 0 {
+		mode |= 0o2000
+	}
+	if m&fs.ModeSticky != 0 {
+		mode |= 0o1000
+	}
+	return mode
+}
+
+// validName reports whether name can be stored in and safely extracted from
+// an archive.
+func validName(name string) bool {
+	return name != "." && fs.ValidPath(name)
+}
+
+// countingWriter tracks the archive offset so that the index can record
+// where each file's data starts.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,247 @@
+package openzl
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Trial is the outcome of compressing the tuning samples with one candidate
+// graph.
+type Trial struct {
+	Graph        Graph
+	InputSize    int           // Total size of the samples
+	OutputSize   int           // Total size of the frames
+	CompressTime time.Duration // Time spent compressing the samples
+	Err          error         // Why the candidate could not compress the samples, or nil
+}
+
+// Ratio returns InputSize divided by OutputSize, or 0 if nothing was
+// produced.
+func (t Trial) Ratio() float64 {
+	if t.OutputSize == 0 {
+		return 0
+	}
+	return float64(t.InputSize) / float64(t.OutputSize)
+}
+
+// MBps returns the compression speed in megabytes (10^6 bytes) of input per
+// second, or 0 if no time was measured.
+func (t Trial) MBps() float64 {
+	if t.CompressTime <= 0 {
+		return 0
+	}
+	return float64(t.InputSize) / 1e6 / t.CompressTime.Seconds()
+}
+
+// Objective scores a successful trial; the candidate with the highest score
+// wins, and ties go to the earlier candidate.
+type Objective func(Trial) float64
+
+// ObjectiveRatio prefers the smallest output.
+func ObjectiveRatio(t Trial) float64 {
+	return t.Ratio()
+}
+
+// ObjectiveSpeed prefers the fastest compression.
+func ObjectiveSpeed(t Trial) float64 {
+	return t.MBps()
+}
+
+// ObjectiveBalanced trades ratio against speed, scoring ratio × MB/s^weight.
+// Weight 0 only considers the ratio; weight 1 values doubling the speed as
+// much as doubling the ratio.
+func ObjectiveBalanced(weight float64) Objective {
+	return func(t Trial) float64 {
+		return t.Ratio() * math.Pow(t.MBps(), weight)
+	}
+}
+
+// Tuning is the result of trial-compressing samples with every candidate.
+type Tuning struct {
+	Graph  Graph   // The winning candidate
+	Score  float64 // The winner's score under the objective
+	Trials []Trial // One per candidate, in candidate order
+
+	opts []Option // the options the trials ran with
+}
+
+// Option returns an option applying the options the trials ran with, such as
+// WithLevel, and selecting the winning graph, for NewContext, NewPool,
+// NewWriter and the other constructors.
+func (t *Tuning) Option() Option {
+	return func(o *options) {
+		for _, opt := range t.opts {
+			opt(o)
+		}
+		o.graph = t.Graph
+	}
+}
+
+// AutoTune compresses samples with each of candidates and returns the graph
+// scoring highest under objective. Nil candidates select Graphs(); a nil
+// objective selects ObjectiveRatio. Candidates that fail to compress a sample,
+// such as graphs requiring typed inputs, are skipped.
+//
+// Samples should be representative of the data to compress and large enough
+// for timings to be meaningful. Options such as WithLevel apply to every
+// trial. Services tuning repeatedly should use a Tuner, which keeps its
+// contexts between tunings.
+func AutoTune(samples [][]byte, candidates []Graph, objective Objective, opts ...Option) (*Tuning, error) {
+	t := NewTuner(candidates, objective, opts...)
+	defer t.Close()
+	return t.Tune(samples)
+}
+
+// Tuner trial-compresses samples on pooled contexts and caches the decision
+// per key, so that a long-running service can pick a graph for each kind of
+// payload once and reuse it. A Tuner is safe for concurrent use.
+//
+//	tuner := openzl.NewTuner(nil, openzl.ObjectiveBalanced(0.5))
+//	defer tuner.Close()
+//
+//	tuning, err := tuner.Decide("events", samples)
+//	if err != nil {
+//		return err
+//	}
+//	ctx, err := openzl.NewContext(tuning.Option())
+type Tuner struct {
+	candidates []Graph
+	objective  Objective
+	opts       []Option
+	pools      []*Pool // one per candidate
+
+	mu        sync.Mutex
+	decisions map[string]*decision
+}
+
+// decision is a cached tuning, or one being computed while done is open.
+type decision struct {
+	done   chan struct{}
+	tuning *Tuning
+	err    error
+}
+
+// NewTuner returns a Tuner choosing among candidates under objective, with
+// the same defaults as AutoTune. Each candidate gets a Pool created with
+// opts, so WithConcurrency bounds the contexts kept per candidate.
+func NewTuner(candidates []Graph, objective Objective, opts ...Option) *Tuner {
+	if candidates == nil {
+		candidates = Graphs()
+	}
+	if objective == nil {
+		objective = ObjectiveRatio
+	}
+	t := &Tuner{
+		candidates: append([]Graph(nil), candidates...),
+		objective:  objective,
+		opts:       append([]Option(nil), opts...),
+		decisions:  make(map[string]*decision),
+	}
+	for _, g := range t.candidates {
+		t.pools = append(t.pools, NewPool(append(opts[:len(opts):len(opts)], WithGraph(g))...))
+	}
+	return t
+}
+
+// Tune compresses samples with every candidate and returns the best one. It
+// does not consult or update the cache.
+func (t *Tuner) Tune(samples [][]byte) (*Tuning, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("openzl: no samples to tune with")
+	}
+	if len(t.candidates) == 0 {
+		return nil, errors.New("openzl: no candidate graphs")
+	}
+
+	tuning := &Tuning{Trials: make([]Trial, len(t.candidates)), opts: t.opts}
+	found := false
+	for i, g := range t.candidates {
+		trial := t.trial(t.pools[i], g, samples)
+		tuning.Trials[i] = trial
+		if trial.Err != nil {
+			continue
+		}
+		if score := t.objective(trial); !found || score > tuning.Score {
+			tuning.Graph, tuning.Score, found = g, score, true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("openzl: no candidate graph could compress the samples: %w", tuning.Trials[0].Err)
+	}
+	return tuning, nil
+}
+
+func (t *Tuner) trial(pool *Pool, g Graph, samples [][]byte) Trial {
+	trial := Trial{Graph: g}
+	ctx, err := pool.Get()
+	if err != nil {
+		trial.Err = err
+		return trial
+	}
+	defer pool.Put(ctx)
+
+	// An untimed warm-up keeps one-off allocations out of the timings.
+	if _, err := ctx.Compress(samples[0]); err != nil {
+		trial.Err = err
+		return trial
+	}
+	for _, sample := range samples {
+		start := time.Now()
+		frame, err := ctx.Compress(sample)
+		trial.CompressTime += time.Since(start)
+		if err != nil {
+			trial.Err = err
+			return trial
+		}
+		trial.InputSize += len(sample)
+		trial.OutputSize += len(frame)
+	}
+	return trial
+}
+
+// Decide returns the cached tuning for key, tuning with samples the first
+// time key is seen. Concurrent calls for the same key wait for a single
+// tuning. Failed tunings are not cached.
+func (t *Tuner) Decide(key string, samples [][]byte) (*Tuning, error) {
+	t.mu.Lock()
+	if d, ok := t.decisions[key]; ok {
+		t.mu.Unlock()
+		<-d.done
+		return d.tuning, d.err
+	}
+	d := &decision{done: make(chan struct{})}
+	t.decisions[key] = d
+	t.mu.Unlock()
+
+	d.tuning, d.err = t.Tune(samples)
+	if d.err != nil {
+		// Forget may have let another Decide replace d in the meantime.
+		t.mu.Lock()
+		if t.decisions[key] == d {
+			delete(t.decisions, key)
+		}
+		t.mu.Unlock()
+	}
+	close(d.done)
+	return d.tuning, d.err
+}
+
+// Forget drops the cached decision for key, so that the next Decide tunes
+// again, for example after the data has changed.
+func (t *Tuner) Forget(key string) {
+	t.mu.Lock()
+	delete(t.decisions, key)
+	t.mu.Unlock()
+}
+
+// Close closes the Tuner's pools. Cached decisions remain available, but new
+// tunings create and close contexts as they go.
+func (t *Tuner) Close() error {
+	for _, p := range t.pools {
+		p.Close()
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package openzl
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func tuneSamples() [][]byte {
+	return [][]byte{
+		bytes.Repeat([]byte("sample one, repeated. "), 200),
+		bytes.Repeat([]byte("sample two is a little different. "), 150),
+	}
+}
+
+func TestAutoTune(t *testing.T) {
+	candidates := []Graph{GraphZstd, GraphDefault, GraphStore}
+	tuning, err := AutoTune(tuneSamples(), candidates, ObjectiveRatio)
+	if err != nil {
+		t.Fatalf("AutoTune() failed: %v", err)
+	}
+	if len(tuning.Trials) != len(candidates) {
+		t.Fatalf("got %d trials, want %d", len(tuning.Trials), len(candidates))
+	}
+	for i, trial := range tuning.Trials {
+		if trial.Graph != candidates[i] || trial.Err != nil || trial.InputSize == 0 {
+			t.Errorf("trial %d = %+v", i, trial)
+		}
+	}
+	// Storing never beats compressing repetitive text.
+	if tuning.Graph == GraphStore {
+		t.Errorf("AutoTune() picked %v", tuning.Graph)
+	}
+
+	ctx, err := NewContext(tuning.Option())
+	if err != nil {
+		t.Fatalf("NewContext() failed: %v", err)
+	}
+	defer ctx.Close()
+	if _, err := ctx.Compress([]byte("tuned")); err != nil {
+		t.Fatalf("Compress() failed: %v", err)
+	}
+}
+
+func TestTuningOption(t *testing.T) {
+	tuning, err := AutoTune(tuneSamples(), []Graph{GraphZstd}, nil, WithLevel(3), WithGraph(GraphStore))
+	if err != nil {
+		t.Fatalf("AutoTune() failed: %v", err)
+	}
+	// The trial options apply, but the winner overrides their graph.
+	o := newOptions([]Option{WithLevel(1), tuning.Option()})
+	if o.level != 3 || o.graph != GraphZstd {
+		t.Errorf("Option() set level %d and graph %v, want 3 and %v", o.level, o.graph, GraphZstd)
+	}
+}
+
+func TestAutoTuneObjective(t *testing.T) {
+	// The objective decides, whatever the measurements.
+	prefer := func(g Graph) Objective {
+		return func(trial Trial) float64 {
+			if trial.Graph == g {
+				return 1
+			}
+			return 0
+		}
+	}
+	for _, g := range []Graph{GraphStore, GraphZstd} {
+		tuning, err := AutoTune(tuneSamples(), []Graph{GraphDefault, GraphStore, GraphZstd}, prefer(g))
+		if err != nil {
+			t.Fatalf("AutoTune() failed: %v", err)
+		}
+		if tuning.Graph != g {
+			t.Errorf("AutoTune() picked %v, want %v", tuning.Graph, g)
+		}
+	}
+
+	if _, err := AutoTune(nil, nil, nil); err == nil {
+		t.Error("AutoTune() without samples should fail")
+	}
+	if _, err := AutoTune(tuneSamples(), []Graph{Graph(99)}, nil); err == nil {
+		t.Error("AutoTune() with only invalid candidates should fail")
+	}
+}
+
+func TestTunerDecide(t *testing.T) {
+	var trials atomic.Int64
+	tuner := NewTuner([]Graph{GraphDefault, GraphZstd}, func(trial Trial) float64 {
+		trials.Add(1)
+		return trial.Ratio()
+	})
+	defer tuner.Close()
+
+	var wg sync.WaitGroup
+	tunings := make([]*Tuning, 8)
+	for i := range tunings {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tuning, err := tuner.Decide("events", tuneSamples())
+			if err != nil {
+				t.Errorf("Decide() failed: %v", err)
+			}
+			tunings[i] = tuning
+		}(i)
+	}
+	wg.Wait()
+	for _, tuning := range tunings[1:] {
+		if tuning != tunings[0] {
+			t.Fatal("concurrent Decide() calls returned different tunings")
+		}
+	}
+	if got := trials.Load(); got != 2 {
+		t.Errorf("objective called %d times, want 2 (one tuning)", got)
+	}
+
+	if _, err := tuner.Decide("logs", tuneSamples()); err != nil {
+		t.Fatal(err)
+	}
+	tuner.Forget("events")
+	if _, err := tuner.Decide("events", tuneSamples()); err != nil {
+		t.Fatal(err)
+	}
+	if got := trials.Load(); got != 6 {
+		t.Errorf("objective called %d times, want 6 (three tunings)", got)
+	}
+
+	// Failed tunings are retried.
+	if _, err := tuner.Decide("empty", nil); err == nil {
+		t.Fatal("Decide() without samples should fail")
+	}
+	if _, err := tuner.Decide("empty", tuneSamples()); err != nil {
+		t.Fatalf("Decide() after a failure failed: %v", err)
+	}
+}
+
+// TestTunerForgetDuringFailure checks that a failing Decide does not drop the
+// decision of a Decide that started after Forget.
+func TestTunerForgetDuringFailure(t *testing.T) {
+	obs := &pausingObserver{started: make(chan int), proceed: []chan struct{}{make(chan struct{}), make(chan struct{})}}
+	tuner := NewTuner([]Graph{Graph(99)}, nil, WithObserver(obs))
+	defer tuner.Close()
+
+	decide := func() <-chan struct{} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if _, err := tuner.Decide("key", tuneSamples()); err == nil {
+				t.Error("Decide() with an invalid graph should fail")
+			}
+		}()
+		<-obs.started
+		return done
+	}
+	first := decide()
+	tuner.Forget("key")
+	second := decide()
+
+	// The first tuning fails while the second is in flight.
+	close(obs.proceed[0])
+	<-first
+	tuner.mu.Lock()
+	_, ok := tuner.decisions["key"]
+	tuner.mu.Unlock()
+	if !ok {
+		t.Error("failed Decide() dropped the decision of a newer Decide()")
+	}
+	close(obs.proceed[1])
+	<-second
+}
+
+// pausingObserver pauses the first len(proceed) contexts created by pools
+// until the matching channel is closed, sending on started as each one
+// pauses.
+type pausingObserver struct {
+	started chan int
+	proceed []chan struct{}
+	n       atomic.Int64
+}
+
+func (o *pausingObserver) Start(_ context.Context, op Op) func(Event) {
+	if op != OpPoolNew {
+		return nil
+	}
+	if n := int(o.n.Add(1)) - 1; n < len(o.proceed) {
+		o.started <- n
+		<-o.proceed[n]
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package openzl
+
+import (
+	"context"
+	"time"
+
+	"github.com/gus3inov/openzl-go/internal/copenzl"
+)
+
+// CompressBatch compresses each of srcs into its own frame, as Compress
+// would, in a fixed number of cgo calls regardless of len(srcs). For many
+// small buffers this avoids paying the cgo call overhead once per buffer.
+//
+// The frames are returned in order and share one backing array, so keeping
+// any of them alive keeps the whole batch in memory; copy frames that outlive
+// the others. Empty buffers produce empty frames. If any buffer fails to
+// compress, no frames are returned.
+func (c *Context) CompressBatch(srcs [][]byte) ([][]byte, error) {
+	if c.ctx == nil {
+		return nil, &Error{Code: -1, Message: "context is closed"}
+	}
+	ob := observe(c.observer, context.Background(), OpCompressBatch)
+	start := time.Now()
+	out, err := copenzl.OpenZLCompressBatch(c.ctx, srcs)
+	in, size := batchSize(srcs), batchSize(out)
+	c.counters.compressedN(len(srcs), in, size, time.Since(start), err)
+	ob.add(in, size)
+	ob.end(err)
+	return out, err
+}
+
+// DecompressBatch decompresses each of frames, as Decompress would, in a
+// fixed number of cgo calls regardless of len(frames). As with
+// CompressBatch, the outputs share one backing array, and an error in any
+// frame fails the whole batch.
+func (c *Context) DecompressBatch(frames [][]byte) ([][]byte, error) {
+	if c.ctx == nil {
+		return nil, &Error{Code: -1, Message: "context is closed"}
+	}
+	ob := observe(c.observer, context.Background(), OpDecompressBatch)
+	start := time.Now()
+	out, err := copenzl.OpenZLDecompressBatch(c.ctx, frames)
+	in, size := batchSize(frames), batchSize(out)
+	c.counters.decompressedN(len(frames), in, size, time.Since(start), err)
+	ob.add(in, size)
+	ob.end(err)
+	return out, err
+}
+
+// CompressBatch compresses each of srcs into its own frame; see
+// Context.CompressBatch.
+func (z *Compressor) CompressBatch(srcs [][]byte) ([][]byte, error) {
+	return z.c.CompressBatch(srcs)
+}
+
+// DecompressBatch decompresses each of frames; see Context.DecompressBatch.
+func (z *Decompressor) DecompressBatch(frames [][]byte) ([][]byte, error) {
+	return z.c.DecompressBatch(frames)
+}
+
+func batchSize(bufs [][]byte) int {
+	n := 0
+	for _, b := range bufs {
+		n += len(b)
+	}
+	return n
+}
@@ -0,0 +1,173 @@
+package openzl
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// smallMessages returns n distinct messages of a few dozen bytes, the
+// workload the batch API is meant for.
+func smallMessages(n int) [][]byte {
+	msgs := make([][]byte, n)
+	for i := range msgs {
+		msgs[i] = []byte(fmt.Sprintf(`{"id":%d,"user":"user-%d","event":"click","x":%d}`, i, i%97, i*7%1000))
+	}
+	return msgs
+}
+
+func TestBatch(t *testing.T) {
+	ctx, err := NewContext()
+	if err != nil {
+		t.Fatalf("NewContext() failed: %v", err)
+	}
+	defer ctx.Close()
+
+	srcs := smallMessages(100)
+	srcs[10] = nil
+	srcs[50] = bytes.Repeat([]byte("larger message "), 1000)
+
+	frames, err := ctx.CompressBatch(srcs)
+	if err != nil {
+		t.Fatalf("CompressBatch() failed: %v", err)
+	}
+	if len(frames) != len(srcs) {
+		t.Fatalf("CompressBatch() returned %d frames, want %d", len(frames), len(srcs))
+	}
+	if len(frames[10]) != 0 {
+		t.Errorf("empty buffer compressed to %d bytes", len(frames[10]))
+	}
+	// Each frame must be an ordinary frame.
+	for _, i := range []int{0, 50, 99} {
+		got, err := ctx.Decompress(frames[i])
+		if err != nil {
+			t.Fatalf("Decompress(frame %d) failed: %v", i, err)
+		}
+		if !bytes.Equal(got, srcs[i]) {
+			t.Fatalf("frame %d does not restore its input", i)
+		}
+	}
+
+	ctx.ResetCounters()
+	outs, err := ctx.DecompressBatch(frames)
+	if err != nil {
+		t.Fatalf("DecompressBatch() failed: %v", err)
+	}
+	for i := range srcs {
+		if !bytes.Equal(outs[i], srcs[i]) {
+			t.Fatalf("output %d differs from its input", i)
+		}
+	}
+	if got := ctx.Counters().Decompressions; got != int64(len(frames)) {
+		t.Errorf("Decompressions = %d, want %d", got, len(frames))
+	}
+
+	// Outputs share a backing array, but appending to one must not
+	// overwrite the next.
+	_ = append(outs[0], "overflow"...)
+	if !bytes.Equal(outs[1], srcs[1]) {
+		t.Error("appending to an output overwrote the next one")
+	}
+
+	frames[20] = []byte("not a frame")
+	if _, err := ctx.DecompressBatch(frames); err == nil {
+		t.Error("DecompressBatch() should fail on an invalid frame")
+	}
+
+	if out, err := ctx.CompressBatch(nil); err != nil || len(out) != 0 {
+		t.Errorf("CompressBatch(nil) = %v, %v", out, err)
+	}
+}
+
+func TestBatchClosedContext(t *testing.T) {
+	ctx, err := NewContext()
+	if err != nil {
+		t.Fatalf("NewContext() failed: %v", err)
+	}
+	ctx.Close()
+	if _, err := ctx.CompressBatch(smallMessages(2)); err == nil {
+		t.Error("CompressBatch() on a closed context should fail")
+	}
+	if _, err := ctx.DecompressBatch([][]byte{{1}}); err == nil {
+		t.Error("DecompressBatch() on a closed context should fail")
+	}
+}
+
+// The benchmarks below compare compressing many small messages one Compress
+// call at a time with a single CompressBatch call. Besides time per message
+// they report cgo-calls/msg, the number of Go-to-C transitions per message.
+
+const batchMessages = 10000
+
+func reportPerMessage(b *testing.B, cgoCalls int64) {
+	b.ReportMetric(float64(cgoCalls)/float64(b.N*batchMessages), "cgo-calls/msg")
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*batchMessages), "ns/msg")
+}
+
+func BenchmarkCompressSmall(b *testing.B) {
+	msgs := smallMessages(batchMessages)
+	ctx, err := NewContext()
+	if err != nil {
+		b.Fatalf("NewContext() failed: %v", err)
+	}
+	defer ctx.Close()
+
+	b.Run("Loop", func(b *testing.B) {
+		b.ReportAllocs()
+		calls := runtime.NumCgoCall()
+		for i := 0; i < b.N; i++ {
+			for _, msg := range msgs {
+				if _, err := ctx.Compress(msg); err != nil {
+					b.Fatalf("Compress() failed: %v", err)
+				}
+			}
+		}
+		reportPerMessage(b, runtime.NumCgoCall()-calls)
+	})
+	b.Run("Batch", func(b *testing.B) {
+		b.ReportAllocs()
+		calls := runtime.NumCgoCall()
+		for i := 0; i < b.N; i++ {
+			if _, err := ctx.CompressBatch(msgs); err != nil {
+				b.Fatalf("CompressBatch() failed: %v", err)
+			}
+		}
+		reportPerMessage(b, runtime.NumCgoCall()-calls)
+	})
+}
+
+func BenchmarkDecompressSmall(b *testing.B) {
+	ctx, err := NewContext()
+	if err != nil {
+		b.Fatalf("NewContext() failed: %v", err)
+	}
+	defer ctx.Close()
+	frames, err := ctx.CompressBatch(smallMessages(batchMessages))
+	if err != nil {
+		b.Fatalf("CompressBatch() failed: %v", err)
+	}
+
+	b.Run("Loop", func(b *testing.B) {
+		b.ReportAllocs()
+		calls := runtime.NumCgoCall()
+		for i := 0; i < b.N; i++ {
+			for _, frame := range frames {
+				if _, err := ctx.Decompress(frame); err != nil {
+					b.Fatalf("Decompress() failed: %v", err)
+				}
+			}
+		}
+		reportPerMessage(b, runtime.NumCgoCall()-calls)
+	})
+	b.Run("Batch", func(b *testing.B) {
+		b.ReportAllocs()
+		calls := runtime.NumCgoCall()
+		for i := 0; i < b.N; i++ {
+			if _, err := ctx.DecompressBatch(frames); err != nil {
+				b.Fatalf("DecompressBatch() failed: %v", err)
+			}
+		}
+		reportPerMessage(b, runtime.NumCgoCall()-calls)
+	})
+}
@@ -0,0 +1,271 @@
+// Package bench measures OpenZL against other codecs on a corpus of files.
+//
+// A benchmark run sweeps every combination of the configured graphs, levels
+// and typed modes, plus optional standard library baselines (gzip and
+// flate), over every file in the corpus. For each combination it reports the
+// compression ratio, compression and decompression throughput, and Go heap
+// allocations per operation:
+//
+//	files, err := bench.LoadCorpus("testdata/")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	results, err := bench.Run(files, bench.Config{
+//		Graphs:    []openzl.Graph{openzl.GraphDefault, openzl.GraphZstd},
+//		Modes:     []bench.Mode{{}, {Type: openzl.TypeNumeric, Width: 8}},
+//		Baselines: []string{"gzip", "flate:9"},
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	bench.WriteTable(os.Stdout, results)
+//
+// Allocation figures cover the Go heap only; memory allocated inside the
+// OpenZL C library is not visible to the Go runtime.
+package bench
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+// File is one corpus entry.
+type File struct {
+	Name string
+	Data []byte
+}
+
+// LoadCorpus reads the given files, and every regular file below the given
+// directories, in lexical order.
+func LoadCorpus(paths ...string) ([]File, error) {
+	var files []File
+	for _, root := range paths {
+		var names []string
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() {
+				names = append(names, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			data, err := os.ReadFile(name)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, File{Name: name, Data: data})
+		}
+	}
+	return files, nil
+}
+
+// Config selects the codecs to compare.
+type Config struct {
+	Graphs     []openzl.Graph // OpenZL graphs; defaults to GraphDefault
+	Levels     []int          // OpenZL compression levels; defaults to 0 (library default)
+	Modes      []Mode         // Typed input modes; defaults to serial
+	Baselines  []string       // Standard library codecs, see ParseBaseline
+	Iterations int            // Runs per measurement, fastest wins; defaults to 3
+}
+
+// Codecs returns one codec per configured combination. The caller must close
+// them.
+func (c Config) Codecs() ([]Codec, error) {
+	graphs := c.Graphs
+	if len(graphs) == 0 {
+		graphs = []openzl.Graph{openzl.GraphDefault}
+	}
+	levels := c.Levels
+	if len(levels) == 0 {
+		levels = []int{0}
+	}
+	modes := c.Modes
+	if len(modes) == 0 {
+		modes = []Mode{{}}
+	}
+
+	var codecs []Codec
+	fail := func(err error) ([]Codec, error) {
+		for _, codec := range codecs {
+			codec.Close()
+		}
+		return nil, err
+	}
+	for _, g := range graphs {
+		for _, level := range levels {
+			for _, mode := range modes {
+				codec, err := OpenZL(g, level, mode)
+				if err != nil {
+					return fail(err)
+				}
+				codecs = append(codecs, codec)
+			}
+		}
+	}
+	for _, name := range c.Baselines {
+		codec, err := ParseBaseline(name)
+		if err != nil {
+			return fail(err)
+		}
+		codecs = append(codecs, codec)
+	}
+	return codecs, nil
+}
+
+// Result is the measurement of one codec on one file.
+type Result struct {
+	File             string  `json:"file"`
+	Codec            string  `json:"codec"`
+	InputSize        int     `json:"input_size"`
+	CompressedSize   int     `json:"compressed_size"`
+	Ratio            float64 `json:"ratio"`
+	CompressMBps     float64 `json:"compress_mbps"`
+	DecompressMBps   float64 `json:"decompress_mbps"`
+	CompressAllocs   uint64  `json:"compress_allocs_per_op"`
+	CompressBytes    uint64  `json:"compress_bytes_per_op"`
+	DecompressAllocs uint64  `json:"decompress_allocs_per_op"`
+	DecompressBytes  uint64  `json:"decompress_bytes_per_op"`
+	Error            string  `json:"error,omitempty"` // Set when the codec rejected the input
+}
+
+// Run measures every configured codec on every file.
+//
+// A codec that fails on a particular file (for example a numeric graph given
+// text) does not abort the run; the failure is recorded in Result.Error.
+func Run(files []File, cfg Config) ([]Result, error) {
+	codecs, err := cfg.Codecs()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, codec := range codecs {
+			codec.Close()
+		}
+	}()
+
+	iterations := cfg.Iterations
+	if iterations < 1 {
+		iterations = 3
+	}
+
+	var results []Result
+	for _, f := range files {
+		for _, codec := range codecs {
+			r, err := Measure(codec, f, iterations)
+			if err != nil {
+				r = Result{File: f.Name, Codec: codec.Name(), InputSize: len(f.Data), Error: err.Error()}
+			}
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+// errMismatch reports a round trip that did not reproduce the input.
+var errMismatch = errors.New("round trip mismatch")
+
+// Measure compresses and decompresses f iterations times with codec,
+// verifying the round trip, and reports the fastest run of each.
+func Measure(codec Codec, f File, iterations int) (Result, error) {
+	r := Result{File: f.Name, Codec: codec.Name(), InputSize: len(f.Data)}
+
+	var compressed []byte
+	elapsed, allocs, allocBytes, err := measure(iterations, func() (err error) {
+		compressed, err = codec.Compress(f.Data)
+		return err
+	})
+	if err != nil {
+		return r, err
+	}
+	r.CompressedSize = len(compressed)
+	r.CompressMBps = mbps(len(f.Data), elapsed)
+	r.CompressAllocs, r.CompressBytes = allocs, allocBytes
+
+	elapsed, allocs, allocBytes, err = measure(iterations, func() error {
+		decompressed, err := codec.Decompress(compressed)
+		if err == nil && !bytes.Equal(decompressed, f.Data) {
+			err = errMismatch
+		}
+		return err
+	})
+	if err != nil {
+		return r, err
+	}
+	r.DecompressMBps = mbps(len(f.Data), elapsed)
+	r.DecompressAllocs, r.DecompressBytes = allocs, allocBytes
+
+	if r.CompressedSize > 0 {
+		r.Ratio = float64(len(f.Data)) / float64(r.CompressedSize)
+	}
+	return r, nil
+}
+
+// measure runs fn iterations times and returns the fastest run together with
+// the average Go heap allocations per run.
+func measure(iterations int, fn func() error) (fastest time.Duration, allocs, allocBytes uint64, err error) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	for i := 0; i < iterations; i++ {
+		start := time.Now()
+		if err := fn(); err != nil {
+			return 0, 0, 0, err
+		}
+		if d := time.Since(start); i == 0 || d < fastest {
+			fastest = d
+		}
+	}
+	runtime.ReadMemStats(&after)
+	n := uint64(iterations)
+	return fastest, (after.Mallocs - before.Mallocs) / n, (after.TotalAlloc - before.TotalAlloc) / n, nil
+}
+
+func mbps(n int, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / 1e6 / d.Seconds()
+}
+
+// WriteTable writes results as an aligned text table.
+func WriteTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "file\tcodec\tsize\tcompressed\tratio\tcompress\tdecompress\tallocs/op\t")
+	for _, r := range results {
+		if r.Error != "" {
+			fmt.Fprintf(tw, "%s\t%s\t%d\terror: %s\t\t\t\t\t\n", r.File, r.Codec, r.InputSize, r.Error)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.2fx\t%.1f MB/s\t%.1f MB/s\t%d/%d\t\n",
+			r.File, r.Codec, r.InputSize, r.CompressedSize, r.Ratio,
+			r.CompressMBps, r.DecompressMBps, r.CompressAllocs, r.DecompressAllocs)
+	}
+	return tw.Flush()
+}
+
+// WriteJSON writes results as an indented JSON array.
+func WriteJSON(w io.Writer, results []Result) error {
+	if results == nil {
+		results = []Result{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
@@ -0,0 +1,136 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+func TestParseMode(t *testing.T) {
+	for _, s := range []string{"serial", "numeric1", "numeric8", "struct12"} {
+		m, err := ParseMode(s)
+		if err != nil {
+			t.Fatalf("ParseMode(%q) failed: %v", s, err)
+		}
+		if m.String() != s {
+			t.Fatalf("ParseMode(%q).String() = %q", s, m.String())
+		}
+	}
+	for _, s := range []string{"", "numeric", "numeric3", "struct0", "string4", "bogus"} {
+		if _, err := ParseMode(s); err == nil {
+			t.Fatalf("ParseMode(%q) should fail", s)
+		}
+	}
+}
+
+func TestParseBaseline(t *testing.T) {
+	for _, s := range []string{"gzip", "gzip:9", "flate", "flate:1"} {
+		c, err := ParseBaseline(s)
+		if err != nil {
+			t.Fatalf("ParseBaseline(%q) failed: %v", s, err)
+		}
+		c.Close()
+	}
+	for _, s := range []string{"zstd", "gzip:x", "flate:42"} {
+		if _, err := ParseBaseline(s); err == nil {
+			t.Fatalf("ParseBaseline(%q) should fail", s)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	numbers := make([]byte, 8*1001+3)
+	for i := 0; i < 1001; i++ {
+		binary.LittleEndian.PutUint64(numbers[8*i:], uint64(1700000000+i*7))
+	}
+	files := []File{
+		{Name: "text", Data: bytes.Repeat([]byte("the quick brown fox "), 500)},
+		{Name: "numbers", Data: numbers},
+		{Name: "empty"},
+	}
+	cfg := Config{
+		Graphs:     []openzl.Graph{openzl.GraphDefault, openzl.GraphStore},
+		Modes:      []Mode{{}, {Type: openzl.TypeNumeric, Width: 8}},
+		Baselines:  []string{"gzip", "flate:1"},
+		Iterations: 1,
+	}
+	results, err := Run(files, cfg)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if want := len(files) * (2*2 + 2); len(results) != want {
+		t.Fatalf("Run() returned %d results, want %d", len(results), want)
+	}
+	for _, r := range results {
+		if r.Error != "" {
+			t.Fatalf("%s on %s: %s", r.Codec, r.File, r.Error)
+		}
+		if r.InputSize > 0 && (r.Ratio <= 0 || r.CompressMBps <= 0 || r.DecompressMBps <= 0) {
+			t.Fatalf("%s on %s: incomplete result %+v", r.Codec, r.File, r)
+		}
+	}
+
+	var table bytes.Buffer
+	if err := WriteTable(&table, results); err != nil {
+		t.Fatalf("WriteTable() failed: %v", err)
+	}
+	if got := strings.Count(table.String(), "\n"); got != len(results)+1 {
+		t.Fatalf("WriteTable() wrote %d lines, want %d", got, len(results)+1)
+	}
+
+	var out bytes.Buffer
+	if err := WriteJSON(&out, results); err != nil {
+		t.Fatalf("WriteJSON() failed: %v", err)
+	}
+	var decoded []Result
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("WriteJSON() output does not decode: %v", err)
+	}
+	if len(decoded) != len(results) || decoded[0] != results[0] {
+		t.Fatal("WriteJSON() output does not match results")
+	}
+}
+
+func TestRunRejectsInvalidConfig(t *testing.T) {
+	if _, err := Run(nil, Config{Baselines: []string{"lz4"}}); err == nil {
+		t.Fatal("Run() with unknown baseline should fail")
+	}
+	if _, err := Run(nil, Config{Graphs: []openzl.Graph{openzl.Graph(99)}}); err == nil {
+		t.Fatal("Run() with unknown graph should fail")
+	}
+}
+
+func TestLoadCorpus(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{"b.txt": "bravo", "a.txt": "alpha", "sub/c.txt": "charlie"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := LoadCorpus(dir)
+	if err != nil {
+		t.Fatalf("LoadCorpus() failed: %v", err)
+	}
+	var got []string
+	for _, f := range files {
+		got = append(got, string(f.Data))
+	}
+	if strings.Join(got, ",") != "alpha,bravo,charlie" {
+		t.Fatalf("LoadCorpus() = %v", got)
+	}
+
+	if _, err := LoadCorpus(filepath.Join(dir, "missing")); err == nil {
+		t.Fatal("LoadCorpus() of a missing path should fail")
+	}
+}
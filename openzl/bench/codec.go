@@ -0,0 +1,207 @@
+package bench
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+// Codec is a compressor under test.
+//
+// Codecs are used by one goroutine at a time; Close releases any native
+// resources they hold.
+type Codec interface {
+	Name() string
+	Compress(src []byte) ([]byte, error)
+	Decompress(src []byte) ([]byte, error)
+	Close() error
+}
+
+// Mode describes how OpenZL codecs present the input to the library.
+//
+// The zero Mode passes data as opaque bytes. Numeric and struct modes
+// interpret the input as little-endian integers or fixed-size records of
+// Width bytes; any trailing bytes that do not fill a whole element are sent
+// as a second, serial input.
+type Mode struct {
+	Type  openzl.Type
+	Width int
+}
+
+// String returns the mode in the form accepted by ParseMode, such as
+// "serial", "numeric8" or "struct12".
+func (m Mode) String() string {
+	if m.Type == openzl.TypeSerial {
+		return "serial"
+	}
+	return m.Type.String() + strconv.Itoa(m.Width)
+}
+
+// ParseMode parses "serial", "numeric<width>" or "struct<width>".
+func ParseMode(s string) (Mode, error) {
+	if s == "serial" {
+		return Mode{}, nil
+	}
+	for _, t := range []openzl.Type{openzl.TypeNumeric, openzl.TypeStruct} {
+		prefix := t.String()
+		if !strings.HasPrefix(s, prefix) {
+			continue
+		}
+		width, err := strconv.Atoi(s[len(prefix):])
+		if err != nil || width < 1 {
+			break
+		}
+		if t == openzl.TypeNumeric && width != 1 && width != 2 && width != 4 && width != 8 {
+			break
+		}
+		return Mode{Type: t, Width: width}, nil
+	}
+	return Mode{}, fmt.Errorf("invalid mode %q (want serial, numeric<1|2|4|8> or struct<width>)", s)
+}
+
+// OpenZL returns a codec compressing with a reused Context configured with
+// the given graph and level, presenting input according to mode.
+func OpenZL(graph openzl.Graph, level int, mode Mode) (Codec, error) {
+	ctx, err := openzl.NewContext(openzl.WithGraph(graph), openzl.WithLevel(level))
+	if err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("openzl/%s/L%d", graph, level)
+	if mode.Type != openzl.TypeSerial {
+		name += "/" + mode.String()
+	}
+	return &openzlCodec{name: name, ctx: ctx, mode: mode}, nil
+}
+
+type openzlCodec struct {
+	name string
+	ctx  *openzl.Context
+	mode Mode
+}
+
+func (c *openzlCodec) Name() string { return c.name }
+
+func (c *openzlCodec) Compress(src []byte) ([]byte, error) {
+	if c.mode.Type == openzl.TypeSerial {
+		return c.ctx.Compress(src)
+	}
+	n := len(src) - len(src)%c.mode.Width
+	inputs := []openzl.Input{{Type: c.mode.Type, Data: src[:n], Width: c.mode.Width}}
+	if n < len(src) {
+		inputs = append(inputs, openzl.SerialInput(src[n:]))
+	}
+	return c.ctx.CompressInputs(inputs...)
+}
+
+func (c *openzlCodec) Decompress(src []byte) ([]byte, error) {
+	if c.mode.Type == openzl.TypeSerial {
+		return c.ctx.Decompress(src)
+	}
+	outputs, err := c.ctx.DecompressInputs(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(outputs) == 1 {
+		return outputs[0].Data, nil
+	}
+	var out []byte
+	for _, o := range outputs {
+		out = append(out, o.Data...)
+	}
+	return out, nil
+}
+
+func (c *openzlCodec) Close() error { return c.ctx.Close() }
+
+// Gzip returns a codec using compress/gzip at the given level.
+func Gzip(level int) (Codec, error) {
+	if _, err := gzip.NewWriterLevel(io.Discard, level); err != nil {
+		return nil, err
+	}
+	return &stdlibCodec{
+		name: fmt.Sprintf("gzip/%d", level),
+		newWriter: func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriterLevel(w, level)
+		},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	}, nil
+}
+
+// Flate returns a codec using compress/flate at the given level.
+func Flate(level int) (Codec, error) {
+	if _, err := flate.NewWriter(io.Discard, level); err != nil {
+		return nil, err
+	}
+	return &stdlibCodec{
+		name: fmt.Sprintf("flate/%d", level),
+		newWriter: func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, level)
+		},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return flate.NewReader(r), nil
+		},
+	}, nil
+}
+
+// ParseBaseline returns a standard library codec from a name of the form
+// "gzip", "gzip:<level>", "flate" or "flate:<level>".
+func ParseBaseline(s string) (Codec, error) {
+	name, levelStr, hasLevel := strings.Cut(s, ":")
+	level := flate.DefaultCompression
+	if hasLevel {
+		l, err := strconv.Atoi(levelStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid level in %q", s)
+		}
+		level = l
+	}
+	switch name {
+	case "gzip":
+		return Gzip(level)
+	case "flate":
+		return Flate(level)
+	}
+	return nil, fmt.Errorf("unknown baseline codec %q (want gzip or flate)", name)
+}
+
+type stdlibCodec struct {
+	name      string
+	newWriter func(io.Writer) (io.WriteCloser, error)
+	newReader func(io.Reader) (io.ReadCloser, error)
+}
+
+func (c *stdlibCodec) Name() string { return c.name }
+
+func (c *stdlibCodec) Compress(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := c.newWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *stdlibCodec) Decompress(src []byte) ([]byte, error) {
+	r, err := c.newReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (c *stdlibCodec) Close() error { return nil }
@@ -0,0 +1,133 @@
+package openzl
+
+import (
+	"context"
+
+	"github.com/gus3inov/openzl-go/internal/copenzl"
+)
+
+// Compressor compresses data like a Context, but allocates only OpenZL's
+// compression state. Use it for write-only paths, and Decompressor for
+// read-only ones; Context combines both.
+//
+// A Compressor is not safe for concurrent use.
+type Compressor struct {
+	c *Context
+}
+
+// NewCompressor creates a compression-only context. Compression parameters
+// such as WithLevel, WithGraph and WithFormatVersion apply to every
+// compression; other options are ignored.
+//
+// The compressor must be closed when no longer needed.
+func NewCompressor(opts ...Option) (*Compressor, error) {
+	c, err := newContextMode(newOptions(opts), copenzl.ModeCompress)
+	if err != nil {
+		return nil, err
+	}
+	return &Compressor{c: c}, nil
+}
+
+// Close frees the native context. It is safe to call Close multiple times.
+func (z *Compressor) Close() error {
+	return z.c.Close()
+}
+
+// Compress compresses data into a single frame; see Context.Compress.
+func (z *Compressor) Compress(data []byte) ([]byte, error) {
+	return z.c.Compress(data)
+}
+
+// CompressContext is like Compress but returns ctx.Err() without compressing
+// if ctx is already done; see Context.CompressContext.
+func (z *Compressor) CompressContext(ctx context.Context, data []byte) ([]byte, error) {
+	return z.c.CompressContext(ctx, data)
+}
+
+// CompressInputs compresses typed inputs into a single frame; see
+// Context.CompressInputs.
+func (z *Compressor) CompressInputs(inputs ...Input) ([]byte, error) {
+	return z.c.CompressInputs(inputs...)
+}
+
+// AutoCompress compresses data as Detect suggests; see Context.AutoCompress.
+func (z *Compressor) AutoCompress(data []byte) ([]byte, Detection, error) {
+	return z.c.AutoCompress(data)
+}
+
+// CompressWithStats compresses data like Compress and also reports
+// statistics about the compression.
+func (z *Compressor) CompressWithStats(data []byte) ([]byte, Stats, error) {
+	return z.c.CompressWithStats(data)
+}
+
+// Counters returns cumulative totals over every compression performed with
+// the compressor. It is safe to call from any goroutine.
+func (z *Compressor) Counters() Counters {
+	return z.c.Counters()
+}
+
+// ResetCounters sets all counters to zero.
+func (z *Compressor) ResetCounters() {
+	z.c.ResetCounters()
+}
+
+// Decompressor decompresses frames like a Context, but allocates only
+// OpenZL's decompression state, which it reuses across frames.
+//
+// A Decompressor is not safe for concurrent use.
+type Decompressor struct {
+	c *Context
+}
+
+// NewDecompressor creates a decompression-only context. Frames are
+// self-describing, so no options currently affect decompression; they are
+// accepted for symmetry with NewCompressor.
+//
+// The decompressor must be closed when no longer needed.
+func NewDecompressor(opts ...Option) (*Decompressor, error) {
+	c, err := newContextMode(newOptions(opts), copenzl.ModeDecompress)
+	if err != nil {
+		return nil, err
+	}
+	return &Decompressor{c: c}, nil
+}
+
+// Close frees the native context. It is safe to call Close multiple times.
+func (z *Decompressor) Close() error {
+	return z.c.Close()
+}
+
+// Decompress decompresses a frame; see Context.Decompress.
+func (z *Decompressor) Decompress(frame []byte) ([]byte, error) {
+	return z.c.Decompress(frame)
+}
+
+// DecompressContext is like Decompress but returns ctx.Err() without
+// decompressing if ctx is already done.
+func (z *Decompressor) DecompressContext(ctx context.Context, frame []byte) ([]byte, error) {
+	return z.c.DecompressContext(ctx, frame)
+}
+
+// DecompressAuto restores a frame written by AutoCompress; see
+// Context.DecompressAuto.
+func (z *Decompressor) DecompressAuto(frame []byte) ([]byte, error) {
+	return z.c.DecompressAuto(frame)
+}
+
+// DecompressInputs decompresses a frame into its typed outputs; see
+// Context.DecompressInputs.
+func (z *Decompressor) DecompressInputs(frame []byte) ([]Input, error) {
+	return z.c.DecompressInputs(frame)
+}
+
+// Counters returns cumulative totals over every decompression performed with
+// the decompressor. It is safe to call from any goroutine.
+func (z *Decompressor) Counters() Counters {
+	return z.c.Counters()
+}
+
+// ResetCounters sets all counters to zero.
+func (z *Decompressor) ResetCounters() {
+	z.c.ResetCounters()
+}
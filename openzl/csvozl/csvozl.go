@@ -0,0 +1,469 @@
+// Package csvozl compresses CSV data column by column with OpenZL.
+//
+// Compress parses its input with encoding/csv, infers a type for every
+// column (integer, float or string) and compresses the columns together as a
+// multi-input frame, so that numeric columns are modelled as numbers rather
+// than text. Decompress restores the original bytes exactly, including
+// quoting, delimiters and line endings.
+//
+// Records that cannot be reproduced from their fields, such as the header,
+// records with an unusual number of fields, or records whose quoting does
+// not round-trip, are stored verbatim alongside the columns:
+//
+//	frame, err := csvozl.Compress(data)
+//	if err != nil {
+//		return err
+//	}
+//	restored, err := csvozl.Decompress(frame) // bytes.Equal(restored, data)
+package csvozl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+// ErrInvalidFrame is returned by Decompress when a frame was not produced by
+// Compress or is corrupt.
+var ErrInvalidFrame = errors.New("csvozl: invalid frame")
+
+// Type is the inferred type of a column.
+type Type byte
+
+const (
+	TypeString Type = iota // Arbitrary text
+	TypeInt                // Canonical base-10 int64 values
+	TypeFloat              // float64 values in shortest 'f' format
+)
+
+// String returns the name of the type.
+func (t Type) String() string {
+	switch t {
+	case TypeString:
+		return "string"
+	case TypeInt:
+		return "int"
+	case TypeFloat:
+		return "float"
+	}
+	return "Type(" + strconv.Itoa(int(t)) + ")"
+}
+
+const formatVersion = 1
+
+// Row kinds, one byte per record in the layout input.
+const (
+	rowLF   = iota // Columnar record ending in "\n"
+	rowCRLF        // Columnar record ending in "\r\n"
+	rowEOF         // Columnar record at the end of the input, without a line ending
+	rowRaw         // Record stored verbatim
+)
+
+// Input order within a frame; columns follow.
+const (
+	inputMeta = iota
+	inputLayout
+	inputRaw
+	inputQuotes
+	numFixedInputs
+)
+
+// Option configures Compress and Decompress.
+type Option func(*config)
+
+type config struct {
+	comma rune
+	pool  *openzl.Pool
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{comma: ','}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithComma sets the field delimiter used to parse the input. It defaults to
+// ','. Decompress reads the delimiter from the frame and ignores this option.
+func WithComma(r rune) Option {
+	return func(c *config) {
+		c.comma = r
+	}
+}
+
+// WithPool makes Compress, Decompress and Columns borrow a context from p
+// rather than create and close one per call, which pays off when many small
+// documents are compressed.
+func WithPool(p *openzl.Pool) Option {
+	return func(c *config) {
+		c.pool = p
+	}
+}
+
+// record is one parsed record and the input bytes it was parsed from.
+type record struct {
+	raw    []byte
+	fields []string
+	quoted []bool
+	ending byte // Row kind; rowRaw if the record cannot be rebuilt
+}
+
+// Compress compresses CSV data into a single frame.
+func Compress(data []byte, opts ...Option) ([]byte, error) {
+	c := newConfig(opts)
+	if !validComma(c.comma) {
+		return nil, errors.New("csvozl: invalid delimiter")
+	}
+	records := parse(data, c.comma)
+
+	// The first record is usually a header, so the column count and types
+	// come from the records after it and the first is kept verbatim.
+	if len(records) > 0 {
+		records[0].ending = rowRaw
+	}
+	ncols := -1
+	for i := range records {
+		r := &records[i]
+		if r.ending == rowRaw {
+			continue
+		}
+		if ncols < 0 {
+			ncols = len(r.fields)
+		}
+		if len(r.fields) != ncols {
+			r.ending = rowRaw
+		}
+	}
+	ncols = max(ncols, 0)
+
+	types := make([]Type, ncols)
+	for j := range types {
+		types[j] = inferType(records, j)
+	}
+
+	layout := make([]byte, len(records))
+	var raw [][]byte
+	var quotes []byte
+	columns := make([][]string, ncols)
+	for i, r := range records {
+		layout[i] = r.ending
+		if r.ending == rowRaw {
+			raw = append(raw, r.raw)
+			continue
+		}
+		for j, f := range r.fields {
+			columns[j] = append(columns[j], f)
+			quotes = append(quotes, boolByte(r.quoted[j]))
+		}
+	}
+
+	meta := []byte{formatVersion}
+	meta = binary.AppendUvarint(meta, uint64(c.comma))
+	meta = binary.AppendUvarint(meta, uint64(ncols))
+	for _, t := range types {
+		meta = append(meta, byte(t))
+	}
+
+	inputs := make([]openzl.Input, numFixedInputs, numFixedInputs+ncols)
+	inputs[inputMeta] = openzl.SerialInput(meta)
+	inputs[inputLayout] = openzl.SerialInput(layout)
+	inputs[inputRaw] = openzl.StringInput(raw)
+	inputs[inputQuotes] = openzl.SerialInput(quotes)
+	for j, col := range columns {
+		inputs = append(inputs, encodeColumn(types[j], col))
+	}
+
+	ctx, release, err := openzl.Borrow(c.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return ctx.CompressInputs(inputs...)
+}
+
+// parse splits data into records. A record is marked raw when rendering its
+// fields does not reproduce its input bytes exactly. Input that encoding/csv
+// rejects is kept verbatim as a single final record.
+func parse(data []byte, comma rune) []record {
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	var records []record
+	var start int64
+	for {
+		fields, err := cr.Read()
+		end := cr.InputOffset()
+		if err == io.EOF {
+			if start < int64(len(data)) {
+				records = append(records, record{raw: data[start:], ending: rowRaw})
+			}
+			return records
+		}
+		if err != nil {
+			records = append(records, record{raw: data[start:], ending: rowRaw})
+			return records
+		}
+		r := record{raw: data[start:end], fields: fields}
+		r.quoted, r.ending = match(r.raw, fields, comma)
+		records = append(records, r)
+		start = end
+	}
+}
+
+// match reports how each field of raw is quoted and how the record ends, or
+// rowRaw if the fields cannot be rendered back into raw.
+func match(raw []byte, fields []string, comma rune) ([]bool, byte) {
+	quoted := make([]bool, len(fields))
+	rest := raw
+	for i, f := range fields {
+		if len(rest) > 0 && rest[0] == '"' {
+			quoted[i] = true
+			f = quote(f)
+		}
+		if !bytes.HasPrefix(rest, []byte(f)) {
+			return nil, rowRaw
+		}
+		rest = rest[len(f):]
+		if i < len(fields)-1 {
+			r, n := utf8.DecodeRune(rest)
+			if r != comma || n == 0 {
+				return nil, rowRaw
+			}
+			rest = rest[n:]
+		}
+	}
+	switch string(rest) {
+	case "\n":
+		return quoted, rowLF
+	case "\r\n":
+		return quoted, rowCRLF
+	case "":
+		return quoted, rowEOF
+	}
+	return nil, rowRaw
+}
+
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// inferType returns the narrowest type that represents every value of
+// column j exactly.
+func inferType(records []record, j int) Type {
+	isInt, isFloat := true, true
+	for _, r := range records {
+		if r.ending == rowRaw {
+			continue
+		}
+		s := r.fields[j]
+		if isInt {
+			v, err := strconv.ParseInt(s, 10, 64)
+			isInt = err == nil && strconv.FormatInt(v, 10) == s
+		}
+		if isFloat {
+			v, err := strconv.ParseFloat(s, 64)
+			isFloat = err == nil && formatFloat(v) == s
+		}
+		if !isInt && !isFloat {
+			return TypeString
+		}
+	}
+	if isInt {
+		return TypeInt
+	}
+	return TypeFloat
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func encodeColumn(t Type, col []string) openzl.Input {
+	switch t {
+	case TypeInt:
+		values := make([]int64, len(col))
+		for i, s := range col {
+			values[i], _ = strconv.ParseInt(s, 10, 64)
+		}
+		return openzl.Int64Input(values)
+	case TypeFloat:
+		values := make([]float64, len(col))
+		for i, s := range col {
+			values[i], _ = strconv.ParseFloat(s, 64)
+		}
+		return openzl.Float64Input(values)
+	}
+	strs := make([][]byte, len(col))
+	for i, s := range col {
+		strs[i] = []byte(s)
+	}
+	return openzl.StringInput(strs)
+}
+
+// Decompress restores the CSV data compressed into frame by Compress.
+func Decompress(frame []byte, opts ...Option) ([]byte, error) {
+	c := newConfig(opts)
+	ctx, release, err := openzl.Borrow(c.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	inputs, err := ctx.DecompressInputs(frame)
+	if err != nil {
+		return nil, err
+	}
+	return render(inputs)
+}
+
+// Columns returns the inferred types of the columns stored in frame.
+func Columns(frame []byte, opts ...Option) ([]Type, error) {
+	c := newConfig(opts)
+	ctx, release, err := openzl.Borrow(c.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	inputs, err := ctx.DecompressInputs(frame)
+	if err != nil {
+		return nil, err
+	}
+	_, types, err := parseMeta(inputs)
+	return types, err
+}
+
+func parseMeta(inputs []openzl.Input) (rune, []Type, error) {
+	if len(inputs) < numFixedInputs || inputs[inputMeta].Type != openzl.TypeSerial {
+		return 0, nil, ErrInvalidFrame
+	}
+	meta := inputs[inputMeta].Data
+	if len(meta) == 0 || meta[0] != formatVersion {
+		return 0, nil, ErrInvalidFrame
+	}
+	meta = meta[1:]
+	comma, n := binary.Uvarint(meta)
+	if n <= 0 || comma > utf8.MaxRune || !validComma(rune(comma)) {
+		return 0, nil, ErrInvalidFrame
+	}
+	meta = meta[n:]
+	ncols, n := binary.Uvarint(meta)
+	if n <= 0 || ncols != uint64(len(meta)-n) || int(ncols) != len(inputs)-numFixedInputs {
+		return 0, nil, ErrInvalidFrame
+	}
+	types := make([]Type, ncols)
+	for j, b := range meta[n:] {
+		types[j] = Type(b)
+		if types[j] > TypeFloat {
+			return 0, nil, ErrInvalidFrame
+		}
+	}
+	return rune(comma), types, nil
+}
+
+// render rebuilds the CSV data from the decompressed inputs of a frame.
+func render(inputs []openzl.Input) ([]byte, error) {
+	comma, types, err := parseMeta(inputs)
+	if err != nil {
+		return nil, err
+	}
+	layout, quotes := inputs[inputLayout], inputs[inputQuotes]
+	if layout.Type != openzl.TypeSerial || quotes.Type != openzl.TypeSerial {
+		return nil, ErrInvalidFrame
+	}
+	raw, err := inputs[inputRaw].Strings()
+	if err != nil {
+		return nil, ErrInvalidFrame
+	}
+
+	nraw := bytes.Count(layout.Data, []byte{rowRaw})
+	nrows := len(layout.Data) - nraw
+	if len(raw) != nraw || len(quotes.Data) != nrows*len(types) {
+		return nil, ErrInvalidFrame
+	}
+	columns := make([]func(i int) string, len(types))
+	for j, t := range types {
+		col, err := decodeColumn(t, inputs[numFixedInputs+j], nrows)
+		if err != nil {
+			return nil, err
+		}
+		columns[j] = col
+	}
+
+	var out bytes.Buffer
+	row := 0
+	for _, kind := range layout.Data {
+		if kind == rowRaw {
+			out.Write(raw[0])
+			raw = raw[1:]
+			continue
+		}
+		for j, col := range columns {
+			if j > 0 {
+				out.WriteRune(comma)
+			}
+			f := col(row)
+			if quotes.Data[row*len(columns)+j] != 0 {
+				f = quote(f)
+			}
+			out.WriteString(f)
+		}
+		switch kind {
+		case rowLF:
+			out.WriteByte('\n')
+		case rowCRLF:
+			out.WriteString("\r\n")
+		case rowEOF:
+		default:
+			return nil, ErrInvalidFrame
+		}
+		row++
+	}
+	return out.Bytes(), nil
+}
+
+// decodeColumn returns an accessor for the n values of a column.
+func decodeColumn(t Type, in openzl.Input, n int) (func(i int) string, error) {
+	if in.Len() != n {
+		return nil, ErrInvalidFrame
+	}
+	switch t {
+	case TypeInt:
+		values, err := in.Int64s()
+		if err != nil {
+			return nil, ErrInvalidFrame
+		}
+		return func(i int) string { return strconv.FormatInt(values[i], 10) }, nil
+	case TypeFloat:
+		values, err := in.Float64s()
+		if err != nil {
+			return nil, ErrInvalidFrame
+		}
+		return func(i int) string { return formatFloat(values[i]) }, nil
+	}
+	strs, err := in.Strings()
+	if err != nil {
+		return nil, ErrInvalidFrame
+	}
+	return func(i int) string { return string(strs[i]) }, nil
+}
+
+// validComma mirrors the delimiter restrictions of encoding/csv.
+func validComma(r rune) bool {
+	return r != 0 && r != '"' && r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,152 @@
+package csvozl
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+func sampleCSV(rows int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("id,timestamp,price,symbol,note\n")
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&buf, "%d,%d,%g,%s,\"said \"\"hi\"\", %d\"\n", i, 1700000000+i*15, float64(i)*0.25+100, []string{"AAPL", "GOOG", "MSFT"}[i%3], i)
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+		opts []Option
+	}{
+		{name: "empty", data: ""},
+		{name: "header only", data: "a,b,c\n"},
+		{name: "simple", data: "a,b\n1,2\n3,4\n"},
+		{name: "crlf", data: "a,b\r\n1,x\r\n2,y\r\n"},
+		{name: "mixed endings", data: "a,b\n1,x\r\n2,y\n3,z"},
+		{name: "no final newline", data: "a,b\n1,2\n3,4"},
+		{name: "quoted everything", data: "\"a\",\"b\"\n\"1\",\"2\"\n\"3\",\"4\"\n"},
+		{name: "embedded quotes and newlines", data: "a,b\n\"x \"\"y\"\"\",\"line1\nline2\"\n\"p,q\",r\n"},
+		{name: "crlf inside quotes", data: "a,b\n\"line1\r\nline2\",1\n"},
+		{name: "ragged", data: "a,b,c\n1,2,3\n4,5\n6,7,8,9\n"},
+		{name: "blank lines", data: "a,b\n\n1,2\n\n\n3,4\n\n"},
+		{name: "non-canonical numbers", data: "n,f\n007,1.50\n+1,1e3\n-0,-0\n"},
+		{name: "floats", data: "f\n0.5\n-2.25\n1e-7\nNaN\n"},
+		{name: "empty fields", data: "a,b,c\n,,\n1,,3\n"},
+		{name: "spaces around quotes", data: "a,b\n \"x\" ,y\n"},
+		{name: "bare quotes", data: "a,b\nx\"y,z\n\"unterminated\n"},
+		{name: "semicolon", data: "a;b\n1;2,5\n3;4\n", opts: []Option{WithComma(';')}},
+		{name: "unicode delimiter", data: "a§b\n1§é\n", opts: []Option{WithComma('§')}},
+		{name: "sample", data: string(sampleCSV(500))},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			frame, err := Compress([]byte(tc.data), tc.opts...)
+			if err != nil {
+				t.Fatalf("Compress() failed: %v", err)
+			}
+			got, err := Decompress(frame)
+			if err != nil {
+				t.Fatalf("Decompress() failed: %v", err)
+			}
+			if string(got) != tc.data {
+				t.Fatalf("Decompress() = %q, want %q", got, tc.data)
+			}
+		})
+	}
+}
+
+func TestColumns(t *testing.T) {
+	pool := openzl.NewPool()
+	defer pool.Close()
+
+	frame, err := Compress(sampleCSV(100), WithPool(pool))
+	if err != nil {
+		t.Fatalf("Compress() failed: %v", err)
+	}
+	types, err := Columns(frame, WithPool(pool))
+	if err != nil {
+		t.Fatalf("Columns() failed: %v", err)
+	}
+	want := []Type{TypeInt, TypeInt, TypeFloat, TypeString, TypeString}
+	if !reflect.DeepEqual(types, want) {
+		t.Fatalf("Columns() = %v, want %v", types, want)
+	}
+
+	// Numeric columns are stored as 64-bit numeric inputs.
+	ctx, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Put(ctx)
+	inputs, err := ctx.DecompressInputs(frame)
+	if err != nil {
+		t.Fatalf("DecompressInputs() failed: %v", err)
+	}
+	for j, typ := range want {
+		in := inputs[numFixedInputs+j]
+		numeric := in.Type == openzl.TypeNumeric && in.Width == 8
+		if numeric != (typ != TypeString) {
+			t.Errorf("column %d (%s) stored as %s of width %d", j, typ, in.Type, in.Width)
+		}
+		if in.Len() != 100 {
+			t.Errorf("column %d has %d values, want 100", j, in.Len())
+		}
+	}
+}
+
+func TestInvalidFrame(t *testing.T) {
+	ctx, err := openzl.NewContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	plain, err := ctx.Compress([]byte("a,b\n1,2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decompress(plain); err != ErrInvalidFrame {
+		t.Fatalf("Decompress() of a plain frame = %v, want %v", err, ErrInvalidFrame)
+	}
+
+	// A layout claiming more columnar rows than the columns hold.
+	meta := []byte{formatVersion, ',', 1, byte(TypeInt)}
+	bad, err := ctx.CompressInputs(
+		openzl.SerialInput(meta),
+		openzl.SerialInput([]byte{rowLF, rowLF}),
+		openzl.StringInput(nil),
+		openzl.SerialInput([]byte{0, 0}),
+		openzl.Int64Input([]int64{1}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decompress(bad); err != ErrInvalidFrame {
+		t.Fatalf("Decompress() of an inconsistent frame = %v, want %v", err, ErrInvalidFrame)
+	}
+
+	if _, err := Compress([]byte("a\n"), WithComma('"')); err == nil {
+		t.Fatal("Compress() with a quote delimiter should fail")
+	}
+}
+
+func BenchmarkCompress(b *testing.B) {
+	data := sampleCSV(20000)
+	pool := openzl.NewPool()
+	defer pool.Close()
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Compress(data, WithPool(pool)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
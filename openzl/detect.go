@@ -0,0 +1,400 @@
+package openzl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"unicode/utf8"
+)
+
+// Format is the kind of payload recognised by Detect.
+type Format int
+
+// Formats recognised by Detect.
+const (
+	FormatBinary    Format = iota // Nothing more specific was recognised
+	FormatText                    // UTF-8 text
+	FormatCSV                     // Delimited text with a consistent field count
+	FormatJSONLines               // One JSON value per line
+	FormatNumeric                 // Little-endian integers of a fixed width
+	FormatStruct                  // Records of a fixed size
+)
+
+var formatNames = [...]string{
+	FormatBinary:    "binary",
+	FormatText:      "text",
+	FormatCSV:       "csv",
+	FormatJSONLines: "jsonl",
+	FormatNumeric:   "numeric",
+	FormatStruct:    "struct",
+}
+
+// String returns the name of the format.
+func (f Format) String() string {
+	if f >= 0 && int(f) < len(formatNames) {
+		return formatNames[f]
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// Detection is the outcome of Detect: what the data looks like and how to
+// compress it.
+type Detection struct {
+	Format Format
+	Type   Type  // Input type to compress the data as
+	Width  int   // Element or record width in bytes for numeric and struct data, otherwise 0
+	Graph  Graph // Suggested starting graph
+}
+
+const (
+	// detectSample bounds how much of the data Detect looks at.
+	detectSample = 64 << 10
+	// recordSample bounds the data searched for a record size, as the search
+	// compares the sample against itself at every candidate width.
+	recordSample = 16 << 10
+	// maxRecordWidth is the largest record size Detect looks for.
+	maxRecordWidth = 256
+	// minElements is the number of elements or records needed before a
+	// width is considered at all.
+	minElements = 16
+)
+
+// Detect guesses the layout of data from a sample of its first bytes. It
+// recognises text, CSV, JSON lines, arrays of 2-, 4- or 8-byte little-endian
+// integers, and fixed-size binary records, and falls back to FormatBinary.
+//
+// The result is a heuristic: it is always safe to compress with, as every
+// suggestion round-trips, but it may not be the best choice. AutoTune
+// measures the candidates instead of guessing.
+func Detect(data []byte) Detection {
+	sample := data
+	if len(sample) > detectSample {
+		sample = sample[:detectSample]
+	}
+	truncated := len(sample) < len(data)
+
+	if len(sample) > 0 && isText(sample, truncated) {
+		lines := sampleLines(sample, truncated)
+		switch {
+		case isJSONLines(lines):
+			return Detection{Format: FormatJSONLines, Type: TypeSerial, Graph: GraphDefault}
+		case isCSV(lines):
+			return Detection{Format: FormatCSV, Type: TypeSerial, Graph: GraphDefault}
+		}
+		return Detection{Format: FormatText, Type: TypeSerial, Graph: GraphDefault}
+	}
+	if w := numericWidth(data, sample); w > 0 {
+		return Detection{Format: FormatNumeric, Type: TypeNumeric, Width: w, Graph: GraphDefault}
+	}
+	if w := recordWidth(sample); w > 0 {
+		return Detection{Format: FormatStruct, Type: TypeStruct, Width: w, Graph: GraphFieldLZ}
+	}
+	return Detection{Format: FormatBinary, Type: TypeSerial, Graph: GraphDefault}
+}
+
+// isText reports whether sample is UTF-8 with at most 1% invalid sequences
+// or control characters other than whitespace. A rune cut off by the end of
+// a truncated sample is not counted.
+func isText(sample []byte, truncated bool) bool {
+	bad := 0
+	for i := 0; i < len(sample); {
+		r, size := utf8.DecodeRune(sample[i:])
+		switch {
+		case r == utf8.RuneError && size <= 1:
+			if truncated && !utf8.FullRune(sample[i:]) {
+				return bad*100 <= len(sample)
+			}
+			bad++
+		case r < 0x20 && r != '\t' && r != '\n' && r != '\r' && r != '\f', r == 0x7f:
+			bad++
+		}
+		i += size
+	}
+	return bad*100 <= len(sample)
+}
+
+// sampleLines returns the non-empty lines of sample without their line
+// endings, dropping the last line if the sample cut it short.
+func sampleLines(sample []byte, truncated bool) [][]byte {
+	lines := bytes.Split(sample, []byte("\n"))
+	if truncated && len(lines) > 1 {
+		lines = lines[:len(lines)-1]
+	}
+	n := 0
+	for _, line := range lines {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if len(bytes.TrimSpace(line)) > 0 {
+			lines[n] = line
+			n++
+		}
+	}
+	return lines[:n]
+}
+
+// isJSONLines reports whether at least 90% of two or more lines are JSON
+// objects or arrays.
+func isJSONLines(lines [][]byte) bool {
+	if len(lines) < 2 {
+		return false
+	}
+	valid := 0
+	for _, line := range lines {
+		line = bytes.TrimSpace(line)
+		if (line[0] == '{' || line[0] == '[') && json.Valid(line) {
+			valid++
+		}
+	}
+	return valid*10 >= len(lines)*9
+}
+
+// isCSV reports whether two or more lines split on a common delimiter into
+// the same number (at least two) of fields, allowing 10% of lines to differ.
+func isCSV(lines [][]byte) bool {
+	if len(lines) < 2 {
+		return false
+	}
+	for _, comma := range []rune{',', '\t', ';', '|'} {
+		counts := make(map[int]int)
+		for _, line := range lines {
+			r := csv.NewReader(bytes.NewReader(line))
+			r.Comma = comma
+			r.FieldsPerRecord = -1
+			r.LazyQuotes = true
+			record, err := r.Read()
+			if err != nil {
+				counts[0]++
+				continue
+			}
+			counts[len(record)]++
+		}
+		for fields, n := range counts {
+			if fields >= 2 && n*10 >= len(lines)*9 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// numericWidth returns the width of the integers data most plausibly holds,
+// or 0 if it does not look like an integer array. Integer arrays tend to
+// change slowly, so the differences between neighbouring elements need far
+// fewer bytes than the elements; read at the wrong width, neighbours belong
+// to different parts of the values and their differences are large.
+func numericWidth(data, sample []byte) int {
+	best, bestCost := 0, 0.0
+	for _, w := range []int{8, 4, 2} {
+		n := len(sample) / w
+		if len(data)%w != 0 || n < minElements {
+			continue
+		}
+		shift := 64 - 8*uint(w)
+		var prev uint64
+		total := 0
+		for i := 0; i < n; i++ {
+			v := littleEndian(sample[i*w:], w)
+			if i > 0 {
+				d := int64((v-prev)<<shift) >> shift
+				zigzag := uint64(d<<1) ^ uint64(d>>63)
+				total += (bits.Len64(zigzag) + 7) / 8
+			}
+			prev = v
+		}
+		// Each difference takes at least a byte unless it is zero, which is
+		// already half of a 2-byte integer, so those get more slack.
+		limit := 0.5
+		if w == 2 {
+			limit = 0.75
+		}
+		if cost := float64(total) / float64((n-1)*w); cost <= limit && (best == 0 || cost < bestCost) {
+			best, bestCost = w, cost
+		}
+	}
+	return best
+}
+
+func littleEndian(b []byte, width int) uint64 {
+	switch width {
+	case 2:
+		return uint64(binary.LittleEndian.Uint16(b))
+	case 4:
+		return uint64(binary.LittleEndian.Uint32(b))
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+// recordWidth returns the size of the records sample most plausibly holds,
+// or 0 if it finds none. Fields of records repeat, so bytes tend to equal
+// the byte one record earlier; the smallest width matching nearly as often
+// as the best one is taken, as multiples of the record size match as well.
+func recordWidth(sample []byte) int {
+	if len(sample) > recordSample {
+		sample = sample[:recordSample]
+	}
+	scores := make([]float64, maxRecordWidth+1)
+	best := 0.0
+	for w := 2; w <= maxRecordWidth && len(sample) >= minElements*w; w++ {
+		same := 0
+		for i := w; i < len(sample); i++ {
+			if sample[i] == sample[i-w] {
+				same++
+			}
+		}
+		scores[w] = float64(same) / float64(len(sample)-w)
+		if scores[w] > best {
+			best = scores[w]
+		}
+	}
+	if best < 0.25 {
+		return 0
+	}
+	for w, score := range scores {
+		if score >= 0.9*best {
+			return w
+		}
+	}
+	return 0
+}
+
+// ErrNotAuto is returned by DecompressAuto for frames not written by
+// AutoCompress.
+var ErrNotAuto = &Error{Code: -1, Message: "not an AutoCompress frame"}
+
+const (
+	// autoMagic starts frames written by AutoCompress. It precedes the
+	// OpenZL frame rather than being part of its content, so no frame
+	// written by Compress or CompressInputs can be mistaken for one.
+	autoMagic = "OZLA"
+	// autoVersion is the version of the AutoCompress header.
+	autoVersion = 1
+	// autoHeaderSize is the size of the header: autoMagic, the version, the
+	// detected Format and the element or record width as a little-endian
+	// uint16.
+	autoHeaderSize = len(autoMagic) + 4
+)
+
+// WithDetectedGraph makes AutoCompress compress with the graph Detect
+// suggests instead of the context's own graph.
+func WithDetectedGraph(enable bool) Option {
+	return func(o *options) {
+		o.detectedGraph = enable
+	}
+}
+
+// AutoCompress compresses data as Detect suggests: as a numeric or struct
+// input when Detect finds one, with any trailing bytes that do not fill a
+// whole element or record kept as a serial input. It returns the frame and
+// the detection it applied.
+//
+// The context's graph is used unless the context was created with
+// WithDetectedGraph, so a graph that only accepts plain bytes fails on
+// numeric and struct data without it.
+//
+// The frame is an OpenZL frame preceded by a small header recording the
+// detection. It must be decompressed with DecompressAuto; Decompress and
+// DecompressInputs reject it.
+func (c *Context) AutoCompress(data []byte) ([]byte, Detection, error) {
+	d := Detect(data)
+	if c.ctx == nil {
+		return nil, d, &Error{Code: -1, Message: "context is closed"}
+	}
+	header := make([]byte, autoHeaderSize)
+	copy(header, autoMagic)
+	header[4] = autoVersion
+	header[5] = byte(d.Format)
+	binary.LittleEndian.PutUint16(header[6:], uint16(d.Width))
+	if len(data) == 0 {
+		return header, d, nil
+	}
+
+	n := len(data)
+	if d.Width > 0 {
+		n -= n % d.Width
+	}
+	inputs := []Input{{Type: d.Type, Data: data[:n], Width: d.Width}}
+	if n < len(data) {
+		inputs = append(inputs, SerialInput(data[n:]))
+	}
+
+	switchGraph := c.detectedGraph && d.Graph != c.graph
+	if switchGraph {
+		if err := c.ctx.SetGraph(int(d.Graph)); err != nil {
+			return nil, d, err
+		}
+	}
+	frame, err := c.CompressInputs(inputs...)
+	if switchGraph {
+		if rerr := c.ctx.SetGraph(int(c.graph)); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	if err != nil {
+		return nil, d, err
+	}
+	return append(header, frame...), d, nil
+}
+
+// DecompressAuto restores the original bytes of a frame written by
+// AutoCompress. It returns ErrNotAuto if frame does not start with the
+// AutoCompress header.
+func (c *Context) DecompressAuto(frame []byte) ([]byte, error) {
+	if len(frame) < autoHeaderSize || string(frame[:len(autoMagic)]) != autoMagic {
+		return nil, ErrNotAuto
+	}
+	if frame[4] != autoVersion {
+		return nil, &Error{Code: -1, Message: fmt.Sprintf("unsupported AutoCompress header version %d", frame[4])}
+	}
+	if c.ctx == nil {
+		return nil, &Error{Code: -1, Message: "context is closed"}
+	}
+	format := Format(frame[5])
+	width := int(binary.LittleEndian.Uint16(frame[6:]))
+	if len(frame) == autoHeaderSize {
+		return []byte{}, nil
+	}
+
+	inputs, err := c.DecompressInputs(frame[autoHeaderSize:])
+	if err != nil {
+		return nil, err
+	}
+	if !validAutoInputs(inputs, format, width) {
+		return nil, &Error{Code: -1, Message: "corrupt AutoCompress frame: inputs do not match the header"}
+	}
+	size := 0
+	for _, in := range inputs {
+		size += len(in.Data)
+	}
+	out := make([]byte, 0, size)
+	for _, in := range inputs {
+		out = append(out, in.Data...)
+	}
+	return out, nil
+}
+
+// validAutoInputs reports whether inputs are laid out as AutoCompress writes
+// data of the given format and width: one input of the detected type,
+// followed for numeric and struct data by an optional serial remainder
+// shorter than an element or record.
+func validAutoInputs(inputs []Input, format Format, width int) bool {
+	want := TypeSerial
+	switch format {
+	case FormatNumeric:
+		want = TypeNumeric
+	case FormatStruct:
+		want = TypeStruct
+	}
+	if len(inputs) == 0 || inputs[0].Type != want {
+		return false
+	}
+	if want == TypeSerial {
+		return len(inputs) == 1 && width == 0
+	}
+	if inputs[0].Width != width {
+		return false
+	}
+	return len(inputs) == 1 ||
+		len(inputs) == 2 && inputs[1].Type == TypeSerial && len(inputs[1].Data) < width
+}
@@ -0,0 +1,149 @@
+package openzl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func detectCases() []struct {
+	name  string
+	data  []byte
+	want  Format
+	width int
+} {
+	var text, csv, jsonl bytes.Buffer
+	csv.WriteString("id,name,score\n")
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&text, "Line %d of a plain text file, with ünïcode.\n", i)
+		fmt.Fprintf(&text, "Prose has commas, sometimes, but not in line %d.\n", i)
+		text.WriteString("And sometimes none at all.\n")
+		fmt.Fprintf(&csv, "%d,item-%d,%d.5\n", i, i%7, i*3)
+		fmt.Fprintf(&jsonl, `{"id":%d,"name":"item-%d","tags":["a","b"]}`+"\n", i, i%7)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	var i64, i32, u16, records []byte
+	for i := 0; i < 1000; i++ {
+		i64 = binary.LittleEndian.AppendUint64(i64, uint64(1_700_000_000_000+i*1000+rng.Intn(10)))
+		i32 = binary.LittleEndian.AppendUint32(i32, uint32(i*3))
+		u16 = binary.LittleEndian.AppendUint16(u16, uint16(i%500))
+		records = binary.LittleEndian.AppendUint32(records, uint32(1<<20+i))
+		records = append(records, "ABCD"...)
+		records = binary.LittleEndian.AppendUint32(records, uint32(rng.Intn(200)))
+	}
+	records = append(records, "tail!"...)
+	random := make([]byte, 8000)
+	rng.Read(random)
+
+	return []struct {
+		name  string
+		data  []byte
+		want  Format
+		width int
+	}{
+		{"empty", nil, FormatBinary, 0},
+		{"text", text.Bytes(), FormatText, 0},
+		{"csv", csv.Bytes(), FormatCSV, 0},
+		{"jsonl", jsonl.Bytes(), FormatJSONLines, 0},
+		{"int64", i64, FormatNumeric, 8},
+		{"int32", i32, FormatNumeric, 4},
+		{"uint16", u16, FormatNumeric, 2},
+		{"records", records, FormatStruct, 12},
+		{"random", random, FormatBinary, 0},
+	}
+}
+
+func TestDetect(t *testing.T) {
+	for _, tc := range detectCases() {
+		d := Detect(tc.data)
+		if d.Format != tc.want || d.Width != tc.width {
+			t.Errorf("%s: Detect() = %+v, want %v of width %d", tc.name, d, tc.want, tc.width)
+		}
+	}
+}
+
+func TestAutoCompress(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithDetectedGraph(true)}} {
+		ctx, err := NewContext(opts...)
+		if err != nil {
+			t.Fatalf("NewContext() failed: %v", err)
+		}
+		defer ctx.Close()
+		dctx, err := NewDecompressor()
+		if err != nil {
+			t.Fatalf("NewDecompressor() failed: %v", err)
+		}
+		defer dctx.Close()
+
+		for _, tc := range detectCases() {
+			frame, d, err := ctx.AutoCompress(tc.data)
+			if err != nil {
+				t.Fatalf("%s: AutoCompress() failed: %v", tc.name, err)
+			}
+			if d.Format != tc.want {
+				t.Errorf("%s: AutoCompress() applied %v, want %v", tc.name, d.Format, tc.want)
+			}
+			for _, decompress := range []func([]byte) ([]byte, error){ctx.DecompressAuto, dctx.DecompressAuto} {
+				got, err := decompress(frame)
+				if err != nil {
+					t.Fatalf("%s: DecompressAuto() failed: %v", tc.name, err)
+				}
+				if !bytes.Equal(got, tc.data) {
+					t.Errorf("%s: round trip returned %d bytes, want %d", tc.name, len(got), len(tc.data))
+				}
+			}
+			if len(tc.data) > 0 {
+				if _, err := ctx.Decompress(frame); err == nil {
+					t.Errorf("%s: Decompress() of an AutoCompress frame should fail", tc.name)
+				}
+			}
+		}
+
+		// The context goes back to its own graph afterwards.
+		if _, err := ctx.Compress([]byte("plain bytes")); err != nil {
+			t.Fatalf("Compress() after AutoCompress failed: %v", err)
+		}
+	}
+}
+
+func TestDecompressAutoInvalid(t *testing.T) {
+	ctx, err := NewContext()
+	if err != nil {
+		t.Fatalf("NewContext() failed: %v", err)
+	}
+	defer ctx.Close()
+
+	// Typed frames whose first input happens to look like the header are
+	// not AutoCompress frames.
+	typed, err := ctx.CompressInputs(SerialInput([]byte(autoMagic+"\x01\x00\x00\x00")), NumericInput(make([]byte, 64), 4))
+	if err != nil {
+		t.Fatalf("CompressInputs() failed: %v", err)
+	}
+	plain, err := ctx.Compress([]byte("plain bytes"))
+	if err != nil {
+		t.Fatalf("Compress() failed: %v", err)
+	}
+	for _, frame := range [][]byte{nil, typed, plain} {
+		if _, err := ctx.DecompressAuto(frame); err != ErrNotAuto {
+			t.Errorf("DecompressAuto() of a %d-byte frame returned %v, want ErrNotAuto", len(frame), err)
+		}
+	}
+
+	frame, _, err := ctx.AutoCompress(detectCases()[5].data) // int32
+	if err != nil {
+		t.Fatalf("AutoCompress() failed: %v", err)
+	}
+	bad := append([]byte(nil), frame...)
+	bad[4] = autoVersion + 1
+	if _, err := ctx.DecompressAuto(bad); err == nil {
+		t.Error("DecompressAuto() with an unknown header version should fail")
+	}
+	bad = append([]byte(nil), frame...)
+	bad[5] = byte(FormatStruct)
+	if _, err := ctx.DecompressAuto(bad); err == nil {
+		t.Error("DecompressAuto() with a header not matching the inputs should fail")
+	}
+}
@@ -0,0 +1,149 @@
+package openzl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gus3inov/openzl-go/internal/copenzl"
+)
+
+// ErrExplainUnsupported is returned by CompressExplain when the linked OpenZL
+// library was built without introspection support.
+var ErrExplainUnsupported = copenzl.ErrNoIntrospection
+
+// NodeKind says what an ExplainNode describes.
+type NodeKind string
+
+const (
+	NodeGraph  NodeKind = "graph"  // A graph, which selects the codecs applied to its inputs
+	NodeCodec  NodeKind = "codec"  // A codec run by a graph
+	NodeStream NodeKind = "stream" // A stream produced by a codec
+)
+
+// ExplainNode is one step of a compression. Graphs contain the codecs they
+// ran, codecs contain the streams they produced, and streams contain the
+// graphs that processed them further; a stream without children was stored
+// as it is.
+type ExplainNode struct {
+	Kind     NodeKind       `json:"kind"`
+	Name     string         `json:"name,omitempty"` // Graph or codec name as registered with OpenZL
+	Type     Type           `json:"type"`           // Type of the stream, or of the first input of a graph or codec
+	Size     int            `json:"size"`           // Stream size, or total input size of a graph or codec, in bytes
+	Children []*ExplainNode `json:"children,omitempty"`
+}
+
+// Explanation describes how CompressExplain compressed its input. It can be
+// rendered as an indented tree with String or WriteText, or marshalled with
+// encoding/json.
+type Explanation struct {
+	InputSize  int            `json:"input_size"`
+	OutputSize int            `json:"output_size"`
+	Nodes      []*ExplainNode `json:"nodes"` // Top-level graphs, in the order they ran
+}
+
+// CompressExplain compresses data like Compress and also reports which
+// graphs and codecs OpenZL applied and the streams they produced.
+//
+// It relies on OpenZL's introspection hooks, which are only available when
+// the library is built with ZL_ALLOW_INTROSPECTION; otherwise it returns
+// ErrExplainUnsupported. Recording the steps slows compression down, so use
+// it for diagnosis rather than on hot paths.
+func (c *Context) CompressExplain(data []byte) ([]byte, *Explanation, error) {
+	if c.ctx == nil {
+		return nil, nil, &Error{Code: -1, Message: "context is closed"}
+	}
+	ob := observe(c.observer, context.Background(), OpCompress)
+	start := time.Now()
+	out, nodes, err := copenzl.OpenZLCompressExplain(c.ctx, data)
+	c.counters.compressed(len(data), len(out), time.Since(start), err)
+	ob.add(len(data), len(out))
+	ob.end(err)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, newExplanation(len(data), len(out), nodes), nil
+}
+
+// CompressExplain compresses data and reports how; see
+// Context.CompressExplain.
+func (z *Compressor) CompressExplain(data []byte) ([]byte, *Explanation, error) {
+	return z.c.CompressExplain(data)
+}
+
+// newExplanation builds the tree from nodes recorded in execution order,
+// each referring to its parent by index.
+func newExplanation(in, out int, recorded []copenzl.ExplainNode) *Explanation {
+	e := &Explanation{InputSize: in, OutputSize: out, Nodes: []*ExplainNode{}}
+	nodes := make([]*ExplainNode, len(recorded))
+	for i, r := range recorded {
+		n := &ExplainNode{Name: r.Name, Type: Type(r.Type), Size: r.Size}
+		switch r.Kind {
+		case copenzl.ExplainGraph:
+			n.Kind = NodeGraph
+		case copenzl.ExplainCodec:
+			n.Kind = NodeCodec
+		default:
+			n.Kind = NodeStream
+		}
+		nodes[i] = n
+		// Parents are recorded before their children.
+		if r.Parent >= 0 && r.Parent < i {
+			p := nodes[r.Parent]
+			p.Children = append(p.Children, n)
+		} else {
+			e.Nodes = append(e.Nodes, n)
+		}
+	}
+	return e
+}
+
+// WriteText writes the explanation as an indented tree:
+//
+//	frame: 1000 -> 120 bytes (8.33x)
+//	graph zl.generic (serial, 1000 bytes)
+//	  codec zl.tokenize (serial, 1000 bytes)
+//	    stream numeric 400 bytes
+//	      graph zl.field_lz (numeric, 400 bytes)
+func (e *Explanation) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "frame: %d -> %d bytes (%s)\n", e.InputSize, e.OutputSize, ratioString(e.InputSize, e.OutputSize))
+	for _, n := range e.Nodes {
+		n.writeText(&b, 0)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// String returns the explanation as written by WriteText.
+func (e *Explanation) String() string {
+	var b strings.Builder
+	e.WriteText(&b)
+	return b.String()
+}
+
+func (n *ExplainNode) writeText(b *strings.Builder, depth int) {
+	b.WriteString(strings.Repeat("  ", depth))
+	name := n.Name
+	if name == "" {
+		name = "(unnamed)"
+	}
+	switch n.Kind {
+	case NodeStream:
+		fmt.Fprintf(b, "stream %s %d bytes\n", n.Type, n.Size)
+	default:
+		fmt.Fprintf(b, "%s %s (%s, %d bytes)\n", n.Kind, name, n.Type, n.Size)
+	}
+	for _, c := range n.Children {
+		c.writeText(b, depth+1)
+	}
+}
+
+func ratioString(original, compressed int) string {
+	if compressed == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2fx", float64(original)/float64(compressed))
+}
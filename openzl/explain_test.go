@@ -0,0 +1,74 @@
+package openzl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gus3inov/openzl-go/internal/copenzl"
+)
+
+func TestCompressExplain(t *testing.T) {
+	ctx, err := NewContext()
+	if err != nil {
+		t.Fatalf("NewContext() failed: %v", err)
+	}
+	defer ctx.Close()
+
+	data := bytes.Repeat([]byte("explain how this compresses\n"), 200)
+	frame, explanation, err := ctx.CompressExplain(data)
+	if errors.Is(err, ErrExplainUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("CompressExplain() failed: %v", err)
+	}
+	got, err := ctx.Decompress(frame)
+	if err != nil {
+		t.Fatalf("Decompress() failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data integrity check failed")
+	}
+	if explanation.InputSize != len(data) || explanation.OutputSize != len(frame) {
+		t.Errorf("sizes = %d -> %d, want %d -> %d", explanation.InputSize, explanation.OutputSize, len(data), len(frame))
+	}
+	if len(explanation.Nodes) == 0 || explanation.Nodes[0].Kind != NodeGraph {
+		t.Errorf("explanation does not start with a graph:\n%s", explanation)
+	}
+}
+
+func TestExplanation(t *testing.T) {
+	e := newExplanation(1000, 120, []copenzl.ExplainNode{
+		{Kind: copenzl.ExplainGraph, Parent: -1, Name: "zl.generic", Type: copenzl.TypeSerial, Size: 1000},
+		{Kind: copenzl.ExplainCodec, Parent: 0, Name: "zl.tokenize", Type: copenzl.TypeSerial, Size: 1000},
+		{Kind: copenzl.ExplainStream, Parent: 1, Type: copenzl.TypeNumeric, Size: 400},
+		{Kind: copenzl.ExplainStream, Parent: 1, Type: copenzl.TypeSerial, Size: 80},
+		{Kind: copenzl.ExplainGraph, Parent: 2, Name: "zl.field_lz", Type: copenzl.TypeNumeric, Size: 400},
+		{Kind: copenzl.ExplainGraph, Parent: -1, Type: copenzl.TypeSerial, Size: 80},
+	})
+
+	want := `frame: 1000 -> 120 bytes (8.33x)
+graph zl.generic (serial, 1000 bytes)
+  codec zl.tokenize (serial, 1000 bytes)
+    stream numeric 400 bytes
+      graph zl.field_lz (numeric, 400 bytes)
+    stream serial 80 bytes
+graph (unnamed) (serial, 80 bytes)
+`
+	if got := e.String(); got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+
+	out, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"input_size":1000`, `"kind":"codec"`, `"type":"numeric"`, `"name":"zl.field_lz"`} {
+		if !strings.Contains(string(out), s) {
+			t.Errorf("JSON %s does not contain %s", out, s)
+		}
+	}
+}
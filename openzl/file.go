@@ -0,0 +1,204 @@
+package openzl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrVerify is returned by CompressFile and DecompressFile when WithVerify is
+// set and the output does not round-trip to the input.
+var ErrVerify = &Error{Code: -1, Message: "verification failed: output does not match input"}
+
+// WithOverwrite makes CompressFile and DecompressFile replace an existing
+// output file instead of failing with an error wrapping fs.ErrExist.
+func WithOverwrite(overwrite bool) Option {
+	return func(o *options) {
+		o.overwrite = overwrite
+	}
+}
+
+// WithRemoveSource makes CompressFile and DecompressFile remove the source
+// file once the output is in place.
+func WithRemoveSource(remove bool) Option {
+	return func(o *options) {
+		o.removeSource = remove
+	}
+}
+
+// WithVerify makes CompressFile and DecompressFile decompress the compressed
+// side again and compare it with the uncompressed side before putting the
+// output in place, failing with ErrVerify if they differ.
+func WithVerify(verify bool) Option {
+	return func(o *options) {
+		o.verify = verify
+	}
+}
+
+// CompressFile compresses the regular file src into a stream, as written by
+// Writer, in the file dst.
+//
+// The stream is written to a temporary file in the directory of dst, which
+// is renamed to dst only once it is complete, so dst is never left partially
+// written. dst gets the permissions and modification time of src. It is an
+// error for dst to exist unless WithOverwrite is set; see also
+// WithRemoveSource and WithVerify. Other options, such as WithProgress and
+// WithConcurrency, apply to the Writer.
+func CompressFile(src, dst string, opts ...Option) error {
+	return transformFile(src, dst, opts, true)
+}
+
+// DecompressFile decompresses the stream in the regular file src into the
+// file dst, like CompressFile in reverse. Options apply to the Reader.
+func DecompressFile(src, dst string, opts ...Option) error {
+	return transformFile(src, dst, opts, false)
+}
+
+func transformFile(src, dst string, opts []Option, compress bool) (err error) {
+	o := newOptions(opts)
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s: not a regular file", src)
+	}
+	if existing, err := os.Stat(dst); err == nil {
+		if os.SameFile(info, existing) {
+			return fmt.Errorf("%s: input and output are the same file", dst)
+		}
+		if !o.overwrite {
+			return &os.PathError{Op: "open", Path: dst, Err: fs.ErrExist}
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	if compress {
+		err = compressTo(tmp, in, opts)
+	} else {
+		err = decompressTo(tmp, in, opts)
+	}
+	if err != nil {
+		return err
+	}
+	if err = tmp.Chmod(info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	if o.verify {
+		// The second pass is not reported as progress.
+		opts := append(opts[:len(opts):len(opts)], WithProgress(nil))
+		if compress {
+			err = verifyFile(src, tmp.Name(), opts)
+		} else {
+			err = verifyFile(tmp.Name(), src, opts)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// A zero access time leaves it unchanged.
+	if err = os.Chtimes(tmp.Name(), time.Time{}, info.ModTime()); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), dst); err != nil {
+		return err
+	}
+	if o.removeSource {
+		in.Close()
+		return os.Remove(src)
+	}
+	return nil
+}
+
+func compressTo(dst io.Writer, src io.Reader, opts []Option) error {
+	w, err := NewWriter(dst, opts...)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func decompressTo(dst io.Writer, src io.Reader, opts []Option) error {
+	r, err := NewReader(src, opts...)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(dst, r)
+	return err
+}
+
+// verifyFile checks that the stream in the file compressed decompresses to
+// the contents of the file raw.
+func verifyFile(raw, compressed string, opts []Option) error {
+	rf, err := os.Open(raw)
+	if err != nil {
+		return err
+	}
+	defer rf.Close()
+	cf, err := os.Open(compressed)
+	if err != nil {
+		return err
+	}
+	defer cf.Close()
+	r, err := NewReader(cf, opts...)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if _, err := io.Copy(&compareWriter{r: rf}, r); err != nil {
+		return err
+	}
+	if n, _ := rf.Read(make([]byte, 1)); n > 0 {
+		return ErrVerify
+	}
+	return nil
+}
+
+// compareWriter fails with ErrVerify unless what is written to it matches
+// what is read from r.
+type compareWriter struct {
+	r   io.Reader
+	buf []byte
+}
+
+func (w *compareWriter) Write(p []byte) (int, error) {
+	if cap(w.buf) < len(p) {
+		w.buf = make([]byte, len(p))
+	}
+	want := w.buf[:len(p)]
+	if _, err := io.ReadFull(w.r, want); err != nil || !bytes.Equal(p, want) {
+		return 0, ErrVerify
+	}
+	return len(p), nil
+}
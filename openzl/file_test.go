@@ -0,0 +1,114 @@
+package openzl
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestCompressFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "data.txt")
+	data := bytes.Repeat([]byte("file helpers round trip\n"), 5000)
+	if err := os.WriteFile(src, data, 0o640); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(src, mtime, mtime); err != nil {
+		t.Fatalf("Chtimes() failed: %v", err)
+	}
+
+	var done bool
+	progress := WithProgress(func(p Progress) { done = done || p.Done })
+	if err := CompressFile(src, src+".ozl", WithVerify(true), WithChunkSize(4096), progress); err != nil {
+		t.Fatalf("CompressFile() failed: %v", err)
+	}
+	if !done {
+		t.Error("CompressFile() did not report progress")
+	}
+	checkFileMeta(t, src+".ozl", 0o640, mtime)
+
+	err := CompressFile(src, src+".ozl")
+	if !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("CompressFile() over an existing file returned %v, want fs.ErrExist", err)
+	}
+	if err := CompressFile(src, src+".ozl", WithOverwrite(true), WithRemoveSource(true)); err != nil {
+		t.Fatalf("CompressFile() with overwrite failed: %v", err)
+	}
+	if _, err := os.Stat(src); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("source still exists after WithRemoveSource: %v", err)
+	}
+
+	if err := DecompressFile(src+".ozl", src, WithVerify(true)); err != nil {
+		t.Fatalf("DecompressFile() failed: %v", err)
+	}
+	got, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data integrity check failed")
+	}
+	checkFileMeta(t, src, 0o640, mtime)
+	checkDirEntries(t, dir, "data.txt", "data.txt.ozl")
+}
+
+func TestDecompressFileInvalid(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "bad.ozl")
+	if err := os.WriteFile(src, []byte("not a stream"), 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if err := DecompressFile(src, filepath.Join(dir, "bad"), WithRemoveSource(true)); err == nil {
+		t.Fatal("DecompressFile() of an invalid stream should fail")
+	}
+	// Neither the output nor a temporary file is left behind, and the
+	// source is kept.
+	checkDirEntries(t, dir, "bad.ozl")
+
+	if err := CompressFile(src, src, WithOverwrite(true)); err == nil {
+		t.Fatal("CompressFile() onto its own input should fail")
+	}
+	if err := CompressFile(dir, src+".ozl"); err == nil {
+		t.Fatal("CompressFile() of a directory should fail")
+	}
+}
+
+func checkFileMeta(t *testing.T, name string, perm fs.FileMode, mtime time.Time) {
+	t.Helper()
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatalf("Stat() failed: %v", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != perm {
+		t.Errorf("%s has permissions %v, want %v", name, info.Mode().Perm(), perm)
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("%s was modified at %v, want %v", name, info.ModTime(), mtime)
+	}
+}
+
+func checkDirEntries(t *testing.T, dir string, want ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() failed: %v", err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	if len(got) != len(want) {
+		t.Fatalf("%s contains %v, want %v", dir, got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("%s contains %v, want %v", dir, got, want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package openzl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Graph selects the OpenZL graph a Context starts compression with.
+//
+// Graphs only affect compression: frames record everything needed to decode
+// them, so any Context can decompress data produced with any Graph. Some
+// graphs only accept typed inputs; compressing plain bytes with them fails.
+type Graph int
+
+// Standard OpenZL graphs. The values mirror the OPENZL_GRAPH_* identifiers of
+// the C shim.
+const (
+	GraphDefault Graph = iota // Library default (format-aware generic compression)
+	GraphStore                // No compression, data stored as-is
+	GraphZstd                 // Zstandard
+	GraphGeneric              // OpenZL's generic compression graph
+	GraphFieldLZ              // LZ over fixed-width fields (typed inputs)
+	GraphEntropy              // Entropy coding only
+	GraphHuffman              // Huffman coding
+	GraphFSE                  // Finite State Entropy coding
+	GraphBitpack              // Bit-packing of numeric inputs
+)
+
+var graphNames = [...]string{
+	GraphDefault: "default",
+	GraphStore:   "store",
+	GraphZstd:    "zstd",
+	GraphGeneric: "generic",
+	GraphFieldLZ: "field-lz",
+	GraphEntropy: "entropy",
+	GraphHuffman: "huffman",
+	GraphFSE:     "fse",
+	GraphBitpack: "bitpack",
+}
+
+// Graphs returns all graphs known to the binding.
+func Graphs() []Graph {
+	graphs := make([]Graph, len(graphNames))
+	for i := range graphNames {
+		graphs[i] = Graph(i)
+	}
+	return graphs
+}
+
+// String returns the graph's name as accepted by ParseGraph.
+func (g Graph) String() string {
+	if g >= 0 && int(g) < len(graphNames) {
+		return graphNames[g]
+	}
+	return fmt.Sprintf("Graph(%d)", int(g))
+}
+
+// ParseGraph returns the Graph with the given name, ignoring case.
+func ParseGraph(name string) (Graph, error) {
+	for i, n := range graphNames {
+		if strings.EqualFold(name, n) {
+			return Graph(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown graph %q", name)
+}
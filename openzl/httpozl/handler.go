@@ -0,0 +1,170 @@
+package httpozl
+
+import (
+	"net/http"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+// Handler returns a handler that serves h with the openzl content encoding.
+//
+// Responses are compressed when the request's Accept-Encoding allows openzl,
+// the response is at least the minimum size (see WithMinSize), and the
+// handler has not set a Content-Encoding of its own. Responses to HEAD
+// requests, partial content (206 or Content-Range) and responses without a
+// body (1xx, 204 and 304) are never compressed. Compressed responses drop Content-Length; if Content-Type is
+// unset it is detected from the uncompressed data.
+//
+// Request bodies sent with Content-Encoding: openzl are decompressed before
+// h sees them.
+//
+// Calling Flush on the response writer compresses and sends the data written
+// so far, so streaming handlers keep working.
+func Handler(h http.Handler, opts ...Option) http.Handler {
+	c := newConfig(opts)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil && isEncoded(r.Header.Get("Content-Encoding")) {
+			r.Body = newDecodingBody(r.Body, c)
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !AcceptsEncoding(r.Header.Get("Accept-Encoding")) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		rw := &responseWriter{ResponseWriter: w, c: c}
+		defer rw.close()
+		h.ServeHTTP(rw, r)
+	})
+}
+
+// responseWriter buffers the start of a response until it can decide whether
+// to compress it, then either streams it through an openzl.Writer or passes
+// it through unchanged.
+type responseWriter struct {
+	http.ResponseWriter
+	c *config
+
+	status      int    // Status set by the handler; 0 until WriteHeader
+	buf         []byte // Body written before the decision
+	decided     bool
+	zw          *openzl.Writer // Set when compressing
+	wroteHeader bool           // Whether headers were sent to ResponseWriter
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if status >= 100 && status < 200 && status != http.StatusSwitchingProtocols {
+		// Informational responses such as 103 Early Hints precede the
+		// final response and are passed through as they are.
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.decided {
+		if w.zw != nil {
+			return w.zw.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.c.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// decide commits to compressing (if wanted and allowed) or passing the
+// response through, sends the headers and writes any buffered body.
+func (w *responseWriter) decide(compress bool) error {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	hdr := w.Header()
+	if hdr.Get("Content-Encoding") != "" || hdr.Get("Content-Range") != "" ||
+		w.status == http.StatusPartialContent || !bodyAllowed(w.status) {
+		compress = false
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if !compress {
+		w.sendHeader()
+		if len(buf) == 0 {
+			return nil
+		}
+		_, err := w.ResponseWriter.Write(buf)
+		return err
+	}
+
+	if hdr.Get("Content-Type") == "" && len(buf) > 0 {
+		hdr.Set("Content-Type", http.DetectContentType(buf))
+	}
+	hdr.Set("Content-Encoding", Encoding)
+	hdr.Del("Content-Length")
+	w.sendHeader()
+
+	zw, err := openzl.NewWriter(w.ResponseWriter, w.c.streamOptions()...)
+	if err != nil {
+		return err
+	}
+	w.zw = zw
+	_, err = zw.Write(buf)
+	return err
+}
+
+func (w *responseWriter) sendHeader() {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// Flush compresses and sends everything written so far. A response flushed
+// before reaching the minimum size is compressed anyway, since the handler is
+// evidently streaming.
+func (w *responseWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.zw != nil {
+		w.zw.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close finishes the response once the handler returns. Responses that never
+// reached the minimum size are sent uncompressed.
+func (w *responseWriter) close() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.zw != nil {
+		w.zw.Close()
+	}
+}
+
+// bodyAllowed reports whether a response with the given status may carry a
+// body.
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -0,0 +1,200 @@
+// Package httpozl implements an OpenZL HTTP content encoding.
+//
+// Handler wraps an http.Handler so that responses are compressed with the
+// openzl stream format when the client advertises support for it in
+// Accept-Encoding, and request bodies sent with Content-Encoding: openzl are
+// decompressed before they reach the handler:
+//
+//	http.ListenAndServe(":8080", httpozl.Handler(mux))
+//
+// NewTransport wraps an http.RoundTripper so that clients request the
+// encoding and transparently decompress responses, optionally compressing
+// request bodies too:
+//
+//	client := &http.Client{
+//		Transport: httpozl.NewTransport(nil, httpozl.WithRequestCompression()),
+//	}
+//
+// Bodies are encoded with openzl.Writer and decoded with openzl.Reader, using
+// Contexts borrowed from an openzl.Pool so that no context is created per
+// request.
+package httpozl
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+// Encoding is the content-coding token used in Accept-Encoding and
+// Content-Encoding headers.
+const Encoding = "openzl"
+
+// DefaultMinSize is the response size below which Handler sends responses
+// uncompressed when no minimum size is configured.
+const DefaultMinSize = 1024
+
+// DefaultChunkSize is the number of uncompressed bytes compressed into each
+// frame of an encoded body when no chunk size is configured. It is smaller
+// than openzl.DefaultChunkSize to bound per-request memory.
+const DefaultChunkSize = 64 << 10
+
+// Option configures Handler and NewTransport.
+type Option func(*config)
+
+type config struct {
+	pool            *openzl.Pool
+	minSize         int
+	chunkSize       int
+	compressRequest bool
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		minSize:   DefaultMinSize,
+		chunkSize: DefaultChunkSize,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.pool == nil {
+		c.pool = openzl.NewPool()
+	}
+	return c
+}
+
+// streamOptions returns the options for the Writers and Readers encoding and
+// decoding bodies.
+func (c *config) streamOptions() []openzl.Option {
+	return []openzl.Option{openzl.WithPool(c.pool), openzl.WithChunkSize(c.chunkSize)}
+}
+
+// WithPool sets the pool contexts are borrowed from. The pool's options, such
+// as its level and graph, determine how bodies are compressed. By default each
+// Handler and Transport has a pool of its own.
+func WithPool(p *openzl.Pool) Option {
+	return func(c *config) {
+		c.pool = p
+	}
+}
+
+// WithMinSize sets the response size below which Handler does not compress.
+// Small responses rarely shrink enough to pay for the framing overhead.
+func WithMinSize(n int) Option {
+	return func(c *config) {
+		if n < 0 {
+			n = 0
+		}
+		c.minSize = n
+	}
+}
+
+// WithChunkSize sets the number of uncompressed bytes compressed into each
+// frame of an encoded body. Non-positive values select DefaultChunkSize.
+func WithChunkSize(n int) Option {
+	return func(c *config) {
+		if n <= 0 {
+			n = DefaultChunkSize
+		}
+		c.chunkSize = n
+	}
+}
+
+// WithRequestCompression makes a Transport compress request bodies that do
+// not already carry a Content-Encoding. The server must understand the
+// encoding, as Handler does.
+func WithRequestCompression() Option {
+	return func(c *config) {
+		c.compressRequest = true
+	}
+}
+
+// AcceptsEncoding reports whether an Accept-Encoding header value allows the
+// openzl encoding, either by name or through a "*" wildcard, with a non-zero
+// quality.
+func AcceptsEncoding(header string) bool {
+	wildcard := false
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.TrimSpace(name)
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(key, "q") {
+				if v, err := strconv.ParseFloat(value, 64); err == nil {
+					q = v
+				}
+			}
+		}
+		switch {
+		case strings.EqualFold(name, Encoding):
+			return q > 0
+		case name == "*":
+			wildcard = q > 0
+		}
+	}
+	return wildcard
+}
+
+// isEncoded reports whether a Content-Encoding header value is openzl.
+func isEncoded(header string) bool {
+	return strings.EqualFold(strings.TrimSpace(header), Encoding)
+}
+
+// decodingBody decompresses an encoded body. The stream header is read on the
+// first Read rather than up front, so wrapping a body never blocks.
+type decodingBody struct {
+	body io.ReadCloser
+	opts []openzl.Option
+	zr   *openzl.Reader
+	err  error
+}
+
+func newDecodingBody(body io.ReadCloser, c *config) *decodingBody {
+	return &decodingBody{body: body, opts: c.streamOptions()}
+}
+
+func (b *decodingBody) Read(p []byte) (int, error) {
+	if b.zr == nil && b.err == nil {
+		b.zr, b.err = openzl.NewReader(b.body, b.opts...)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.zr.Read(p)
+}
+
+// Close returns the reader's context to the pool and closes the body.
+func (b *decodingBody) Close() error {
+	if b.zr != nil {
+		b.zr.Close()
+		b.zr = nil
+	}
+	if b.err == nil {
+		b.err = http.ErrBodyReadAfterClose
+	}
+	return b.body.Close()
+}
+
+// encodingBody compresses body on a goroutine, exposing the encoded stream as
+// the read side of a pipe. Closing it stops the goroutine.
+func encodingBody(body io.ReadCloser, c *config) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		defer body.Close()
+		zw, err := openzl.NewWriter(pw, c.streamOptions()...)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		_, err = io.Copy(zw, body)
+		if cerr := zw.Close(); err == nil {
+			err = cerr
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
@@ -0,0 +1,338 @@
+package httpozl
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+var payload = bytes.Repeat([]byte("OpenZL between internal services over HTTP. "), 2000)
+
+func payloadHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write(payload)
+}
+
+func get(t *testing.T, url, acceptEncoding string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestHandlerCompresses(t *testing.T) {
+	srv := httptest.NewServer(Handler(http.HandlerFunc(payloadHandler)))
+	defer srv.Close()
+
+	resp := get(t, srv.URL, "gzip, openzl")
+	if got := resp.Header.Get("Content-Encoding"); got != Encoding {
+		t.Fatalf("Content-Encoding = %q, want %q", got, Encoding)
+	}
+	if got := resp.Header.Get("Vary"); got != "Accept-Encoding" {
+		t.Fatalf("Vary = %q, want Accept-Encoding", got)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/plain" {
+		t.Fatalf("Content-Type = %q, want text/plain", got)
+	}
+	compressed, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ReadAll() failed: %v", err)
+	}
+	if len(compressed) >= len(payload) {
+		t.Fatalf("response is %d bytes, want fewer than %d", len(compressed), len(payload))
+	}
+	got, err := openzl.DecompressParallel(compressed)
+	if err != nil {
+		t.Fatalf("DecompressParallel() failed: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatal("Data integrity check failed")
+	}
+}
+
+func TestHandlerPassesThrough(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/big", payloadHandler)
+	mux.HandleFunc("/small", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tiny"))
+	})
+	mux.HandleFunc("/encoded", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "identity")
+		w.Write(payload)
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	srv := httptest.NewServer(Handler(mux))
+	defer srv.Close()
+
+	tests := []struct {
+		name, path, accept string
+		want               []byte
+	}{
+		{"no accept-encoding", "/big", "", payload},
+		{"refused", "/big", "openzl;q=0, gzip", payload},
+		{"below min size", "/small", "openzl", []byte("tiny")},
+		{"already encoded", "/encoded", "openzl", payload},
+		{"no content", "/empty", "openzl", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := get(t, srv.URL+tt.path, tt.accept)
+			if isEncoded(resp.Header.Get("Content-Encoding")) {
+				t.Fatal("response should not be compressed")
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("ReadAll() failed: %v", err)
+			}
+			if !bytes.Equal(body, tt.want) {
+				t.Fatalf("body is %d bytes, want %d", len(body), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestHandlerFlush(t *testing.T) {
+	// The first line is queued up front: the handler must write and flush
+	// before the client receives the response headers.
+	lines := make(chan string, 1)
+	lines <- "first"
+	srv := httptest.NewServer(Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for line := range lines {
+			io.WriteString(w, line+"\n")
+			w.(http.Flusher).Flush()
+		}
+	})))
+	defer srv.Close()
+	defer func() {
+		if lines != nil {
+			close(lines)
+		}
+	}()
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Each line must arrive before the next is produced, which only works if
+	// Flush pushes a frame through the encoder.
+	br := bufio.NewReader(resp.Body)
+	for i, want := range []string{"first", "second", "third"} {
+		if i > 0 {
+			lines <- want
+		}
+		got, err := br.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString() failed: %v", err)
+		}
+		if got != want+"\n" {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+	close(lines)
+	lines = nil
+	if rest, err := io.ReadAll(br); err != nil || len(rest) != 0 {
+		t.Fatalf("trailing data %q, err %v", rest, err)
+	}
+	if !resp.Uncompressed {
+		t.Fatal("response should have been decompressed by the transport")
+	}
+}
+
+func TestHandlerPartialContent(t *testing.T) {
+	srv := httptest.NewServer(Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "payload.txt", time.Time{}, bytes.NewReader(payload))
+	})))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept-Encoding", Encoding)
+	req.Header.Set("Range", "bytes=100-5099")
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		t.Fatalf("status = %s, want 206", resp.Status)
+	}
+	if isEncoded(resp.Header.Get("Content-Encoding")) {
+		t.Fatal("partial content should not be compressed")
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ReadAll() failed: %v", err)
+	}
+	if !bytes.Equal(body, payload[100:5100]) {
+		t.Fatal("partial content does not match the requested range")
+	}
+}
+
+func TestHandlerHostileRequestBody(t *testing.T) {
+	srv := httptest.NewServer(Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})))
+	defer srv.Close()
+
+	// A stream header followed by a block header claiming a 4 GiB frame.
+	body := append([]byte("OZLF\x01"), 0xff, 0xff, 0xff, 0xff, 1, 0, 0, 0)
+	req, err := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Encoding", Encoding)
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status = %s, want 400", resp.Status)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	pool := openzl.NewPool()
+	defer pool.Close()
+
+	type seen struct {
+		wireEncoding string // Content-Encoding as sent by the client
+		wireSize     int64  // Request body bytes as sent by the client
+		encoding     string // Content-Encoding as seen by the wrapped handler
+	}
+	requests := make(chan seen, 1)
+
+	var s seen
+	echo := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.encoding = r.Header.Get("Content-Encoding")
+		w.Write(body)
+	}), WithPool(pool))
+
+	// The outer handler sees the request as it arrived on the wire.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.wireEncoding = r.Header.Get("Content-Encoding")
+		counter := &countingReader{r: r.Body}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{counter, r.Body}
+		echo.ServeHTTP(w, r)
+		s.wireSize = counter.n
+		requests <- s
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, WithPool(pool), WithRequestCompression())}
+	resp, err := client.Post(srv.URL, "text/plain", bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("Post() failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %s", resp.Status)
+	}
+	if !resp.Uncompressed || resp.Header.Get("Content-Encoding") != "" || resp.ContentLength != -1 {
+		t.Fatal("response was not transparently decompressed")
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ReadAll() failed: %v", err)
+	}
+	if !bytes.Equal(body, payload) {
+		t.Fatal("Data integrity check failed")
+	}
+
+	got := <-requests
+	if got.wireEncoding != Encoding {
+		t.Fatalf("request Content-Encoding on the wire = %q, want %q", got.wireEncoding, Encoding)
+	}
+	if got.wireSize == 0 || got.wireSize >= int64(len(payload)) {
+		t.Fatalf("request body was %d bytes on the wire, want fewer than %d", got.wireSize, len(payload))
+	}
+	if got.encoding != "" {
+		t.Fatalf("handler saw Content-Encoding %q, want it removed", got.encoding)
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func TestTransportInvalidBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", Encoding)
+		io.WriteString(w, "not an openzl stream")
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.ReadAll(resp.Body); err != openzl.ErrInvalidStream {
+		t.Fatalf("ReadAll() = %v, want %v", err, openzl.ErrInvalidStream)
+	}
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip, deflate", false},
+		{"openzl", true},
+		{"gzip, OpenZL;q=0.5", true},
+		{"openzl;q=0", false},
+		{"*", true},
+		{"*;q=0", false},
+		{"*, openzl;q=0", false},
+		{"openzl; q=0.0, *", false},
+	}
+	for _, tt := range tests {
+		if got := AcceptsEncoding(tt.header); got != tt.want {
+			t.Errorf("AcceptsEncoding(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package httpozl
+
+import (
+	"io"
+	"net/http"
+)
+
+// NewTransport returns a RoundTripper that requests the openzl encoding and
+// transparently decompresses responses that use it. Requests are sent with
+// base, or http.DefaultTransport if base is nil.
+//
+// Accept-Encoding: openzl is added to requests that do not set
+// Accept-Encoding themselves. Decompressed responses have their
+// Content-Encoding and Content-Length removed and Uncompressed set, as
+// net/http does for gzip. Note that setting the header disables the gzip
+// negotiation http.Transport performs on its own.
+//
+// With WithRequestCompression, request bodies are compressed as they are sent.
+// Such requests have an unknown content length; GetBody, if set, is wrapped so
+// that retries compress the body again.
+func NewTransport(base http.RoundTripper, opts ...Option) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, c: newConfig(opts)}
+}
+
+type transport struct {
+	base http.RoundTripper
+	c    *config
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	addAccept := req.Header.Get("Accept-Encoding") == ""
+	compressBody := t.c.compressRequest && req.Body != nil && req.Body != http.NoBody &&
+		req.Header.Get("Content-Encoding") == ""
+
+	if addAccept || compressBody {
+		// A RoundTripper must not modify the caller's request.
+		orig := req
+		req = req.Clone(req.Context())
+		if addAccept {
+			req.Header.Set("Accept-Encoding", Encoding)
+		}
+		if compressBody {
+			req.Body = encodingBody(orig.Body, t.c)
+			req.ContentLength = -1
+			req.Header.Del("Content-Length")
+			req.Header.Set("Content-Encoding", Encoding)
+			if orig.GetBody != nil {
+				req.GetBody = func() (io.ReadCloser, error) {
+					body, err := orig.GetBody()
+					if err != nil {
+						return nil, err
+					}
+					return encodingBody(body, t.c), nil
+				}
+			}
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if isEncoded(resp.Header.Get("Content-Encoding")) && bodyAllowed(resp.StatusCode) && req.Method != http.MethodHead {
+		resp.Body = newDecodingBody(resp.Body, t.c)
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+	}
+	return resp, nil
+}
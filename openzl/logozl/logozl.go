@@ -0,0 +1,350 @@
+// Package logozl compresses application logs by separating line templates
+// from their variable fields before compressing with OpenZL.
+//
+// Every line is tokenised into a template and its variables. Digit runs
+// become numeric fields, so "took 12ms" and "took 7ms" share the template
+// "took <num>ms"; hexadecimal identifiers such as trace IDs and UUIDs, and
+// the values of key=value pairs that contain no digits, become string
+// fields. Each distinct template is stored once and referenced by ID, and
+// the template IDs, numbers and strings are compressed as separate typed
+// inputs of one frame. Decompress restores the original text exactly:
+//
+//	frame, err := logozl.Compress(logs)
+//	if err != nil {
+//		return err
+//	}
+//	restored, err := logozl.Decompress(frame) // bytes.Equal(restored, logs)
+//
+// Lines containing the control bytes 0x00 to 0x02, which templates use as
+// placeholders, are stored verbatim.
+package logozl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strconv"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+// ErrInvalidFrame is returned by Decompress when a frame was not produced by
+// Compress or is corrupt.
+var ErrInvalidFrame = errors.New("logozl: invalid frame")
+
+const formatVersion = 1
+
+// Template placeholders.
+const (
+	markNumber = 0x00 // Digit run without leading zeros
+	markString = 0x01 // String field
+	markPadded = 0x02 // Zero-padded digit run; followed by its width
+)
+
+// maxDigits is the longest digit run stored as a number; longer runs are
+// part of the template.
+const maxDigits = 18
+
+// minHexLen is the shortest hexadecimal token treated as an identifier.
+const minHexLen = 8
+
+// Input order within a frame.
+const (
+	inputMeta      = iota
+	inputIDs       // Template ID of every line; 0 marks a verbatim line
+	inputTemplates // Distinct templates, the first with ID 1
+	inputNumbers
+	inputStrings
+	inputRaw // Lines stored verbatim
+	numInputs
+)
+
+// Option configures Compress and Decompress.
+type Option func(*config)
+
+type config struct {
+	pool *openzl.Pool
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithPool makes Compress and Decompress reuse contexts from p. Log shippers
+// compressing one batch after another should set it.
+func WithPool(p *openzl.Pool) Option {
+	return func(c *config) {
+		c.pool = p
+	}
+}
+
+// fields collects the variables of the lines being compressed.
+type fields struct {
+	numbers []int64
+	strings [][]byte
+}
+
+// Compress compresses log text into a single frame.
+func Compress(data []byte, opts ...Option) ([]byte, error) {
+	c := newConfig(opts)
+
+	lines := bytes.Split(data, []byte("\n"))
+	trailingNewline := len(data) > 0 && data[len(data)-1] == '\n'
+	if trailingNewline || len(data) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	var (
+		f         fields
+		templates [][]byte
+		ids       = make(map[string]uint32)
+		lineIDs   = make([]byte, 0, 4*len(lines))
+		raw       [][]byte
+	)
+	for _, line := range lines {
+		if bytes.IndexFunc(line, func(r rune) bool { return r <= markPadded }) >= 0 {
+			lineIDs = binary.LittleEndian.AppendUint32(lineIDs, 0)
+			raw = append(raw, line)
+			continue
+		}
+		tmpl := f.extract(line)
+		id, ok := ids[string(tmpl)]
+		if !ok {
+			templates = append(templates, tmpl)
+			id = uint32(len(templates))
+			ids[string(tmpl)] = id
+		}
+		lineIDs = binary.LittleEndian.AppendUint32(lineIDs, id)
+	}
+
+	ctx, release, err := openzl.Borrow(c.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return ctx.CompressInputs(
+		openzl.SerialInput([]byte{formatVersion, boolByte(trailingNewline)}),
+		openzl.NumericInput(lineIDs, 4),
+		openzl.StringInput(templates),
+		openzl.Int64Input(f.numbers),
+		openzl.StringInput(f.strings),
+		openzl.StringInput(raw),
+	)
+}
+
+// extract appends the variables of line to f and returns its template.
+func (f *fields) extract(line []byte) []byte {
+	tmpl := make([]byte, 0, len(line))
+	for len(line) > 0 {
+		// Whitespace is copied to the template as it is.
+		if line[0] == ' ' || line[0] == '\t' {
+			tmpl = append(tmpl, line[0])
+			line = line[1:]
+			continue
+		}
+		end := bytes.IndexAny(line, " \t")
+		if end < 0 {
+			end = len(line)
+		}
+		tmpl = f.token(tmpl, line[:end])
+		line = line[end:]
+	}
+	return tmpl
+}
+
+// token appends the template of a whitespace-delimited token to tmpl.
+func (f *fields) token(tmpl, tok []byte) []byte {
+	if isHexID(tok) {
+		f.strings = append(f.strings, tok)
+		return append(tmpl, markString)
+	}
+	// In key=value pairs the value is variable even without digits.
+	if i := bytes.IndexByte(tok, '='); i > 0 && isKey(tok[:i]) && i+1 < len(tok) {
+		tmpl = append(tmpl, tok[:i+1]...)
+		value := tok[i+1:]
+		if !hasDigit(value) || isHexID(value) {
+			f.strings = append(f.strings, value)
+			return append(tmpl, markString)
+		}
+		tok = value
+	}
+	return f.digits(tmpl, tok)
+}
+
+// digits appends tok to tmpl, replacing digit runs with numeric fields.
+func (f *fields) digits(tmpl, tok []byte) []byte {
+	for len(tok) > 0 {
+		n := 0
+		for n < len(tok) && isDigit(tok[n]) {
+			n++
+		}
+		if n == 0 {
+			tmpl = append(tmpl, tok[0])
+			tok = tok[1:]
+			continue
+		}
+		run := tok[:n]
+		tok = tok[n:]
+		if n > maxDigits {
+			tmpl = append(tmpl, run...)
+			continue
+		}
+		v, _ := strconv.ParseInt(string(run), 10, 64)
+		f.numbers = append(f.numbers, v)
+		if run[0] == '0' && n > 1 {
+			tmpl = append(tmpl, markPadded, byte(n))
+		} else {
+			tmpl = append(tmpl, markNumber)
+		}
+	}
+	return tmpl
+}
+
+// isHexID reports whether tok looks like a hexadecimal identifier, such as a
+// hash, trace ID or UUID.
+func isHexID(tok []byte) bool {
+	if len(tok) < minHexLen || !hasDigit(tok) {
+		return false
+	}
+	letters := false
+	for _, c := range tok {
+		switch {
+		case isDigit(c), c == '-':
+		case c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+			letters = true
+		default:
+			return false
+		}
+	}
+	return letters
+}
+
+func isKey(b []byte) bool {
+	for _, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c) || c == '_' || c == '.' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+func hasDigit(b []byte) bool {
+	return bytes.IndexFunc(b, func(r rune) bool { return r >= '0' && r <= '9' }) >= 0
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Decompress restores the log text compressed into frame by Compress.
+func Decompress(frame []byte, opts ...Option) ([]byte, error) {
+	c := newConfig(opts)
+	ctx, release, err := openzl.Borrow(c.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	inputs, err := ctx.DecompressInputs(frame)
+	if err != nil {
+		return nil, err
+	}
+	return render(inputs)
+}
+
+// render rebuilds the log text from the decompressed inputs of a frame.
+func render(inputs []openzl.Input) ([]byte, error) {
+	if len(inputs) != numInputs || inputs[inputMeta].Type != openzl.TypeSerial {
+		return nil, ErrInvalidFrame
+	}
+	meta := inputs[inputMeta].Data
+	if len(meta) != 2 || meta[0] != formatVersion || meta[1] > 1 {
+		return nil, ErrInvalidFrame
+	}
+	ids := inputs[inputIDs]
+	if ids.Type != openzl.TypeNumeric || ids.Width != 4 {
+		return nil, ErrInvalidFrame
+	}
+	templates, err1 := inputs[inputTemplates].Strings()
+	numbers, err2 := inputs[inputNumbers].Int64s()
+	strs, err3 := inputs[inputStrings].Strings()
+	raw, err4 := inputs[inputRaw].Strings()
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return nil, ErrInvalidFrame
+	}
+
+	n := ids.Len()
+	var out []byte
+	for i := 0; i < n; i++ {
+		id := binary.LittleEndian.Uint32(ids.Data[4*i:])
+		switch {
+		case id == 0:
+			if len(raw) == 0 {
+				return nil, ErrInvalidFrame
+			}
+			out = append(out, raw[0]...)
+			raw = raw[1:]
+		case int(id) <= len(templates):
+			tmpl := templates[id-1]
+			for j := 0; j < len(tmpl); j++ {
+				switch c := tmpl[j]; c {
+				case markNumber, markPadded:
+					if len(numbers) == 0 || numbers[0] < 0 {
+						return nil, ErrInvalidFrame
+					}
+					start := len(out)
+					out = strconv.AppendInt(out, numbers[0], 10)
+					numbers = numbers[1:]
+					if c == markPadded {
+						j++
+						if j == len(tmpl) || tmpl[j] < 2 || tmpl[j] > maxDigits {
+							return nil, ErrInvalidFrame
+						}
+						out = pad(out, start, int(tmpl[j]))
+					}
+				case markString:
+					if len(strs) == 0 {
+						return nil, ErrInvalidFrame
+					}
+					out = append(out, strs[0]...)
+					strs = strs[1:]
+				default:
+					out = append(out, c)
+				}
+			}
+		default:
+			return nil, ErrInvalidFrame
+		}
+		if i < n-1 || meta[1] == 1 {
+			out = append(out, '\n')
+		}
+	}
+	if len(raw) != 0 || len(numbers) != 0 || len(strs) != 0 {
+		return nil, ErrInvalidFrame
+	}
+	return out, nil
+}
+
+// pad left-pads the digits in out[start:] with zeros to width.
+func pad(out []byte, start, width int) []byte {
+	n := len(out) - start
+	if n >= width {
+		return out
+	}
+	out = append(out, make([]byte, width-n)...)
+	copy(out[start+width-n:], out[start:start+n])
+	for i := start; i < start+width-n; i++ {
+		out[i] = '0'
+	}
+	return out
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,152 @@
+package logozl
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+func sampleLogs(n int) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		switch i % 3 {
+		case 0:
+			fmt.Fprintf(&buf, "2024-03-%02d 12:%02d:%02d.%03d INFO request handled method=GET path=/api/users/%d status=200 took=%dms trace=%016x\n",
+				1+i/1000%28, i/60%60, i%60, i*7%1000, i%500, 3+i%40, uint64(i)*0x9e3779b97f4a7c15)
+		case 1:
+			fmt.Fprintf(&buf, "2024-03-%02d 12:%02d:%02d.%03d WARN cache miss key=user:%d backend=%s\n",
+				1+i/1000%28, i/60%60, i%60, i*7%1000, i%500, []string{"redis", "memcached"}[i%2])
+		default:
+			fmt.Fprintf(&buf, "2024-03-%02d 12:%02d:%02d.%03d ERROR connection to 10.0.%d.%d:5432 refused after %d retries\n",
+				1+i/1000%28, i/60%60, i%60, i*7%1000, i%4, i%250, i%5)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{name: "empty", data: ""},
+		{name: "newline only", data: "\n"},
+		{name: "blank lines", data: "\n\n\na\n\n"},
+		{name: "no final newline", data: "took 12ms\ntook 7ms"},
+		{name: "numbers", data: "0 00 007 10 -5 +3 1.50 1e9 " + strings.Repeat("9", 19) + " 999999999999999999\n"},
+		{name: "hex ids", data: "trace=4bf92f3577b34da6a3ce929d0e0e4736 span 00f067aa0ba902b7 id=deadbeef cafe beefcafe1\n"},
+		{name: "uuid", data: "job 123e4567-e89b-12d3-a456-426614174000 done\n"},
+		{name: "key values", data: "a=b c= =d x=y=z k=v1 k=\n"},
+		{name: "whitespace", data: "  lead\t\ttabs  trail  \r\n"},
+		{name: "control bytes", data: "ok 1\nnul\x00 2\nsoh\x01\nstx\x02 3\nok 4\n"},
+		{name: "utf-8", data: "пользователь 42 вошёл ✓\n"},
+		{name: "sample", data: string(sampleLogs(300))},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			frame, err := Compress([]byte(tc.data))
+			if err != nil {
+				t.Fatalf("Compress() failed: %v", err)
+			}
+			got, err := Decompress(frame)
+			if err != nil {
+				t.Fatalf("Decompress() failed: %v", err)
+			}
+			if string(got) != tc.data {
+				t.Fatalf("Decompress() = %q, want %q", got, tc.data)
+			}
+		})
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	pool := openzl.NewPool()
+	defer pool.Close()
+
+	frame, err := Compress(sampleLogs(300), WithPool(pool))
+	if err != nil {
+		t.Fatalf("Compress() failed: %v", err)
+	}
+	ctx, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Put(ctx)
+	inputs, err := ctx.DecompressInputs(frame)
+	if err != nil {
+		t.Fatalf("DecompressInputs() failed: %v", err)
+	}
+
+	if n := inputs[inputIDs].Len(); n != 300 {
+		t.Fatalf("frame has %d template IDs, want 300", n)
+	}
+	if n := inputs[inputRaw].Len(); n != 0 {
+		t.Fatalf("%d lines stored verbatim, want 0", n)
+	}
+	// Three kinds of line; zero-padded fields may add a few variants.
+	if n := inputs[inputTemplates].Len(); n < 3 || n > 24 {
+		t.Fatalf("frame has %d templates, want between 3 and 24", n)
+	}
+	strs, err := inputs[inputStrings].Strings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Line 0 has an all-digit trace ID, stored as a number.
+	step := uint64(0x9e3779b97f4a7c15)
+	want := []string{"GET", "memcached", "GET", fmt.Sprintf("%016x", 3*step)}
+	for i, w := range want {
+		if string(strs[i]) != w {
+			t.Errorf("string field %d = %q, want %q", i, strs[i], w)
+		}
+	}
+}
+
+func TestInvalidFrame(t *testing.T) {
+	ctx, err := openzl.NewContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	plain, err := ctx.Compress([]byte("took 12ms\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decompress(plain); err != ErrInvalidFrame {
+		t.Fatalf("Decompress() of a plain frame = %v, want %v", err, ErrInvalidFrame)
+	}
+
+	// A template with two numeric fields but a single number.
+	bad, err := ctx.CompressInputs(
+		openzl.SerialInput([]byte{formatVersion, 1}),
+		openzl.NumericInput([]byte{1, 0, 0, 0}, 4),
+		openzl.StringInput([][]byte{{'a', markNumber, ' ', markNumber}}),
+		openzl.Int64Input([]int64{1}),
+		openzl.StringInput(nil),
+		openzl.StringInput(nil),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decompress(bad); err != ErrInvalidFrame {
+		t.Fatalf("Decompress() of an inconsistent frame = %v, want %v", err, ErrInvalidFrame)
+	}
+}
+
+func BenchmarkCompress(b *testing.B) {
+	data := sampleLogs(20000)
+	pool := openzl.NewPool()
+	defer pool.Close()
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Compress(data, WithPool(pool)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
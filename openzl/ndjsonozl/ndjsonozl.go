@@ -0,0 +1,474 @@
+// Package ndjsonozl compresses newline-delimited JSON column by column with
+// OpenZL.
+//
+// Compress splits every line holding a JSON object into its keys and values
+// and stores the values of each key in columns of their own: integers and
+// floats as numeric inputs, strings as string inputs, and nested objects,
+// arrays and unusually formatted numbers as JSON text. The key order of every
+// distinct record shape is stored once, so logs with a stable set of keys
+// only pay for their values. Decompress reconstructs the original lines
+// exactly.
+//
+// Records are recognised in the compact layout written by encoding/json
+// ({"a":1,"b":2}) and the spaced layout written by many other encoders
+// ({"a": 1, "b": 2}). Lines in any other form, including blank lines, lines
+// ending in "\r" and lines that are not objects, are stored verbatim:
+//
+//	frame, err := ndjsonozl.Compress(events)
+//	if err != nil {
+//		return err
+//	}
+//	restored, err := ndjsonozl.Decompress(frame) // bytes.Equal(restored, events)
+package ndjsonozl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+// ErrInvalidFrame is returned by Decompress when a frame was not produced by
+// Compress or is corrupt.
+var ErrInvalidFrame = errors.New("ndjsonozl: invalid frame")
+
+// MaxKeys is the number of distinct keys stored as columns. Records using
+// further keys are stored verbatim, which keeps the number of inputs per
+// frame bounded for irregular data.
+const MaxKeys = 64
+
+const formatVersion = 1
+
+// Record layouts.
+const (
+	styleCompact = iota // {"a":1,"b":2}
+	styleSpaced         // {"a": 1, "b": 2}
+)
+
+// Value kinds, one byte per value in a key's kind input.
+const (
+	kindInt    = iota // Canonical int64, in the int input
+	kindFloat         // float64 in shortest 'f' format, in the float input
+	kindString        // String contents without the quotes, in the string input
+	kindTrue
+	kindFalse
+	kindNull
+	kindRaw // Other JSON text, in the raw input
+)
+
+// Input order within a frame; per-key inputs follow in groups of
+// inputsPerKey.
+const (
+	inputMeta = iota
+	inputShapes
+	inputLines
+	numFixedInputs
+)
+
+const (
+	keyKinds = iota
+	keyInts
+	keyFloats
+	keyStrings
+	keyRaw
+	inputsPerKey
+)
+
+// Option configures Compress and Decompress.
+type Option func(*config)
+
+type config struct {
+	pool *openzl.Pool
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithPool makes Compress and Decompress borrow a context from p instead of
+// creating one for every batch of lines.
+func WithPool(p *openzl.Pool) Option {
+	return func(c *config) {
+		c.pool = p
+	}
+}
+
+// shape is the layout and ordered key list shared by a group of records.
+type shape struct {
+	style byte
+	keys  []int
+}
+
+func (s shape) id() string {
+	b := []byte{s.style}
+	for _, k := range s.keys {
+		b = binary.AppendUvarint(b, uint64(k))
+	}
+	return string(b)
+}
+
+// column accumulates the values of one key.
+type column struct {
+	kinds   []byte
+	ints    []int64
+	floats  []float64
+	strings [][]byte
+	raw     [][]byte
+}
+
+func (c *column) add(v value) {
+	c.kinds = append(c.kinds, v.kind)
+	switch v.kind {
+	case kindInt:
+		n, _ := strconv.ParseInt(string(v.text), 10, 64)
+		c.ints = append(c.ints, n)
+	case kindFloat:
+		f, _ := strconv.ParseFloat(string(v.text), 64)
+		c.floats = append(c.floats, f)
+	case kindString:
+		c.strings = append(c.strings, v.text)
+	case kindRaw:
+		c.raw = append(c.raw, v.text)
+	}
+}
+
+// Compress compresses newline-delimited JSON into a single frame.
+func Compress(data []byte, opts ...Option) ([]byte, error) {
+	c := newConfig(opts)
+
+	lines := bytes.Split(data, []byte("\n"))
+	trailingNewline := len(data) > 0 && data[len(data)-1] == '\n'
+	if trailingNewline || len(data) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	var (
+		keys     [][]byte
+		keyIDs   = make(map[string]int)
+		shapes   []shape
+		shapeIDs = make(map[string]int)
+		columns  []*column
+		lineIDs  = make([]uint32, len(lines))
+		raw      [][]byte
+	)
+	for i, line := range lines {
+		rec, ok := parseLine(line)
+		if ok {
+			// Resolve the keys without registering them, so that a record
+			// exceeding MaxKeys leaves no trace.
+			s := shape{style: rec.style, keys: make([]int, len(rec.keys))}
+			var pending map[string]int
+			for j, k := range rec.keys {
+				id, seen := keyIDs[string(k)]
+				if !seen {
+					if id, seen = pending[string(k)]; !seen {
+						if pending == nil {
+							pending = make(map[string]int)
+						}
+						id = len(keys) + len(pending)
+						pending[string(k)] = id
+					}
+				}
+				s.keys[j] = id
+			}
+			ok = len(keys)+len(pending) <= MaxKeys
+			if ok {
+				// New keys were numbered in order of first appearance.
+				for j, k := range rec.keys {
+					if s.keys[j] == len(keys) {
+						keyIDs[string(k)] = len(keys)
+						keys = append(keys, k)
+						columns = append(columns, &column{})
+					}
+				}
+				for j, v := range rec.values {
+					columns[s.keys[j]].add(v)
+				}
+				id, seen := shapeIDs[s.id()]
+				if !seen {
+					id = len(shapes)
+					shapeIDs[s.id()] = id
+					shapes = append(shapes, s)
+				}
+				lineIDs[i] = uint32(id + 1)
+			}
+		}
+		if !ok {
+			raw = append(raw, line)
+		}
+	}
+
+	meta := []byte{formatVersion, boolByte(trailingNewline)}
+	meta = binary.AppendUvarint(meta, uint64(len(keys)))
+	for _, k := range keys {
+		meta = binary.AppendUvarint(meta, uint64(len(k)))
+		meta = append(meta, k...)
+	}
+	meta = binary.AppendUvarint(meta, uint64(len(shapes)))
+	for _, s := range shapes {
+		meta = append(meta, s.style)
+		meta = binary.AppendUvarint(meta, uint64(len(s.keys)))
+		for _, k := range s.keys {
+			meta = binary.AppendUvarint(meta, uint64(k))
+		}
+	}
+
+	ids := make([]byte, 4*len(lineIDs))
+	for i, id := range lineIDs {
+		binary.LittleEndian.PutUint32(ids[4*i:], id)
+	}
+	inputs := []openzl.Input{
+		inputMeta:   openzl.SerialInput(meta),
+		inputShapes: openzl.NumericInput(ids, 4),
+		inputLines:  openzl.StringInput(raw),
+	}
+	for _, col := range columns {
+		inputs = append(inputs,
+			openzl.SerialInput(col.kinds),
+			openzl.Int64Input(col.ints),
+			openzl.Float64Input(col.floats),
+			openzl.StringInput(col.strings),
+			openzl.StringInput(col.raw),
+		)
+	}
+
+	ctx, release, err := openzl.Borrow(c.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return ctx.CompressInputs(inputs...)
+}
+
+// Decompress restores the newline-delimited JSON compressed into frame by
+// Compress.
+func Decompress(frame []byte, opts ...Option) ([]byte, error) {
+	c := newConfig(opts)
+	ctx, release, err := openzl.Borrow(c.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	inputs, err := ctx.DecompressInputs(frame)
+	if err != nil {
+		return nil, err
+	}
+	return render(inputs)
+}
+
+// frameMeta is the decoded meta input of a frame.
+type frameMeta struct {
+	trailingNewline bool
+	keys            [][]byte
+	shapes          []shape
+}
+
+func parseMeta(b []byte) (*frameMeta, error) {
+	if len(b) < 2 || b[0] != formatVersion || b[1] > 1 {
+		return nil, ErrInvalidFrame
+	}
+	m := &frameMeta{trailingNewline: b[1] == 1}
+	b = b[2:]
+	next := func() (int, bool) {
+		v, n := binary.Uvarint(b)
+		if n <= 0 || v > math.MaxInt32 {
+			return 0, false
+		}
+		b = b[n:]
+		return int(v), true
+	}
+
+	nkeys, ok := next()
+	if !ok || nkeys > MaxKeys {
+		return nil, ErrInvalidFrame
+	}
+	for i := 0; i < nkeys; i++ {
+		l, ok := next()
+		if !ok || l > len(b) {
+			return nil, ErrInvalidFrame
+		}
+		m.keys = append(m.keys, b[:l])
+		b = b[l:]
+	}
+	nshapes, ok := next()
+	if !ok || nshapes > len(b) {
+		return nil, ErrInvalidFrame
+	}
+	for i := 0; i < nshapes; i++ {
+		if len(b) == 0 || b[0] > styleSpaced {
+			return nil, ErrInvalidFrame
+		}
+		s := shape{style: b[0]}
+		b = b[1:]
+		n, ok := next()
+		if !ok || n > len(b) {
+			return nil, ErrInvalidFrame
+		}
+		for j := 0; j < n; j++ {
+			k, ok := next()
+			if !ok || k >= nkeys {
+				return nil, ErrInvalidFrame
+			}
+			s.keys = append(s.keys, k)
+		}
+		m.shapes = append(m.shapes, s)
+	}
+	if len(b) != 0 {
+		return nil, ErrInvalidFrame
+	}
+	return m, nil
+}
+
+// reader hands out the values of one key in order.
+type reader struct {
+	kinds   []byte
+	ints    []int64
+	floats  []float64
+	strings [][]byte
+	raw     [][]byte
+}
+
+func newReader(inputs []openzl.Input) (*reader, error) {
+	var r reader
+	var err1, err2, err3, err4 error
+	if inputs[keyKinds].Type != openzl.TypeSerial {
+		return nil, ErrInvalidFrame
+	}
+	r.kinds = inputs[keyKinds].Data
+	r.ints, err1 = inputs[keyInts].Int64s()
+	r.floats, err2 = inputs[keyFloats].Float64s()
+	r.strings, err3 = inputs[keyStrings].Strings()
+	r.raw, err4 = inputs[keyRaw].Strings()
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return nil, ErrInvalidFrame
+	}
+	return &r, nil
+}
+
+// appendNext appends the next value of the key to out.
+func (r *reader) appendNext(out []byte) ([]byte, error) {
+	if len(r.kinds) == 0 {
+		return nil, ErrInvalidFrame
+	}
+	kind := r.kinds[0]
+	r.kinds = r.kinds[1:]
+	switch kind {
+	case kindInt:
+		if len(r.ints) == 0 {
+			return nil, ErrInvalidFrame
+		}
+		out = strconv.AppendInt(out, r.ints[0], 10)
+		r.ints = r.ints[1:]
+	case kindFloat:
+		if len(r.floats) == 0 {
+			return nil, ErrInvalidFrame
+		}
+		out = strconv.AppendFloat(out, r.floats[0], 'f', -1, 64)
+		r.floats = r.floats[1:]
+	case kindString:
+		if len(r.strings) == 0 {
+			return nil, ErrInvalidFrame
+		}
+		out = append(append(append(out, '"'), r.strings[0]...), '"')
+		r.strings = r.strings[1:]
+	case kindTrue:
+		out = append(out, "true"...)
+	case kindFalse:
+		out = append(out, "false"...)
+	case kindNull:
+		out = append(out, "null"...)
+	case kindRaw:
+		if len(r.raw) == 0 {
+			return nil, ErrInvalidFrame
+		}
+		out = append(out, r.raw[0]...)
+		r.raw = r.raw[1:]
+	default:
+		return nil, ErrInvalidFrame
+	}
+	return out, nil
+}
+
+// render rebuilds the lines from the decompressed inputs of a frame.
+func render(inputs []openzl.Input) ([]byte, error) {
+	if len(inputs) < numFixedInputs || inputs[inputMeta].Type != openzl.TypeSerial {
+		return nil, ErrInvalidFrame
+	}
+	m, err := parseMeta(inputs[inputMeta].Data)
+	if err != nil {
+		return nil, err
+	}
+	if len(inputs) != numFixedInputs+inputsPerKey*len(m.keys) {
+		return nil, ErrInvalidFrame
+	}
+	ids := inputs[inputShapes]
+	if ids.Type != openzl.TypeNumeric || ids.Width != 4 {
+		return nil, ErrInvalidFrame
+	}
+	raw, err := inputs[inputLines].Strings()
+	if err != nil {
+		return nil, ErrInvalidFrame
+	}
+	readers := make([]*reader, len(m.keys))
+	for k := range readers {
+		start := numFixedInputs + inputsPerKey*k
+		if readers[k], err = newReader(inputs[start : start+inputsPerKey]); err != nil {
+			return nil, err
+		}
+	}
+
+	n := ids.Len()
+	var out []byte
+	for i := 0; i < n; i++ {
+		id := binary.LittleEndian.Uint32(ids.Data[4*i:])
+		if id == 0 {
+			if len(raw) == 0 {
+				return nil, ErrInvalidFrame
+			}
+			out = append(out, raw[0]...)
+			raw = raw[1:]
+		} else {
+			if int(id) > len(m.shapes) {
+				return nil, ErrInvalidFrame
+			}
+			s := m.shapes[id-1]
+			sep, colon := ",", ":"
+			if s.style == styleSpaced {
+				sep, colon = ", ", ": "
+			}
+			out = append(out, '{')
+			for j, k := range s.keys {
+				if j > 0 {
+					out = append(out, sep...)
+				}
+				out = append(append(append(out, '"'), m.keys[k]...), '"')
+				out = append(out, colon...)
+				if out, err = readers[k].appendNext(out); err != nil {
+					return nil, err
+				}
+			}
+			out = append(out, '}')
+		}
+		if i < n-1 || m.trailingNewline {
+			out = append(out, '\n')
+		}
+	}
+	if len(raw) != 0 {
+		return nil, ErrInvalidFrame
+	}
+	return out, nil
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
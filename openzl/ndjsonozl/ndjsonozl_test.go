@@ -0,0 +1,151 @@
+package ndjsonozl
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+func sampleEvents(n int) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&buf, `{"ts":%d,"level":"%s","latency":%g,"user":"u%04d","ok":%t,"tags":["a","b"],"err":null}`+"\n",
+			1700000000000+int64(i)*37, []string{"info", "warn", "error"}[i%3], float64(i%100)/8, i%250, i%7 != 0)
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	var wide strings.Builder
+	wide.WriteString("{")
+	for i := 0; i <= MaxKeys; i++ {
+		if i > 0 {
+			wide.WriteString(",")
+		}
+		fmt.Fprintf(&wide, `"k%d":%d`, i, i)
+	}
+	wide.WriteString("}\n")
+
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{name: "empty", data: ""},
+		{name: "newline only", data: "\n"},
+		{name: "compact", data: `{"a":1,"b":"x"}` + "\n" + `{"a":2,"b":"y"}` + "\n"},
+		{name: "spaced", data: `{"a": 1, "b": "x"}` + "\n" + `{"a": 2, "b": "y"}` + "\n"},
+		{name: "no final newline", data: `{"a":1}` + "\n" + `{"a":2}`},
+		{name: "changing shapes", data: `{"a":1,"b":2}` + "\n" + `{"b":3,"a":4}` + "\n" + `{"c":true}` + "\n" + `{}` + "\n"},
+		{name: "mixed kinds", data: `{"v":1}` + "\n" + `{"v":"1"}` + "\n" + `{"v":1.5}` + "\n" + `{"v":null}` + "\n" + `{"v":[1,{"x":"]"}]}` + "\n" + `{"v":false}` + "\n"},
+		{name: "odd numbers", data: `{"n":1e5,"m":-0,"o":007,"p":1.50,"q":99999999999999999999}` + "\n"},
+		{name: "escapes", data: `{"s":"tab\tquote\"slash\/é","k\"ey":"v"}` + "\n"},
+		{name: "duplicate keys", data: `{"a":1,"a":2}` + "\n"},
+		{name: "irregular", data: "not json\n" + `{ "a":1}` + "\n" + `{"a":1 }` + "\n" + `{"a": 1,"b": 2}` + "\n" + `[1,2]` + "\n" + `{"a":1}` + "\r\n" + "\n" + `{"a":}` + "\n"},
+		{name: "unterminated", data: `{"a":"x}` + "\n" + `{"a":[1}` + "\n"},
+		{name: "too many keys", data: wide.String() + `{"k0":1}` + "\n"},
+		{name: "sample", data: string(sampleEvents(300))},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			frame, err := Compress([]byte(tc.data))
+			if err != nil {
+				t.Fatalf("Compress() failed: %v", err)
+			}
+			got, err := Decompress(frame)
+			if err != nil {
+				t.Fatalf("Decompress() failed: %v", err)
+			}
+			if string(got) != tc.data {
+				t.Fatalf("Decompress() = %q, want %q", got, tc.data)
+			}
+		})
+	}
+}
+
+func TestColumns(t *testing.T) {
+	pool := openzl.NewPool()
+	defer pool.Close()
+
+	frame, err := Compress(sampleEvents(100), WithPool(pool))
+	if err != nil {
+		t.Fatalf("Compress() failed: %v", err)
+	}
+	ctx, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Put(ctx)
+	inputs, err := ctx.DecompressInputs(frame)
+	if err != nil {
+		t.Fatalf("DecompressInputs() failed: %v", err)
+	}
+
+	// Seven keys, every line columnar.
+	if want := numFixedInputs + 7*inputsPerKey; len(inputs) != want {
+		t.Fatalf("frame has %d inputs, want %d", len(inputs), want)
+	}
+	if n := inputs[inputLines].Len(); n != 0 {
+		t.Fatalf("%d lines stored verbatim, want 0", n)
+	}
+	ts := inputs[numFixedInputs+keyInts]
+	if ts.Type != openzl.TypeNumeric || ts.Len() != 100 {
+		t.Fatalf("ts stored as %s with %d values, want 100 numeric values", ts.Type, ts.Len())
+	}
+	latency := inputs[numFixedInputs+2*inputsPerKey+keyFloats]
+	if latency.Len()+inputs[numFixedInputs+2*inputsPerKey+keyInts].Len() != 100 {
+		t.Fatal("latency values are not all numeric")
+	}
+}
+
+func TestInvalidFrame(t *testing.T) {
+	ctx, err := openzl.NewContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	plain, err := ctx.Compress([]byte(`{"a":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decompress(plain); err != ErrInvalidFrame {
+		t.Fatalf("Decompress() of a plain frame = %v, want %v", err, ErrInvalidFrame)
+	}
+
+	// One key, one shape, but two lines referring to it and a single value.
+	meta := []byte{formatVersion, 1, 1, 1, 'a', 1, styleCompact, 1, 0}
+	bad, err := ctx.CompressInputs(
+		openzl.SerialInput(meta),
+		openzl.NumericInput([]byte{1, 0, 0, 0, 1, 0, 0, 0}, 4),
+		openzl.StringInput(nil),
+		openzl.SerialInput([]byte{kindInt, kindInt}),
+		openzl.Int64Input([]int64{1}),
+		openzl.Float64Input(nil),
+		openzl.StringInput(nil),
+		openzl.StringInput(nil),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decompress(bad); err != ErrInvalidFrame {
+		t.Fatalf("Decompress() of an inconsistent frame = %v, want %v", err, ErrInvalidFrame)
+	}
+}
+
+func BenchmarkCompress(b *testing.B) {
+	data := sampleEvents(20000)
+	pool := openzl.NewPool()
+	defer pool.Close()
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Compress(data, WithPool(pool)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package ndjsonozl
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// record is a line split into keys and values.
+type record struct {
+	style  byte
+	keys   [][]byte // Key contents without the quotes
+	values []value
+}
+
+// value is one member value and the bytes that reproduce it.
+type value struct {
+	kind byte
+	text []byte // Number text, string contents or raw JSON text
+}
+
+// parseLine splits a line holding a JSON object in one of the supported
+// layouts. Values are not unescaped: strings keep their exact contents and
+// nested objects and arrays their exact text, so the line can be rebuilt
+// byte for byte. It reports false for anything else.
+func parseLine(line []byte) (record, bool) {
+	var rec record
+	if len(line) < 2 || line[0] != '{' || line[len(line)-1] != '}' {
+		return rec, false
+	}
+	body := line[1 : len(line)-1]
+	if len(body) == 0 {
+		return rec, true
+	}
+
+	// The first member decides the layout; every other separator must
+	// match it.
+	sep, colon := []byte(","), []byte(":")
+	if i := stringEnd(body); i > 0 && bytes.HasPrefix(body[i:], []byte(": ")) {
+		rec.style = styleSpaced
+		sep, colon = []byte(", "), []byte(": ")
+	}
+
+	for {
+		end := stringEnd(body)
+		if end < 0 {
+			return rec, false
+		}
+		key := body[1 : end-1]
+		body = body[end:]
+		if !bytes.HasPrefix(body, colon) {
+			return rec, false
+		}
+		body = body[len(colon):]
+
+		n := valueEnd(body)
+		if n <= 0 {
+			return rec, false
+		}
+		rec.keys = append(rec.keys, key)
+		rec.values = append(rec.values, classify(body[:n]))
+		body = body[n:]
+
+		if len(body) == 0 {
+			return rec, true
+		}
+		if !bytes.HasPrefix(body, sep) {
+			return rec, false
+		}
+		body = body[len(sep):]
+	}
+}
+
+// classify decides how a value is stored.
+func classify(text []byte) value {
+	switch text[0] {
+	case '"':
+		return value{kind: kindString, text: text[1 : len(text)-1]}
+	case 't':
+		if string(text) == "true" {
+			return value{kind: kindTrue}
+		}
+	case 'f':
+		if string(text) == "false" {
+			return value{kind: kindFalse}
+		}
+	case 'n':
+		if string(text) == "null" {
+			return value{kind: kindNull}
+		}
+	default:
+		s := string(text)
+		if v, err := strconv.ParseInt(s, 10, 64); err == nil && strconv.FormatInt(v, 10) == s {
+			return value{kind: kindInt, text: text}
+		}
+		if v, err := strconv.ParseFloat(s, 64); err == nil && strconv.FormatFloat(v, 'f', -1, 64) == s {
+			return value{kind: kindFloat, text: text}
+		}
+	}
+	return value{kind: kindRaw, text: text}
+}
+
+// stringEnd returns the length of the JSON string at the start of b,
+// including its quotes, or -1 if b does not start with a complete string.
+func stringEnd(b []byte) int {
+	if len(b) == 0 || b[0] != '"' {
+		return -1
+	}
+	for i := 1; i < len(b); i++ {
+		switch b[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// valueEnd returns the length of the value at the start of b, or -1 if none
+// is found. Nested objects and arrays are matched by bracket depth, skipping
+// brackets inside strings; scalars extend to the next ',' or whitespace.
+func valueEnd(b []byte) int {
+	if len(b) == 0 {
+		return -1
+	}
+	switch b[0] {
+	case '"':
+		return stringEnd(b)
+	case '{', '[':
+		depth := 0
+		for i := 0; i < len(b); i++ {
+			switch b[i] {
+			case '"':
+				n := stringEnd(b[i:])
+				if n < 0 {
+					return -1
+				}
+				i += n - 1
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+		}
+		return -1
+	}
+	for i, c := range b {
+		switch c {
+		case ',', ' ', '\t', '{', '}', '[', ']', '"', ':':
+			return i
+		}
+	}
+	return len(b)
+}
@@ -0,0 +1,93 @@
+// Package observe provides openzl.Observer adapters for the standard
+// library's expvar and runtime/trace packages.
+//
+// They are kept out of package openzl because importing expvar registers an
+// HTTP handler on http.DefaultServeMux. Adapters for other systems, such as
+// Prometheus or OpenTelemetry, follow the same pattern: implement Start, and
+// record the Event passed to the returned function.
+package observe
+
+import (
+	"context"
+	"expvar"
+	"runtime/trace"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+// Expvar publishes cumulative per-operation totals as an expvar.Map. For each
+// operation name (see openzl.Op) the map holds the keys
+//
+//	<op>.calls      operations finished, including failed ones
+//	<op>.errors     operations that failed
+//	<op>.bytes_in   bytes consumed by successful operations
+//	<op>.bytes_out  bytes produced by successful operations
+//	<op>.nanos      time spent in successful operations
+type Expvar struct {
+	m *expvar.Map
+}
+
+// NewExpvar publishes a new map under name and returns an Observer updating
+// it. Like expvar.Publish, it panics if name is already in use.
+func NewExpvar(name string) *Expvar {
+	return &Expvar{m: expvar.NewMap(name)}
+}
+
+// Map returns the published map.
+func (e *Expvar) Map() *expvar.Map {
+	return e.m
+}
+
+// Start implements openzl.Observer.
+func (e *Expvar) Start(_ context.Context, op openzl.Op) func(openzl.Event) {
+	return e.record
+}
+
+func (e *Expvar) record(ev openzl.Event) {
+	name := ev.Op.String()
+	e.m.Add(name+".calls", 1)
+	if ev.Err != nil {
+		e.m.Add(name+".errors", 1)
+		return
+	}
+	e.m.Add(name+".bytes_in", ev.BytesIn)
+	e.m.Add(name+".bytes_out", ev.BytesOut)
+	e.m.Add(name+".nanos", int64(ev.Duration))
+}
+
+// Trace records operations in the runtime execution trace while one is being
+// collected (see runtime/trace.Start), and does nothing otherwise.
+//
+// Calls on a Context become regions named "openzl.<op>" on the calling
+// goroutine. Streams, which may end on a different goroutine than they
+// started on, become tasks of the same name. Sizes and errors are logged
+// under the category "openzl".
+type Trace struct{}
+
+// Start implements openzl.Observer.
+func (Trace) Start(ctx context.Context, op openzl.Op) func(openzl.Event) {
+	if !trace.IsEnabled() {
+		return nil
+	}
+	name := "openzl." + op.String()
+	if op == openzl.OpWrite || op == openzl.OpRead {
+		ctx, task := trace.NewTask(ctx, name)
+		return func(ev openzl.Event) {
+			logEvent(ctx, ev)
+			task.End()
+		}
+	}
+	region := trace.StartRegion(ctx, name)
+	return func(ev openzl.Event) {
+		logEvent(ctx, ev)
+		region.End()
+	}
+}
+
+func logEvent(ctx context.Context, ev openzl.Event) {
+	if ev.Err != nil {
+		trace.Log(ctx, "openzl", "error: "+ev.Err.Error())
+		return
+	}
+	trace.Logf(ctx, "openzl", "%d -> %d bytes", ev.BytesIn, ev.BytesOut)
+}
@@ -0,0 +1,82 @@
+package observe
+
+import (
+	"bytes"
+	"expvar"
+	"runtime/trace"
+	"testing"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+func TestExpvar(t *testing.T) {
+	obs := NewExpvar("openzl_test")
+	ctx, err := openzl.NewContext(openzl.WithObserver(obs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	data := bytes.Repeat([]byte("observed "), 100)
+	frame, err := ctx.Compress(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ctx.Decompress(frame); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ctx.Decompress([]byte("not a frame")); err == nil {
+		t.Fatal("Decompress() should fail on garbage")
+	}
+
+	want := map[string]int64{
+		"compress.calls":     1,
+		"compress.bytes_in":  int64(len(data)),
+		"compress.bytes_out": int64(len(frame)),
+		"decompress.calls":   2,
+		"decompress.errors":  1,
+	}
+	for key, v := range want {
+		got, ok := obs.Map().Get(key).(*expvar.Int)
+		if !ok || got.Value() != v {
+			t.Errorf("%s = %v, want %d", key, obs.Map().Get(key), v)
+		}
+	}
+	if expvar.Get("openzl_test") == nil {
+		t.Error("map is not published")
+	}
+}
+
+func TestTrace(t *testing.T) {
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Skipf("cannot start trace: %v", err)
+	}
+	defer trace.Stop()
+
+	opts := []openzl.Option{openzl.WithObserver(Trace{})}
+	var stream bytes.Buffer
+	w, err := openzl.NewWriter(&stream, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(bytes.Repeat([]byte("traced "), 1000)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := openzl.NewReader(&stream, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err := r.Read(make([]byte, 1<<16)); err != nil {
+		t.Fatal(err)
+	}
+
+	trace.Stop()
+	if buf.Len() == 0 {
+		t.Error("no trace data written")
+	}
+}
@@ -0,0 +1,120 @@
+package openzl
+
+import (
+	"context"
+	"time"
+)
+
+// Op identifies the kind of operation an Event describes.
+type Op int
+
+const (
+	OpCompress         Op = iota + 1 // Compress, CompressContext or CompressWithStats
+	OpDecompress                     // Decompress or DecompressContext
+	OpCompressInputs                 // CompressInputs
+	OpDecompressInputs               // DecompressInputs
+	OpCompressBatch                  // CompressBatch
+	OpDecompressBatch                // DecompressBatch
+	OpWrite                          // A stream written by a Writer, from NewWriter to Close
+	OpRead                           // A stream read by a Reader, from NewReader to the end marker or Close
+	OpPoolNew                        // A Pool creating a context because none was idle
+)
+
+var opNames = map[Op]string{
+	OpCompress:         "compress",
+	OpDecompress:       "decompress",
+	OpCompressInputs:   "compress-inputs",
+	OpDecompressInputs: "decompress-inputs",
+	OpCompressBatch:    "compress-batch",
+	OpDecompressBatch:  "decompress-batch",
+	OpWrite:            "write",
+	OpRead:             "read",
+	OpPoolNew:          "pool-new",
+}
+
+// String returns the operation's name, such as "compress".
+func (op Op) String() string {
+	if name, ok := opNames[op]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Event describes a finished operation.
+type Event struct {
+	Op       Op
+	BytesIn  int64         // Bytes consumed: uncompressed when compressing, compressed when decompressing
+	BytesOut int64         // Bytes produced
+	Duration time.Duration // Time from the start of the operation to its end
+	Err      error         // Why the operation failed, or nil
+}
+
+// Observer receives the operations performed by Contexts, Pools, Writers and
+// Readers configured with WithObserver, so that callers can export metrics
+// or traces without this package depending on a particular system. Package
+// observe provides adapters for expvar and runtime/trace.
+//
+// Start is called when an operation begins, with the context.Context passed
+// to the operation (context.Background() for methods that take none). The
+// returned function, unless nil, is called once with the outcome when the
+// operation ends. Both may be called from several goroutines at once.
+type Observer interface {
+	Start(ctx context.Context, op Op) func(Event)
+}
+
+// ObserverFunc is an Observer that only needs the outcome of operations.
+type ObserverFunc func(Event)
+
+// Start returns f.
+func (f ObserverFunc) Start(context.Context, Op) func(Event) {
+	return f
+}
+
+// WithObserver reports the operations of contexts, pools, streaming writers
+// and readers, and parallel operations to obs. Contexts borrowed from a Pool
+// report to the pool's observer instead.
+func WithObserver(obs Observer) Option {
+	return func(o *options) {
+		o.observer = obs
+	}
+}
+
+// observation accumulates one operation for an Observer. A nil *observation
+// discards everything, so unobserved operations cost a nil check.
+type observation struct {
+	done  func(Event)
+	start time.Time
+	e     Event
+}
+
+// observe starts observing op, returning nil if obs is nil or not interested.
+func observe(obs Observer, ctx context.Context, op Op) *observation {
+	if obs == nil {
+		return nil
+	}
+	done := obs.Start(ctx, op)
+	if done == nil {
+		return nil
+	}
+	return &observation{done: done, start: time.Now(), e: Event{Op: op}}
+}
+
+// add counts bytes consumed and produced by the operation.
+func (ob *observation) add(in, out int) {
+	if ob == nil {
+		return
+	}
+	ob.e.BytesIn += int64(in)
+	ob.e.BytesOut += int64(out)
+}
+
+// end reports the operation. Only the first call has an effect.
+func (ob *observation) end(err error) {
+	if ob == nil || ob.done == nil {
+		return
+	}
+	ob.e.Duration = time.Since(ob.start)
+	ob.e.Err = err
+	ob.done(ob.e)
+	ob.done = nil
+}
@@ -0,0 +1,181 @@
+package openzl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+)
+
+// recorder is an Observer keeping every event it receives.
+type recorder struct {
+	mu      sync.Mutex
+	started []Op
+	events  []Event
+}
+
+func (r *recorder) Start(_ context.Context, op Op) func(Event) {
+	r.mu.Lock()
+	r.started = append(r.started, op)
+	r.mu.Unlock()
+	return func(e Event) {
+		r.mu.Lock()
+		r.events = append(r.events, e)
+		r.mu.Unlock()
+	}
+}
+
+// ops returns the operations of the events received so far, in order.
+func (r *recorder) ops() []Op {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ops []Op
+	for _, e := range r.events {
+		ops = append(ops, e.Op)
+	}
+	return ops
+}
+
+func (r *recorder) last(op Op) Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.events) - 1; i >= 0; i-- {
+		if r.events[i].Op == op {
+			return r.events[i]
+		}
+	}
+	return Event{}
+}
+
+func TestObserverContext(t *testing.T) {
+	var rec recorder
+	ctx, err := NewContext(WithObserver(&rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	data := bytes.Repeat([]byte("observer "), 200)
+	frame, err := ctx.Compress(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ctx.DecompressContext(context.Background(), frame); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ctx.Decompress([]byte("garbage")); err == nil {
+		t.Fatal("Decompress() should fail on garbage")
+	}
+	if _, err := ctx.CompressBatch([][]byte{data, data}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Op{OpCompress, OpDecompress, OpDecompress, OpCompressBatch}
+	if got := rec.ops(); !equalOps(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	e := rec.events[0]
+	if e.BytesIn != int64(len(data)) || e.BytesOut != int64(len(frame)) || e.Err != nil {
+		t.Errorf("compress event = %+v", e)
+	}
+	if e := rec.events[2]; e.Err == nil {
+		t.Error("failed decompression reported without an error")
+	}
+}
+
+func TestObserverStream(t *testing.T) {
+	var rec recorder
+	opts := []Option{WithObserver(&rec), WithChunkSize(1000)}
+	data := bytes.Repeat([]byte("stream observer "), 500)
+
+	var stream bytes.Buffer
+	w, err := NewWriter(&stream, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	e := rec.last(OpWrite)
+	if e.BytesIn != int64(len(data)) || e.BytesOut != int64(stream.Len()) || e.Err != nil {
+		t.Errorf("write event = %+v, want %d -> %d bytes", e, len(data), stream.Len())
+	}
+
+	r, err := NewReader(bytes.NewReader(stream.Bytes()), append(opts, WithConcurrency(2))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	e = rec.last(OpRead)
+	if e.BytesIn != int64(stream.Len()) || e.BytesOut != int64(len(data)) || e.Err != nil {
+		t.Errorf("read event = %+v, want %d -> %d bytes", e, stream.Len(), len(data))
+	}
+
+	var reads int
+	for _, op := range rec.ops() {
+		if op == OpRead {
+			reads++
+		}
+	}
+	if reads != 1 {
+		t.Errorf("stream read reported %d times", reads)
+	}
+}
+
+func TestObserverPool(t *testing.T) {
+	var rec recorder
+	pool := NewPool(WithObserver(&rec))
+	defer pool.Close()
+
+	ctx, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.Put(ctx)
+	if ctx, err = pool.Get(); err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Put(ctx)
+	if _, err := ctx.Compress([]byte("pooled")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Op{OpPoolNew, OpCompress}
+	if got := rec.ops(); !equalOps(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+}
+
+func TestObserverFunc(t *testing.T) {
+	var got []Event
+	ctx, err := NewContext(WithObserver(ObserverFunc(func(e Event) { got = append(got, e) })))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+	if _, err := ctx.Compress([]byte("func")); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Op != OpCompress || got[0].Op.String() != "compress" {
+		t.Fatalf("events = %+v", got)
+	}
+}
+
+func equalOps(a, b []Op) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
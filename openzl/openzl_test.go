@@ -2,6 +2,11 @@ package openzl
 
 import (
 	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -270,3 +275,262 @@ func BenchmarkRoundTrip(b *testing.B) {
 		}
 	}
 }
+
+func TestContextOptions(t *testing.T) {
+	data := bytes.Repeat([]byte("Context options test data for OpenZL. "), 200)
+
+	testCases := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "default", opts: nil},
+		{name: "level", opts: []Option{WithLevel(1)}},
+		{name: "store", opts: []Option{WithGraph(GraphStore)}},
+		{name: "zstd", opts: []Option{WithGraph(GraphZstd)}},
+		{name: "zstd level", opts: []Option{WithGraph(GraphZstd), WithLevel(3)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, err := NewContext(tc.opts...)
+			if err != nil {
+				t.Fatalf("NewContext() failed: %v", err)
+			}
+			defer ctx.Close()
+
+			compressed, err := ctx.Compress(data)
+			if err != nil {
+				t.Fatalf("Compress() failed: %v", err)
+			}
+
+			size, err := DecompressedSize(compressed)
+			if err != nil {
+				t.Fatalf("DecompressedSize() failed: %v", err)
+			}
+			if size != len(data) {
+				t.Fatalf("DecompressedSize() = %d, want %d", size, len(data))
+			}
+
+			// Frames are self-describing: a default context can decode them.
+			dctx, err := NewContext()
+			if err != nil {
+				t.Fatalf("NewContext() failed: %v", err)
+			}
+			defer dctx.Close()
+
+			decompressed, err := dctx.Decompress(compressed)
+			if err != nil {
+				t.Fatalf("Decompress() failed: %v", err)
+			}
+			if !bytes.Equal(data, decompressed) {
+				t.Fatal("Data integrity check failed")
+			}
+		})
+	}
+}
+
+func TestInvalidContextOptions(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "negative level", opts: []Option{WithLevel(-1)}},
+		{name: "unknown graph", opts: []Option{WithGraph(Graph(99))}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, err := NewContext(tc.opts...)
+			if err == nil {
+				ctx.Close()
+				t.Fatal("NewContext() should fail")
+			}
+		})
+	}
+}
+
+func TestCompressorDecompressor(t *testing.T) {
+	c, err := NewCompressor(WithGraph(GraphZstd), WithLevel(3))
+	if err != nil {
+		t.Fatalf("NewCompressor() failed: %v", err)
+	}
+	defer c.Close()
+	d, err := NewDecompressor()
+	if err != nil {
+		t.Fatalf("NewDecompressor() failed: %v", err)
+	}
+	defer d.Close()
+
+	data := bytes.Repeat([]byte("compressor and decompressor share no state. "), 100)
+	// Several frames check that the decompressor's state is reused.
+	for i := 0; i < 3; i++ {
+		frame, err := c.Compress(data)
+		if err != nil {
+			t.Fatalf("Compress() failed: %v", err)
+		}
+		got, err := d.Decompress(frame)
+		if err != nil {
+			t.Fatalf("Decompress() failed: %v", err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatal("Data integrity check failed")
+		}
+	}
+
+	frame, err := c.CompressInputs(Int64Input([]int64{1, 2, 3, 5, 8, 13}))
+	if err != nil {
+		t.Fatalf("CompressInputs() failed: %v", err)
+	}
+	outputs, err := d.DecompressInputs(frame)
+	if err != nil {
+		t.Fatalf("DecompressInputs() failed: %v", err)
+	}
+	if len(outputs) != 1 || outputs[0].Type != TypeNumeric {
+		t.Fatalf("DecompressInputs() = %v, want one numeric input", outputs)
+	}
+
+	if got := c.Counters(); got.Compressions != 4 || got.Decompressions != 0 {
+		t.Errorf("compressor counters = %+v", got)
+	}
+	if got := d.Counters(); got.Decompressions != 4 || got.Compressions != 0 {
+		t.Errorf("decompressor counters = %+v", got)
+	}
+
+	if _, err := NewCompressor(WithLevel(-1)); err == nil {
+		t.Error("NewCompressor() with a negative level should fail")
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Decompress(frame); err == nil {
+		t.Error("Decompress() on a closed decompressor should fail")
+	}
+}
+
+func TestParseGraph(t *testing.T) {
+	for _, g := range Graphs() {
+		parsed, err := ParseGraph(g.String())
+		if err != nil {
+			t.Fatalf("ParseGraph(%q) failed: %v", g.String(), err)
+		}
+		if parsed != g {
+			t.Fatalf("ParseGraph(%q) = %v, want %v", g.String(), parsed, g)
+		}
+	}
+
+	if g, err := ParseGraph("ZSTD"); err != nil || g != GraphZstd {
+		t.Fatalf("ParseGraph(\"ZSTD\") = (%v, %v), want zstd", g, err)
+	}
+	if _, err := ParseGraph("no-such-graph"); err == nil {
+		t.Fatal("ParseGraph() with unknown name should fail")
+	}
+}
+
+func TestVersion(t *testing.T) {
+	v := Version()
+	if v.Binding != BindingVersion {
+		t.Fatalf("Version().Binding = %q, want %q", v.Binding, BindingVersion)
+	}
+	if v.MinFormatVersion <= 0 || v.MinFormatVersion > v.MaxFormatVersion {
+		t.Fatalf("invalid format version range %d-%d", v.MinFormatVersion, v.MaxFormatVersion)
+	}
+	if s := v.String(); !strings.Contains(s, BindingVersion) || !strings.Contains(s, v.Library()) {
+		t.Fatalf("Version().String() = %q, want binding and library versions", s)
+	}
+
+	f := Features()
+	if !f.Native {
+		t.Fatal("Features().Native = false in a build with the native library")
+	}
+	if len(f.Graphs) == 0 || f.Graphs[0] != GraphDefault {
+		t.Fatalf("Features().Graphs = %v, want the default graph first", f.Graphs)
+	}
+	if !sort.StringsAreSorted(f.Codecs) {
+		t.Fatalf("Features().Codecs = %v, want them sorted", f.Codecs)
+	}
+	ctx, err := NewContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+	if _, _, err := ctx.CompressExplain([]byte("probe")); err == nil && len(f.Codecs) == 0 {
+		t.Fatal("Features().Codecs is empty although introspection is available")
+	}
+	if probeGraph(Graph(99), make(map[string]bool)) {
+		t.Fatal("probeGraph() accepted an unknown graph")
+	}
+	if len(f.Types) != 4 {
+		t.Fatalf("Features().Types = %v, want all four input types", f.Types)
+	}
+}
+
+//go:generate go run ../internal/genframes -dir testdata/frames
+
+// TestGoldenFrames decompresses the frame corpus in testdata/frames, which
+// holds frames written by every library version the corpus has been
+// regenerated with. A library upgrade must keep decoding all of them.
+func TestGoldenFrames(t *testing.T) {
+	frames, err := filepath.Glob(filepath.Join("testdata", "frames", "v*", "*", "*.ozl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) == 0 {
+		t.Fatal("no golden frames in testdata/frames; run go generate ./openzl with the native library")
+	}
+	v := Version()
+	for version := v.MinFormatVersion; version <= v.MaxFormatVersion; version++ {
+		dir := filepath.Join("testdata", "frames", fmt.Sprintf("v%d", version))
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("no golden frames for format version %d; run go generate ./openzl to add them", version)
+		}
+	}
+
+	for _, path := range frames {
+		rel, _ := filepath.Rel(filepath.Join("testdata", "frames"), path)
+		t.Run(filepath.ToSlash(rel), func(t *testing.T) {
+			var version int
+			if _, err := fmt.Sscanf(filepath.Base(filepath.Dir(filepath.Dir(path))), "v%d", &version); err != nil {
+				t.Fatalf("cannot parse format version: %v", err)
+			}
+			if version < v.MinFormatVersion || version > v.MaxFormatVersion {
+				t.Fatalf("format version %d is not supported by OpenZL %s (%d-%d)",
+					version, v.Library(), v.MinFormatVersion, v.MaxFormatVersion)
+			}
+			name := strings.TrimSuffix(filepath.Base(path), ".ozl")
+			want, err := os.ReadFile(filepath.Join("testdata", "frames", "inputs", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			frame, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, err := NewContext()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ctx.Close()
+			switch filepath.Ext(name) {
+			case ".serial":
+				got, err := ctx.Decompress(frame)
+				if err != nil {
+					t.Fatalf("Decompress() failed: %v", err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Fatal("decompressed data differs from the input")
+				}
+			case ".u32":
+				got, err := ctx.DecompressInputs(frame)
+				if err != nil {
+					t.Fatalf("DecompressInputs() failed: %v", err)
+				}
+				if len(got) != 1 || got[0].Type != TypeNumeric || got[0].Width != 4 || !bytes.Equal(got[0].Data, want) {
+					t.Fatal("decompressed input differs from the original")
+				}
+			default:
+				t.Fatalf("unknown input kind %q", filepath.Ext(name))
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package openzl
+
+import (
+	"runtime"
+	"time"
+)
+
+// DefaultChunkSize is the number of uncompressed bytes stored in each frame
+// of a stream when no chunk size is configured.
+const DefaultChunkSize = 1 << 20
+
+// maxChunkSize bounds the chunk size so that block lengths fit in the
+// stream format's uint32 fields.
+const maxChunkSize = 1 << 30
+
+// Option configures contexts, streaming and parallel operations.
+type Option func(*options)
+
+type options struct {
+	level         int
+	graph         Graph
+	formatVersion int
+	concurrency   int
+	chunkSize     int
+	pool          *Pool
+
+	progress         func(Progress)
+	progressInterval time.Duration
+
+	observer Observer
+
+	overwrite    bool
+	removeSource bool
+	verify       bool
+
+	detectedGraph bool
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		concurrency:      1,
+		chunkSize:        DefaultChunkSize,
+		progressInterval: DefaultProgressInterval,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithLevel sets the compression level. Level 0 selects the library default;
+// the valid range is defined by the linked OpenZL library. Negative levels,
+// and levels the library rejects, make NewContext fail.
+func WithLevel(level int) Option {
+	return func(o *options) {
+		o.level = level
+	}
+}
+
+// WithGraph selects the graph compression starts with.
+func WithGraph(g Graph) Option {
+	return func(o *options) {
+		o.graph = g
+	}
+}
+
+// WithFormatVersion selects the frame format version written by compression,
+// so that older library versions can read the frames. Version 0 selects the
+// library default, normally its newest format; versions outside
+// Version().MinFormatVersion to MaxFormatVersion make NewContext fail.
+func WithFormatVersion(v int) Option {
+	return func(o *options) {
+		o.formatVersion = v
+	}
+}
+
+// WithConcurrency sets the number of goroutines, each with its own Context,
+// used to compress or decompress chunks. Values below 1 select
+// runtime.GOMAXPROCS(0).
+//
+// Streaming writers and readers default to a concurrency of 1; CompressParallel
+// and DecompressParallel default to runtime.GOMAXPROCS(0).
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		if n < 1 {
+			n = runtime.GOMAXPROCS(0)
+		}
+		o.concurrency = n
+	}
+}
+
+// WithChunkSize sets the number of uncompressed bytes compressed into each
+// frame. Larger chunks compress better; smaller chunks parallelise better.
+// Non-positive values select DefaultChunkSize.
+func WithChunkSize(n int) Option {
+	return func(o *options) {
+		if n <= 0 {
+			n = DefaultChunkSize
+		}
+		if n > maxChunkSize {
+			n = maxChunkSize
+		}
+		o.chunkSize = n
+	}
+}
+
+// WithPool makes streaming writers and readers, and parallel operations,
+// borrow their contexts from p and return them when done instead of creating
+// and closing their own. Pooled contexts use the pool's configuration, so
+// WithLevel and WithGraph are ignored.
+func WithPool(p *Pool) Option {
+	return func(o *options) {
+		o.pool = p
+	}
+}
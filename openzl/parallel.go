@@ -0,0 +1,188 @@
+package openzl
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gus3inov/openzl-go/internal/copenzl"
+)
+
+// CompressParallel compresses data into the stream format using all
+// available cores.
+//
+// The input is split into chunks (see WithChunkSize) that are compressed
+// concurrently, each goroutine using its own Context; frames are emitted in
+// input order. The concurrency defaults to runtime.GOMAXPROCS(0) and can be
+// changed with WithConcurrency. The output can be decompressed with
+// DecompressParallel or read with a Reader.
+func CompressParallel(data []byte, opts ...Option) ([]byte, error) {
+	return CompressParallelContext(context.Background(), data, opts...)
+}
+
+// CompressParallelContext is like CompressParallel but stops compressing
+// chunks once ctx is done, returning ctx.Err(). Chunks already being
+// compressed run to completion.
+func CompressParallelContext(ctx context.Context, data []byte, opts ...Option) ([]byte, error) {
+	o := newOptions(append([]Option{WithConcurrency(0)}, opts...))
+
+	n := (len(data) + o.chunkSize - 1) / o.chunkSize
+	frames := make([][]byte, n)
+	err := parallelFor(ctx, n, o, copenzl.ModeCompress, func(ctx *Context, i int) error {
+		start := i * o.chunkSize
+		end := min(start+o.chunkSize, len(data))
+		frame, err := ctx.Compress(data[start:end])
+		frames[i] = frame
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	size := streamHeaderSize + (n+1)*blockHeaderSize
+	for _, f := range frames {
+		size += len(f)
+	}
+	out := appendStreamHeader(make([]byte, 0, size))
+	for i, f := range frames {
+		rawSize := min(o.chunkSize, len(data)-i*o.chunkSize)
+		out = appendBlockHeader(out, len(f), rawSize)
+		out = append(out, f...)
+	}
+	return appendBlockHeader(out, 0, 0), nil
+}
+
+// DecompressParallel decompresses a stream produced by CompressParallel or
+// Writer, decompressing its frames concurrently.
+//
+// The concurrency defaults to runtime.GOMAXPROCS(0) and can be changed with
+// WithConcurrency.
+func DecompressParallel(data []byte, opts ...Option) ([]byte, error) {
+	return DecompressParallelContext(context.Background(), data, opts...)
+}
+
+// DecompressParallelContext is like DecompressParallel but stops
+// decompressing frames once ctx is done, returning ctx.Err(). Frames already
+// being decompressed run to completion.
+func DecompressParallelContext(ctx context.Context, data []byte, opts ...Option) ([]byte, error) {
+	o := newOptions(append([]Option{WithConcurrency(0)}, opts...))
+
+	blocks, total, err := parseBlocks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// The block headers only claim a total size, so the output is assembled
+	// from verified frames rather than allocated up front.
+	parts := make([][]byte, len(blocks))
+	err = parallelFor(ctx, len(blocks), o, copenzl.ModeDecompress, func(ctx *Context, i int) error {
+		b := blocks[i]
+		decompressed, err := decompressBlock(ctx, b.frame, b.rawSize)
+		parts[i] = decompressed
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, total)
+	for i, part := range parts {
+		out = append(out, part...)
+		parts[i] = nil
+	}
+	return out, nil
+}
+
+// block locates one frame of an in-memory stream.
+type block struct {
+	frame   []byte
+	rawSize int
+}
+
+// parseBlocks walks an in-memory stream and returns its blocks along with
+// the total decompressed size.
+func parseBlocks(data []byte) ([]block, int, error) {
+	if err := checkStreamHeader(data); err != nil {
+		return nil, 0, err
+	}
+	p := data[streamHeaderSize:]
+
+	var blocks []block
+	total := 0
+	for {
+		if len(p) < blockHeaderSize {
+			return nil, 0, ErrInvalidStream
+		}
+		compressedSize := binary.LittleEndian.Uint32(p[0:])
+		rawSize := binary.LittleEndian.Uint32(p[4:])
+		p = p[blockHeaderSize:]
+		if err := checkBlockSizes(compressedSize, rawSize); err != nil {
+			return nil, 0, err
+		}
+		if compressedSize == 0 {
+			if rawSize != 0 || len(p) != 0 {
+				return nil, 0, ErrInvalidStream
+			}
+			return blocks, total, nil
+		}
+		if int(compressedSize) > len(p) {
+			return nil, 0, ErrInvalidStream
+		}
+		blocks = append(blocks, block{frame: p[:compressedSize], rawSize: int(rawSize)})
+		total += int(rawSize)
+		p = p[compressedSize:]
+	}
+}
+
+// parallelFor calls fn for every index in [0, n) on up to o.concurrency
+// goroutines, each owning its own Context with native state for mode (see
+// options.acquire). It stops handing out work after
+// the first error, or once cctx is done, and returns that error or
+// cctx.Err().
+func parallelFor(cctx context.Context, n int, o options, mode int, fn func(ctx *Context, i int) error) error {
+	if n == 0 {
+		return nil
+	}
+	workers := max(1, min(o.concurrency, n))
+
+	var (
+		next     atomic.Int64
+		failed   atomic.Bool
+		errOnce  sync.Once
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	fail := func(err error) {
+		errOnce.Do(func() { firstErr = err })
+		failed.Store(true)
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, err := o.acquire(mode)
+			if err != nil {
+				fail(err)
+				return
+			}
+			defer o.release(ctx)
+			for !failed.Load() {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				if err := cctx.Err(); err != nil {
+					fail(err)
+					return
+				}
+				if err := fn(ctx, i); err != nil {
+					fail(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,129 @@
+package openzl
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gus3inov/openzl-go/internal/copenzl"
+)
+
+// Pool is a cache of Contexts sharing one configuration.
+//
+// Creating a Context is comparatively expensive, so servers handling many
+// short requests should borrow contexts from a Pool rather than create one per
+// request. Unlike a Context, a Pool is safe for concurrent use.
+//
+//	pool := openzl.NewPool(openzl.WithGraph(openzl.GraphZstd))
+//	defer pool.Close()
+//
+//	ctx, err := pool.Get()
+//	if err != nil {
+//		return err
+//	}
+//	defer pool.Put(ctx)
+//	compressed, err := ctx.Compress(data)
+type Pool struct {
+	o      options
+	mu     sync.Mutex
+	idle   []*Context
+	closed bool
+}
+
+// NewPool returns a Pool whose contexts are created with opts.
+//
+// WithConcurrency sets how many idle contexts the pool retains; it defaults
+// to runtime.GOMAXPROCS(0). Contexts returned beyond that are closed.
+func NewPool(opts ...Option) *Pool {
+	o := newOptions(append([]Option{WithConcurrency(0)}, opts...))
+	o.pool = nil
+	return &Pool{o: o}
+}
+
+// Get returns an idle context from the pool, or creates a new one.
+func (p *Pool) Get() (*Context, error) {
+	p.mu.Lock()
+	if n := len(p.idle); n > 0 {
+		ctx := p.idle[n-1]
+		p.idle[n-1] = nil
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return ctx, nil
+	}
+	p.mu.Unlock()
+	ob := observe(p.o.observer, context.Background(), OpPoolNew)
+	ctx, err := newContext(p.o)
+	ob.end(err)
+	return ctx, err
+}
+
+// Put returns a context obtained from Get to the pool. The context must not
+// be used afterwards. Closed contexts are discarded.
+func (p *Pool) Put(ctx *Context) {
+	if ctx == nil || ctx.ctx == nil {
+		return
+	}
+	p.mu.Lock()
+	if !p.closed && len(p.idle) < p.o.concurrency {
+		p.idle = append(p.idle, ctx)
+		ctx = nil
+	}
+	p.mu.Unlock()
+	if ctx != nil {
+		ctx.Close()
+	}
+}
+
+// Close closes the idle contexts. Contexts put back afterwards are closed
+// immediately; Get keeps working but no longer caches.
+//
+// It is safe to call Close multiple times.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.closed = true
+	p.mu.Unlock()
+	for _, ctx := range idle {
+		ctx.Close()
+	}
+	return nil
+}
+
+// Borrow returns a context for one operation and a function that gives it
+// back. The context comes from p, or, if p is nil, is created with opts and
+// closed by the function; opts are ignored when p is set. Packages building
+// on Context use Borrow to offer an optional Pool:
+//
+//	ctx, release, err := openzl.Borrow(pool)
+//	if err != nil {
+//		return err
+//	}
+//	defer release()
+func Borrow(p *Pool, opts ...Option) (*Context, func(), error) {
+	o := newOptions(opts)
+	o.pool = p
+	ctx, err := o.acquire(copenzl.ModeCompress | copenzl.ModeDecompress)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ctx, func() { o.release(ctx) }, nil
+}
+
+// acquire returns a context configured by o, borrowed from o.pool if set.
+// Otherwise a new context is created holding native state for the given
+// copenzl.Mode* flags only; pooled contexts support both modes.
+func (o options) acquire(mode int) (*Context, error) {
+	if o.pool != nil {
+		return o.pool.Get()
+	}
+	return newContextMode(o, mode)
+}
+
+// release gives back a context obtained from acquire.
+func (o options) release(ctx *Context) {
+	if o.pool != nil {
+		o.pool.Put(ctx)
+		return
+	}
+	ctx.Close()
+}
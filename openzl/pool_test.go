@@ -0,0 +1,122 @@
+package openzl
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	pool := NewPool(WithGraph(GraphZstd), WithConcurrency(2))
+	defer pool.Close()
+
+	data := bytes.Repeat([]byte("pooled contexts "), 256)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				ctx, err := pool.Get()
+				if err != nil {
+					t.Errorf("Get() failed: %v", err)
+					return
+				}
+				compressed, err := ctx.Compress(data)
+				if err == nil {
+					_, err = ctx.Decompress(compressed)
+				}
+				pool.Put(ctx)
+				if err != nil {
+					t.Errorf("round trip failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	pool.mu.Lock()
+	idle := len(pool.idle)
+	pool.mu.Unlock()
+	if idle > 2 {
+		t.Fatalf("pool retained %d idle contexts, want at most 2", idle)
+	}
+
+	ctx, err := pool.Get()
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	pool.Close()
+	pool.Put(ctx)
+	if _, err := ctx.Compress(data); err == nil || err.Error() != "context is closed" {
+		t.Fatalf("Put() after Close() should close the context, got %v", err)
+	}
+}
+
+func TestBorrow(t *testing.T) {
+	// Without a pool, the context is created for the call and closed by
+	// release.
+	ctx, release, err := Borrow(nil, WithGraph(GraphZstd))
+	if err != nil {
+		t.Fatalf("Borrow(nil) failed: %v", err)
+	}
+	if ctx.graph != GraphZstd {
+		t.Errorf("Borrow(nil) context has graph %v, want zstd", ctx.graph)
+	}
+	release()
+	if _, err := ctx.Compress([]byte("closed")); err == nil {
+		t.Error("context still usable after release")
+	}
+
+	pool := NewPool()
+	defer pool.Close()
+	ctx, release, err = Borrow(pool, WithGraph(GraphZstd))
+	if err != nil {
+		t.Fatalf("Borrow(pool) failed: %v", err)
+	}
+	if ctx.graph != GraphDefault {
+		t.Errorf("Borrow(pool) context has graph %v, want the pool's", ctx.graph)
+	}
+	release()
+	got, err := pool.Get()
+	if err != nil || got != ctx {
+		t.Errorf("release did not put the context back into the pool")
+	}
+	pool.Put(got)
+}
+
+func TestStreamWithPool(t *testing.T) {
+	pool := NewPool()
+	defer pool.Close()
+
+	data := bytes.Repeat([]byte("streams borrow contexts from the pool "), 4096)
+	for _, concurrency := range []int{1, 4} {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, WithPool(pool), WithConcurrency(concurrency), WithChunkSize(16<<10))
+		if err != nil {
+			t.Fatalf("NewWriter() failed: %v", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close() failed: %v", err)
+		}
+
+		got, err := DecompressParallel(buf.Bytes(), WithPool(pool))
+		if err != nil {
+			t.Fatalf("DecompressParallel() failed: %v", err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatal("Data integrity check failed")
+		}
+	}
+
+	pool.mu.Lock()
+	idle := len(pool.idle)
+	pool.mu.Unlock()
+	if idle == 0 {
+		t.Fatal("streams did not return their contexts to the pool")
+	}
+}
@@ -0,0 +1,91 @@
+package openzl
+
+import "time"
+
+// DefaultProgressInterval is the minimum time between progress reports when
+// no interval is configured.
+const DefaultProgressInterval = 100 * time.Millisecond
+
+// Progress reports how much data a Writer or Reader has processed.
+type Progress struct {
+	BytesIn  int64   // Bytes consumed: uncompressed for a Writer, compressed for a Reader
+	BytesOut int64   // Bytes produced: compressed for a Writer, uncompressed for a Reader
+	Ratio    float64 // Uncompressed per compressed byte so far; 0 until both are known
+	Done     bool    // Set on the final report, once the end marker is written or read
+}
+
+// WithProgress makes streaming writers and readers call fn as frames are
+// written or read, at most once per progress interval (see
+// WithProgressInterval), and once more with Done set when the stream ends.
+//
+// fn runs on the goroutine that writes or reads frames, which in concurrent
+// mode is not the caller's; it must not call back into the Writer or Reader.
+func WithProgress(fn func(Progress)) Option {
+	return func(o *options) {
+		o.progress = fn
+	}
+}
+
+// WithProgressInterval sets the minimum time between progress reports. Zero
+// reports after every frame; negative values select DefaultProgressInterval.
+func WithProgressInterval(d time.Duration) Option {
+	return func(o *options) {
+		if d < 0 {
+			d = DefaultProgressInterval
+		}
+		o.progressInterval = d
+	}
+}
+
+// progress accumulates byte counts and reports them to a WithProgress
+// callback. A nil *progress discards everything.
+type progress struct {
+	fn            func(Progress)
+	interval      time.Duration
+	decompressing bool
+	last          time.Time
+	p             Progress
+}
+
+func newProgress(o options, decompressing bool) *progress {
+	if o.progress == nil {
+		return nil
+	}
+	return &progress{fn: o.progress, interval: o.progressInterval, decompressing: decompressing, last: time.Now()}
+}
+
+// add counts processed bytes and reports them if the interval has passed.
+func (t *progress) add(in, out int) {
+	if t == nil {
+		return
+	}
+	t.p.BytesIn += int64(in)
+	t.p.BytesOut += int64(out)
+	if now := time.Now(); now.Sub(t.last) >= t.interval {
+		t.last = now
+		t.report()
+	}
+}
+
+// finish counts the last bytes processed and sends the final report.
+func (t *progress) finish(in, out int) {
+	if t == nil || t.p.Done {
+		return
+	}
+	t.p.BytesIn += int64(in)
+	t.p.BytesOut += int64(out)
+	t.p.Done = true
+	t.report()
+}
+
+func (t *progress) report() {
+	compressed, uncompressed := t.p.BytesOut, t.p.BytesIn
+	if t.decompressing {
+		compressed, uncompressed = uncompressed, compressed
+	}
+	t.p.Ratio = 0
+	if compressed > 0 && uncompressed > 0 {
+		t.p.Ratio = float64(uncompressed) / float64(compressed)
+	}
+	t.fn(t.p)
+}
@@ -0,0 +1,407 @@
+// Package protoozl compresses serialized protocol buffer messages with
+// OpenZL by splitting them into one stream per field.
+//
+// Compress parses the protobuf wire format directly, without a schema or a
+// protobuf dependency. The values of every top-level field number and wire
+// type go to an input of their own: varints and fixed-width values as
+// numeric inputs, length-delimited values (strings, bytes and nested
+// messages) as string inputs. The order of fields in each message is kept in
+// a separate stream, so Decompress reassembles the original bytes exactly,
+// including unknown fields, repeated fields and field order:
+//
+//	frame, err := protoozl.Compress(messages)
+//	if err != nil {
+//		return err
+//	}
+//	restored, err := protoozl.Decompress(frame) // same messages, same bytes
+//
+// Messages that do not parse, that use groups or non-minimal varints, or
+// that would exceed MaxFields are stored verbatim.
+package protoozl
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+// ErrInvalidFrame is returned by Decompress when a frame was not produced by
+// Compress or is corrupt.
+var ErrInvalidFrame = errors.New("protoozl: invalid frame")
+
+// MaxFields is the number of distinct (field number, wire type) pairs
+// stored as separate inputs. Messages using further fields are stored
+// verbatim.
+const MaxFields = 256
+
+// Wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+const formatVersion = 1
+
+// Input order within a frame; one input per field follows.
+const (
+	inputMeta   = iota
+	inputCounts // Fields per message, or rawMessage
+	inputOrder  // Field index of every field of every message
+	inputRaw    // Messages stored verbatim
+	numFixedInputs
+)
+
+// rawMessage in the counts input marks a message stored verbatim.
+const rawMessage = 1<<32 - 1
+
+// Option configures Compress and Decompress.
+type Option func(*config)
+
+type config struct {
+	pool *openzl.Pool
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithPool makes Compress and Decompress draw their context from p, so that
+// splitting many small messages does not allocate native state each time.
+func WithPool(p *openzl.Pool) Option {
+	return func(c *config) {
+		c.pool = p
+	}
+}
+
+// field identifies a stream: a field number and its wire type.
+type field struct {
+	number   uint64
+	wireType byte
+}
+
+// stream accumulates the values of one field.
+type stream struct {
+	numeric []byte   // Varints as uint64, fixed values as they are encoded
+	strs    [][]byte // Length-delimited values
+}
+
+// wireValue is one parsed field of a message.
+type wireValue struct {
+	f     field
+	value []byte // Fixed-width or length-delimited payload
+	n     uint64 // Varint value
+}
+
+// Compress compresses a batch of serialized messages into a single frame.
+func Compress(messages [][]byte, opts ...Option) ([]byte, error) {
+	c := newConfig(opts)
+
+	var (
+		fields  []field
+		index   = make(map[field]int)
+		streams []*stream
+		counts  = make([]byte, 0, 4*len(messages))
+		order   []byte
+		raw     [][]byte
+	)
+	for _, msg := range messages {
+		values, ok := parse(msg)
+		if ok {
+			added := 0
+			for _, v := range values {
+				if _, seen := index[v.f]; !seen {
+					index[v.f] = len(fields)
+					fields = append(fields, v.f)
+					streams = append(streams, &stream{})
+					added++
+				}
+			}
+			if len(fields) > MaxFields {
+				// Forget the fields this message introduced.
+				for _, f := range fields[len(fields)-added:] {
+					delete(index, f)
+				}
+				fields = fields[:len(fields)-added]
+				streams = streams[:len(streams)-added]
+				ok = false
+			}
+		}
+		if !ok {
+			counts = binary.LittleEndian.AppendUint32(counts, rawMessage)
+			raw = append(raw, msg)
+			continue
+		}
+		counts = binary.LittleEndian.AppendUint32(counts, uint32(len(values)))
+		for _, v := range values {
+			i := index[v.f]
+			order = append(order, byte(i))
+			s := streams[i]
+			switch v.f.wireType {
+			case wireVarint:
+				s.numeric = binary.LittleEndian.AppendUint64(s.numeric, v.n)
+			case wireFixed64, wireFixed32:
+				s.numeric = append(s.numeric, v.value...)
+			case wireBytes:
+				s.strs = append(s.strs, v.value)
+			}
+		}
+	}
+
+	meta := []byte{formatVersion}
+	meta = binary.AppendUvarint(meta, uint64(len(fields)))
+	for _, f := range fields {
+		meta = binary.AppendUvarint(meta, f.number)
+		meta = append(meta, f.wireType)
+	}
+
+	inputs := make([]openzl.Input, numFixedInputs, numFixedInputs+len(fields))
+	inputs[inputMeta] = openzl.SerialInput(meta)
+	inputs[inputCounts] = openzl.NumericInput(counts, 4)
+	inputs[inputOrder] = openzl.NumericInput(order, 1)
+	inputs[inputRaw] = openzl.StringInput(raw)
+	for i, f := range fields {
+		inputs = append(inputs, streams[i].input(f.wireType))
+	}
+
+	ctx, release, err := openzl.Borrow(c.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return ctx.CompressInputs(inputs...)
+}
+
+func (s *stream) input(wireType byte) openzl.Input {
+	switch wireType {
+	case wireVarint, wireFixed64:
+		return openzl.NumericInput(s.numeric, 8)
+	case wireFixed32:
+		return openzl.NumericInput(s.numeric, 4)
+	}
+	return openzl.StringInput(s.strs)
+}
+
+// parse splits msg into its top-level fields. It reports false unless the
+// fields re-encode to exactly msg.
+func parse(msg []byte) ([]wireValue, bool) {
+	var values []wireValue
+	for len(msg) > 0 {
+		tag, n := uvarint(msg)
+		if n <= 0 {
+			return nil, false
+		}
+		msg = msg[n:]
+		v := wireValue{f: field{number: tag >> 3, wireType: byte(tag & 7)}}
+		if v.f.number == 0 || v.f.number > 1<<29-1 {
+			return nil, false
+		}
+		switch v.f.wireType {
+		case wireVarint:
+			if v.n, n = uvarint(msg); n <= 0 {
+				return nil, false
+			}
+			msg = msg[n:]
+		case wireFixed64, wireFixed32:
+			size := 8
+			if v.f.wireType == wireFixed32 {
+				size = 4
+			}
+			if len(msg) < size {
+				return nil, false
+			}
+			v.value, msg = msg[:size], msg[size:]
+		case wireBytes:
+			l, n := uvarint(msg)
+			if n <= 0 || l > uint64(len(msg)-n) {
+				return nil, false
+			}
+			msg = msg[n:]
+			v.value, msg = msg[:l], msg[l:]
+		default:
+			// Groups are deprecated and rare; such messages are kept whole.
+			return nil, false
+		}
+		values = append(values, v)
+	}
+	return values, true
+}
+
+// uvarint decodes a varint, reporting n <= 0 unless it is minimally encoded,
+// since only those re-encode to the same bytes.
+func uvarint(b []byte) (uint64, int) {
+	v, n := binary.Uvarint(b)
+	if n <= 0 || n != uvarintLen(v) {
+		return 0, -1
+	}
+	return v, n
+}
+
+func uvarintLen(v uint64) int {
+	n := 1
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+	return n
+}
+
+// Decompress restores the messages compressed into frame by Compress.
+func Decompress(frame []byte, opts ...Option) ([][]byte, error) {
+	c := newConfig(opts)
+	ctx, release, err := openzl.Borrow(c.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	inputs, err := ctx.DecompressInputs(frame)
+	if err != nil {
+		return nil, err
+	}
+	return assemble(inputs)
+}
+
+func parseMeta(meta []byte) ([]field, error) {
+	if len(meta) == 0 || meta[0] != formatVersion {
+		return nil, ErrInvalidFrame
+	}
+	meta = meta[1:]
+	nfields, n := binary.Uvarint(meta)
+	if n <= 0 || nfields > MaxFields {
+		return nil, ErrInvalidFrame
+	}
+	meta = meta[n:]
+	fields := make([]field, nfields)
+	for i := range fields {
+		number, n := binary.Uvarint(meta)
+		if n <= 0 || n >= len(meta) || number == 0 || number > 1<<29-1 {
+			return nil, ErrInvalidFrame
+		}
+		fields[i] = field{number: number, wireType: meta[n]}
+		switch fields[i].wireType {
+		case wireVarint, wireFixed64, wireFixed32, wireBytes:
+		default:
+			return nil, ErrInvalidFrame
+		}
+		meta = meta[n+1:]
+	}
+	if len(meta) != 0 {
+		return nil, ErrInvalidFrame
+	}
+	return fields, nil
+}
+
+// fieldReader hands out the values of one field in order.
+type fieldReader struct {
+	numeric []byte
+	strs    [][]byte
+}
+
+// assemble rebuilds the messages from the decompressed inputs of a frame.
+func assemble(inputs []openzl.Input) ([][]byte, error) {
+	if len(inputs) < numFixedInputs || inputs[inputMeta].Type != openzl.TypeSerial {
+		return nil, ErrInvalidFrame
+	}
+	fields, err := parseMeta(inputs[inputMeta].Data)
+	if err != nil {
+		return nil, err
+	}
+	counts, order := inputs[inputCounts], inputs[inputOrder]
+	if len(inputs) != numFixedInputs+len(fields) ||
+		counts.Type != openzl.TypeNumeric || counts.Width != 4 ||
+		order.Type != openzl.TypeNumeric || order.Width != 1 {
+		return nil, ErrInvalidFrame
+	}
+	raw, err := inputs[inputRaw].Strings()
+	if err != nil {
+		return nil, ErrInvalidFrame
+	}
+	readers := make([]fieldReader, len(fields))
+	for i, f := range fields {
+		in := inputs[numFixedInputs+i]
+		switch f.wireType {
+		case wireBytes:
+			if readers[i].strs, err = in.Strings(); err != nil {
+				return nil, ErrInvalidFrame
+			}
+		default:
+			want := 8
+			if f.wireType == wireFixed32 {
+				want = 4
+			}
+			if in.Type != openzl.TypeNumeric || in.Width != want {
+				return nil, ErrInvalidFrame
+			}
+			readers[i].numeric = in.Data
+		}
+	}
+
+	seq := order.Data
+	messages := make([][]byte, counts.Len())
+	for m := range messages {
+		count := binary.LittleEndian.Uint32(counts.Data[4*m:])
+		if count == rawMessage {
+			if len(raw) == 0 {
+				return nil, ErrInvalidFrame
+			}
+			messages[m], raw = raw[0], raw[1:]
+			continue
+		}
+		if uint64(count) > uint64(len(seq)) {
+			return nil, ErrInvalidFrame
+		}
+		var msg []byte
+		for _, i := range seq[:count] {
+			if int(i) >= len(fields) {
+				return nil, ErrInvalidFrame
+			}
+			f, r := fields[i], &readers[i]
+			msg = binary.AppendUvarint(msg, f.number<<3|uint64(f.wireType))
+			switch f.wireType {
+			case wireVarint:
+				if len(r.numeric) < 8 {
+					return nil, ErrInvalidFrame
+				}
+				msg = binary.AppendUvarint(msg, binary.LittleEndian.Uint64(r.numeric))
+				r.numeric = r.numeric[8:]
+			case wireFixed64, wireFixed32:
+				size := 8
+				if f.wireType == wireFixed32 {
+					size = 4
+				}
+				if len(r.numeric) < size {
+					return nil, ErrInvalidFrame
+				}
+				msg = append(msg, r.numeric[:size]...)
+				r.numeric = r.numeric[size:]
+			case wireBytes:
+				if len(r.strs) == 0 {
+					return nil, ErrInvalidFrame
+				}
+				msg = binary.AppendUvarint(msg, uint64(len(r.strs[0])))
+				msg = append(msg, r.strs[0]...)
+				r.strs = r.strs[1:]
+			}
+		}
+		seq = seq[count:]
+		if msg == nil {
+			msg = []byte{}
+		}
+		messages[m] = msg
+	}
+	if len(seq) != 0 || len(raw) != 0 {
+		return nil, ErrInvalidFrame
+	}
+	for _, r := range readers {
+		if len(r.numeric) != 0 || len(r.strs) != 0 {
+			return nil, ErrInvalidFrame
+		}
+	}
+	return messages, nil
+}
@@ -0,0 +1,201 @@
+package protoozl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+// Helpers encoding single fields in the wire format.
+
+func varintField(b []byte, num int, v uint64) []byte {
+	b = binary.AppendUvarint(b, uint64(num)<<3|wireVarint)
+	return binary.AppendUvarint(b, v)
+}
+
+func fixed64Field(b []byte, num int, v uint64) []byte {
+	b = binary.AppendUvarint(b, uint64(num)<<3|wireFixed64)
+	return binary.LittleEndian.AppendUint64(b, v)
+}
+
+func fixed32Field(b []byte, num int, v uint32) []byte {
+	b = binary.AppendUvarint(b, uint64(num)<<3|wireFixed32)
+	return binary.LittleEndian.AppendUint32(b, v)
+}
+
+func bytesField(b []byte, num int, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(num)<<3|wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// sampleMessages returns n messages shaped like a metrics sample with a
+// nested label message.
+func sampleMessages(n int) [][]byte {
+	messages := make([][]byte, n)
+	for i := range messages {
+		var label []byte
+		label = bytesField(label, 1, []byte("host"))
+		label = bytesField(label, 2, []byte(fmt.Sprintf("web-%02d", i%16)))
+
+		var m []byte
+		m = varintField(m, 1, uint64(1700000000000+i*1000))
+		m = bytesField(m, 2, []byte("http_requests_total"))
+		m = fixed64Field(m, 3, math.Float64bits(float64(i)*1.5))
+		m = bytesField(m, 4, label)
+		if i%3 == 0 {
+			m = fixed32Field(m, 5, uint32(i))
+		}
+		m = varintField(m, 6, uint64(int64(-i))) // Negative int64: ten bytes
+		messages[i] = m
+	}
+	return messages
+}
+
+func TestRoundTrip(t *testing.T) {
+	var wide []byte
+	for i := 1; i <= MaxFields+1; i++ {
+		wide = varintField(wide, i, uint64(i))
+	}
+
+	testCases := []struct {
+		name     string
+		messages [][]byte
+	}{
+		{name: "none", messages: nil},
+		{name: "empty message", messages: [][]byte{{}, {}}},
+		{name: "sample", messages: sampleMessages(200)},
+		{name: "repeated and unordered", messages: [][]byte{
+			varintField(varintField(varintField(nil, 3, 1), 1, 2), 3, 3),
+			bytesField(bytesField(nil, 7, nil), 7, []byte("x")),
+		}},
+		{name: "same number different wire types", messages: [][]byte{
+			varintField(fixed32Field(nil, 1, 7), 1, 8),
+		}},
+		{name: "non-minimal varint", messages: [][]byte{{1<<3 | wireVarint, 0x81, 0x00}, varintField(nil, 1, 1)}},
+		{name: "group", messages: [][]byte{{1<<3 | 3, 1<<3 | 4}}},
+		{name: "truncated", messages: [][]byte{bytesField(nil, 1, []byte("hello"))[:4], {0x08}}},
+		{name: "field zero", messages: [][]byte{{0x00, 0x01}}},
+		{name: "not protobuf", messages: [][]byte{[]byte("plain text, not a message")}},
+		{name: "too many fields", messages: [][]byte{wide, varintField(nil, 1, 1)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			frame, err := Compress(tc.messages)
+			if err != nil {
+				t.Fatalf("Compress() failed: %v", err)
+			}
+			got, err := Decompress(frame)
+			if err != nil {
+				t.Fatalf("Decompress() failed: %v", err)
+			}
+			if len(got) != len(tc.messages) {
+				t.Fatalf("Decompress() returned %d messages, want %d", len(got), len(tc.messages))
+			}
+			for i := range got {
+				if !bytes.Equal(got[i], tc.messages[i]) {
+					t.Fatalf("message %d = %x, want %x", i, got[i], tc.messages[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFieldStreams(t *testing.T) {
+	pool := openzl.NewPool()
+	defer pool.Close()
+
+	frame, err := Compress(sampleMessages(30), WithPool(pool))
+	if err != nil {
+		t.Fatalf("Compress() failed: %v", err)
+	}
+	ctx, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Put(ctx)
+	inputs, err := ctx.DecompressInputs(frame)
+	if err != nil {
+		t.Fatalf("DecompressInputs() failed: %v", err)
+	}
+
+	want := []struct {
+		typ   openzl.Type
+		width int
+		n     int
+	}{
+		{openzl.TypeNumeric, 8, 30}, // 1: varint timestamp
+		{openzl.TypeString, 0, 30},  // 2: name
+		{openzl.TypeNumeric, 8, 30}, // 3: fixed64 value
+		{openzl.TypeString, 0, 30},  // 4: nested label
+		{openzl.TypeNumeric, 4, 10}, // 5: fixed32, every third message
+		{openzl.TypeNumeric, 8, 30}, // 6: negative varint
+	}
+	if len(inputs) != numFixedInputs+len(want) {
+		t.Fatalf("frame has %d inputs, want %d", len(inputs), numFixedInputs+len(want))
+	}
+	if n := inputs[inputRaw].Len(); n != 0 {
+		t.Fatalf("%d messages stored verbatim, want 0", n)
+	}
+	for i, w := range want {
+		in := inputs[numFixedInputs+i]
+		if in.Type != w.typ || in.Width != w.width || in.Len() != w.n {
+			t.Errorf("field %d stored as %s of width %d with %d values, want %s of width %d with %d",
+				i+1, in.Type, in.Width, in.Len(), w.typ, w.width, w.n)
+		}
+	}
+}
+
+func TestInvalidFrame(t *testing.T) {
+	ctx, err := openzl.NewContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	plain, err := ctx.Compress(varintField(nil, 1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decompress(plain); err != ErrInvalidFrame {
+		t.Fatalf("Decompress() of a plain frame = %v, want %v", err, ErrInvalidFrame)
+	}
+
+	// A message claiming two fields where the order stream has one.
+	bad, err := ctx.CompressInputs(
+		openzl.SerialInput([]byte{formatVersion, 1, 1, wireVarint}),
+		openzl.NumericInput([]byte{2, 0, 0, 0}, 4),
+		openzl.NumericInput([]byte{0}, 1),
+		openzl.StringInput(nil),
+		openzl.Int64Input([]int64{5}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decompress(bad); err != ErrInvalidFrame {
+		t.Fatalf("Decompress() of an inconsistent frame = %v, want %v", err, ErrInvalidFrame)
+	}
+}
+
+func BenchmarkCompress(b *testing.B) {
+	messages := sampleMessages(20000)
+	size := 0
+	for _, m := range messages {
+		size += len(m)
+	}
+	pool := openzl.NewPool()
+	defer pool.Close()
+
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Compress(messages, WithPool(pool)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Package purezl decodes OpenZL frames in pure Go, without cgo.
+//
+// It is the decompressor used by the openzl package when it is built with
+// CGO_ENABLED=0, and can be called directly by programs that must not depend
+// on the native library. It handles a subset of frames and returns
+// ErrUnsupported for all others; it never guesses.
+//
+// Supported frames:
+//
+//   - empty frames, which the bindings produce for empty input.
+//
+// OpenZL does not yet specify its frame format as stable, so codecs are only
+// added here together with cross-check tests against frames produced by the
+// native library. Until a codec is supported, callers that need to read
+// arbitrary frames must build with cgo.
+package purezl
+
+import "errors"
+
+// ErrUnsupported is returned for frames the pure-Go decoder cannot decode.
+var ErrUnsupported = errors.New("openzl: frame not supported by the pure-Go decoder")
+
+// Supported reports whether Decompress can decode frame.
+func Supported(frame []byte) bool {
+	return len(frame) == 0
+}
+
+// DecompressedSize returns the number of bytes frame decompresses to, or
+// ErrUnsupported if Decompress cannot decode it. The openzl package uses it
+// to validate stream blocks before allocating their output.
+func DecompressedSize(frame []byte) (int, error) {
+	if !Supported(frame) {
+		return 0, ErrUnsupported
+	}
+	return 0, nil
+}
+
+// Decompress decodes frame, or returns ErrUnsupported if the frame uses a
+// format or codec this package does not implement.
+func Decompress(frame []byte) ([]byte, error) {
+	if !Supported(frame) {
+		return nil, ErrUnsupported
+	}
+	return []byte{}, nil
+}
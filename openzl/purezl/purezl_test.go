@@ -0,0 +1,116 @@
+package purezl_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gus3inov/openzl-go/openzl"
+	"github.com/gus3inov/openzl-go/openzl/purezl"
+)
+
+func TestDecompress(t *testing.T) {
+	got, err := purezl.Decompress(nil)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("Decompress(nil) = %q, %v, want empty output", got, err)
+	}
+	if _, err := purezl.Decompress([]byte("not a frame")); err != purezl.ErrUnsupported {
+		t.Fatalf("Decompress() of garbage = %v, want %v", err, purezl.ErrUnsupported)
+	}
+}
+
+func TestDecompressedSize(t *testing.T) {
+	if n, err := purezl.DecompressedSize(nil); err != nil || n != 0 {
+		t.Fatalf("DecompressedSize(nil) = %d, %v, want 0", n, err)
+	}
+	if _, err := purezl.DecompressedSize([]byte("not a frame")); err != purezl.ErrUnsupported {
+		t.Fatalf("DecompressedSize() of garbage = %v, want %v", err, purezl.ErrUnsupported)
+	}
+}
+
+// TestCrossCheck decodes the golden frame corpus of the openzl package,
+// written by the native library for every format version and graph. The
+// pure-Go decoder must return the original data or ErrUnsupported, never
+// different data or another error. It runs without cgo, so it also checks
+// CGO_ENABLED=0 builds.
+func TestCrossCheck(t *testing.T) {
+	corpus := filepath.Join("..", "testdata", "frames")
+	frames, err := filepath.Glob(filepath.Join(corpus, "v*", "*", "*.serial.ozl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) == 0 {
+		t.Fatalf("no golden frames in %s; run go generate ./openzl with the native library", corpus)
+	}
+	for _, path := range frames {
+		rel, _ := filepath.Rel(corpus, path)
+		t.Run(filepath.ToSlash(rel), func(t *testing.T) {
+			frame, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(filepath.Join(corpus, "inputs", strings.TrimSuffix(filepath.Base(path), ".ozl")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkDecode(t, frame, want)
+		})
+	}
+}
+
+// checkDecode checks that Decompress and DecompressedSize either decode
+// frame to want or both report ErrUnsupported.
+func checkDecode(t *testing.T, frame, want []byte) {
+	t.Helper()
+	got, err := purezl.Decompress(frame)
+	if errors.Is(err, purezl.ErrUnsupported) {
+		if purezl.Supported(frame) {
+			t.Fatal("Supported() reported a frame Decompress rejects")
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("Decompress() failed: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("Decompress() returned %d bytes differing from the %d-byte original", len(got), len(want))
+	}
+	if n, err := purezl.DecompressedSize(frame); err != nil || n != len(want) {
+		t.Fatalf("DecompressedSize() = %d, %v, want %d", n, err, len(want))
+	}
+}
+
+// TestCrossCheckNative compresses samples with the native library, when it
+// is linked, using every graph, and checks the pure-Go decoder on the frames.
+func TestCrossCheckNative(t *testing.T) {
+	samples := [][]byte{
+		nil,
+		[]byte("a"),
+		bytes.Repeat([]byte("pure-Go cross-check "), 500),
+		bytes.Repeat([]byte{0, 1, 2, 3, 4, 5, 6, 7}, 1000),
+	}
+
+	for _, g := range openzl.Graphs() {
+		t.Run(g.String(), func(t *testing.T) {
+			ctx, err := openzl.NewContext(openzl.WithGraph(g))
+			if err != nil {
+				t.Skipf("native library unavailable: %v", err)
+			}
+			defer ctx.Close()
+			if _, err := ctx.Compress([]byte("probe")); err != nil {
+				t.Skipf("native library unavailable: %v", err)
+			}
+
+			for _, sample := range samples {
+				frame, err := ctx.Compress(sample)
+				if err != nil {
+					t.Fatalf("Compress() failed: %v", err)
+				}
+				checkDecode(t, frame, sample)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package seekable
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+// Reader provides random access to the decompressed contents of a seekable
+// stream. Only the frames overlapping a requested range are read and
+// decompressed; the most recently decompressed frame is cached so that
+// sequential reads do not decompress the same frame twice.
+//
+// Reader implements io.ReaderAt, io.ReadSeeker and io.Closer. ReadAt may be
+// called concurrently; Read and Seek share a cursor and must not be.
+type Reader struct {
+	r      io.ReaderAt
+	frames []frame
+	size   int64 // total decompressed size
+	pos    int64 // cursor for Read and Seek
+
+	mu      sync.Mutex
+	ctx     *openzl.Context
+	cached  int // index of the cached frame, -1 if none
+	cache   []byte
+	scratch []byte
+	closed  bool
+}
+
+// NewReader reads the jump table of the seekable stream stored in the first
+// size bytes of r and returns a Reader over its decompressed contents.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	if size < footerSize {
+		return nil, ErrInvalidFormat
+	}
+	footer := make([]byte, footerSize)
+	if _, err := r.ReadAt(footer, size-footerSize); err != nil {
+		return nil, err
+	}
+	n, err := parseFooter(footer)
+	if err != nil {
+		return nil, err
+	}
+
+	// The table must fit in the stream before it is allocated.
+	if int64(n) > (size-footerSize)/entrySize {
+		return nil, ErrInvalidFormat
+	}
+	tableSize := int64(n) * entrySize
+	dataSize := size - footerSize - tableSize
+	table := make([]byte, tableSize)
+	if _, err := r.ReadAt(table, dataSize); err != nil {
+		return nil, err
+	}
+	frames, err := parseEntries(table, dataSize)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err := openzl.NewContext()
+	if err != nil {
+		return nil, err
+	}
+
+	rd := &Reader{r: r, frames: frames, ctx: ctx, cached: -1}
+	if n > 0 {
+		last := frames[n-1]
+		rd.size = last.outOffset + int64(last.decompressedSize)
+	}
+	return rd, nil
+}
+
+// Size returns the total decompressed size of the stream.
+func (r *Reader) Size() int64 {
+	return r.size
+}
+
+// NumFrames returns the number of frames in the stream.
+func (r *Reader) NumFrames() int {
+	return len(r.frames)
+}
+
+// ReadAt reads len(p) decompressed bytes starting at offset off.
+//
+// It decompresses only the frames that overlap [off, off+len(p)). As required
+// by io.ReaderAt, it returns a non-nil error whenever n < len(p).
+func (r *Reader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("seekable: negative offset")
+	}
+	if off >= r.size {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0, errors.New("seekable: read from closed reader")
+	}
+
+	// Find the first frame whose decompressed range ends after off.
+	i := sort.Search(len(r.frames), func(i int) bool {
+		f := r.frames[i]
+		return f.outOffset+int64(f.decompressedSize) > off
+	})
+
+	n := 0
+	for n < len(p) && i < len(r.frames) {
+		data, err := r.frameData(i)
+		if err != nil {
+			return n, err
+		}
+		start := off + int64(n) - r.frames[i].outOffset
+		n += copy(p[n:], data[start:])
+		i++
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// frameData returns the decompressed contents of frame i, using the cache
+// when possible. The caller must hold r.mu.
+func (r *Reader) frameData(i int) ([]byte, error) {
+	if r.cached == i {
+		return r.cache, nil
+	}
+	f := r.frames[i]
+	if cap(r.scratch) < int(f.compressedSize) {
+		r.scratch = make([]byte, f.compressedSize)
+	}
+	compressed := r.scratch[:f.compressedSize]
+	if _, err := r.r.ReadAt(compressed, f.offset); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	// The frame header must agree with the jump table before the output is
+	// allocated.
+	if size, err := openzl.DecompressedSize(compressed); err != nil {
+		return nil, err
+	} else if size != int(f.decompressedSize) {
+		return nil, ErrChecksumMismatch
+	}
+	data, err := r.ctx.Decompress(compressed)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != int(f.decompressedSize) || checksum(data) != f.checksum {
+		return nil, ErrChecksumMismatch
+	}
+	r.cached = i
+	r.cache = data
+	return data, nil
+}
+
+// Read reads up to len(p) decompressed bytes from the current position.
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.pos >= r.size {
+		return 0, io.EOF
+	}
+	if rem := r.size - r.pos; int64(len(p)) > rem {
+		p = p[:rem]
+	}
+	n, err := r.ReadAt(p, r.pos)
+	r.pos += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// Seek sets the position for the next Read, interpreted according to whence
+// as described by io.Seeker. Seeking past the end is allowed; subsequent reads
+// return io.EOF.
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = r.pos + offset
+	case io.SeekEnd:
+		abs = r.size + offset
+	default:
+		return 0, errors.New("seekable: invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("seekable: negative position")
+	}
+	r.pos = abs
+	return abs, nil
+}
+
+// Close releases the decompression context. It does not close the underlying
+// io.ReaderAt. It is safe to call Close multiple times.
+func (r *Reader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	r.cache = nil
+	return r.ctx.Close()
+}
@@ -0,0 +1,146 @@
+// Package seekable implements a random-access container built from
+// independent OpenZL frames.
+//
+// A seekable stream is a sequence of OpenZL frames followed by a jump table
+// that records, for every frame, its compressed size, its decompressed size
+// and a CRC-32C checksum of the decompressed bytes:
+//
+//	[frame 0][frame 1]...[frame n-1][entry 0]...[entry n-1][footer]
+//
+// Each entry is 12 bytes (compressed size, decompressed size, checksum) and
+// the 12-byte footer holds the number of frames, a format version and a magic
+// number. All integers are little-endian uint32. Because the jump table lives
+// at the end of the stream, a Reader can locate any decompressed byte range
+// and decompress only the frames covering it.
+//
+// Writing:
+//
+//	w, err := seekable.NewWriter(f, seekable.DefaultFrameSize)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if _, err := w.Write(data); err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := w.Close(); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Reading a range:
+//
+//	r, err := seekable.NewReader(f, size)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer r.Close()
+//
+//	buf := make([]byte, 4096)
+//	n, err := r.ReadAt(buf, 1<<30)
+package seekable
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// DefaultFrameSize is the number of decompressed bytes stored per frame when
+// NewWriter is called with a non-positive frame size.
+const DefaultFrameSize = 1 << 20
+
+const (
+	magic      = 0x534c5a4f // "OZLS" in little-endian byte order
+	version    = 1
+	entrySize  = 12
+	footerSize = 12
+)
+
+var (
+	// ErrInvalidFormat is returned when the jump table or footer of a
+	// seekable stream is missing or inconsistent.
+	ErrInvalidFormat = errors.New("seekable: invalid jump table")
+
+	// ErrChecksumMismatch is returned when a decompressed frame does not
+	// match the checksum recorded in the jump table.
+	ErrChecksumMismatch = errors.New("seekable: frame checksum mismatch")
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// entry describes a single frame in the jump table.
+type entry struct {
+	compressedSize   uint32
+	decompressedSize uint32
+	checksum         uint32
+}
+
+// frame is an entry resolved to absolute offsets within the stream.
+type frame struct {
+	entry
+	offset    int64 // offset of the compressed frame in the stream
+	outOffset int64 // offset of the frame's first byte in the decompressed data
+}
+
+func checksum(p []byte) uint32 {
+	return crc32.Checksum(p, castagnoli)
+}
+
+// appendJumpTable serialises entries followed by the footer.
+func appendJumpTable(dst []byte, entries []entry) []byte {
+	var buf [entrySize]byte
+	for _, e := range entries {
+		binary.LittleEndian.PutUint32(buf[0:], e.compressedSize)
+		binary.LittleEndian.PutUint32(buf[4:], e.decompressedSize)
+		binary.LittleEndian.PutUint32(buf[8:], e.checksum)
+		dst = append(dst, buf[:]...)
+	}
+	binary.LittleEndian.PutUint32(buf[0:], uint32(len(entries)))
+	binary.LittleEndian.PutUint32(buf[4:], version)
+	binary.LittleEndian.PutUint32(buf[8:], magic)
+	return append(dst, buf[:footerSize]...)
+}
+
+// parseFooter validates the footer and returns the number of frames.
+func parseFooter(p []byte) (int, error) {
+	if len(p) != footerSize {
+		return 0, ErrInvalidFormat
+	}
+	if binary.LittleEndian.Uint32(p[8:]) != magic {
+		return 0, ErrInvalidFormat
+	}
+	if binary.LittleEndian.Uint32(p[4:]) != version {
+		return 0, ErrInvalidFormat
+	}
+	return int(binary.LittleEndian.Uint32(p[0:])), nil
+}
+
+// parseEntries decodes the jump table entries and resolves them to offsets.
+// dataSize is the number of bytes preceding the jump table. Entries are
+// validated against the limits of Writer and against dataSize, so that a
+// corrupt table cannot make a Reader allocate more than the stream holds.
+func parseEntries(p []byte, dataSize int64) ([]frame, error) {
+	if len(p)%entrySize != 0 {
+		return nil, ErrInvalidFormat
+	}
+	frames := make([]frame, len(p)/entrySize)
+	var offset, outOffset int64
+	for i := range frames {
+		b := p[i*entrySize:]
+		e := entry{
+			compressedSize:   binary.LittleEndian.Uint32(b[0:]),
+			decompressedSize: binary.LittleEndian.Uint32(b[4:]),
+			checksum:         binary.LittleEndian.Uint32(b[8:]),
+		}
+		if e.compressedSize > maxCompressedSize || e.decompressedSize > maxFrameSize ||
+			int64(e.compressedSize) > dataSize-offset {
+			return nil, ErrInvalidFormat
+		}
+		frames[i] = frame{entry: e, offset: offset, outOffset: outOffset}
+		offset += int64(e.compressedSize)
+		outOffset += int64(e.decompressedSize)
+	}
+	if offset != dataSize {
+		return nil, ErrInvalidFormat
+	}
+	return frames, nil
+}
@@ -0,0 +1,249 @@
+package seekable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func testData(n int) []byte {
+	var buf bytes.Buffer
+	for i := 0; buf.Len() < n; i++ {
+		fmt.Fprintf(&buf, "record %08d: seekable OpenZL test payload\n", i)
+	}
+	return buf.Bytes()[:n]
+}
+
+func writeStream(t *testing.T, data []byte, frameSize int) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	w, err := NewWriter(&out, frameSize)
+	if err != nil {
+		t.Fatalf("NewWriter() failed: %v", err)
+	}
+	// Write in uneven pieces to exercise frame boundaries.
+	for p := data; len(p) > 0; {
+		n := 777
+		if n > len(p) {
+			n = len(p)
+		}
+		if _, err := w.Write(p[:n]); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+		p = p[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	return out.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name      string
+		size      int
+		frameSize int
+	}{
+		{name: "empty", size: 0, frameSize: 1024},
+		{name: "single frame", size: 500, frameSize: 1024},
+		{name: "exact frames", size: 4096, frameSize: 1024},
+		{name: "partial last frame", size: 10000, frameSize: 1024},
+		{name: "default frame size", size: 10000, frameSize: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := testData(tc.size)
+			stream := writeStream(t, data, tc.frameSize)
+
+			r, err := NewReader(bytes.NewReader(stream), int64(len(stream)))
+			if err != nil {
+				t.Fatalf("NewReader() failed: %v", err)
+			}
+			defer r.Close()
+
+			if r.Size() != int64(len(data)) {
+				t.Fatalf("Size() = %d, want %d", r.Size(), len(data))
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("ReadAll() failed: %v", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatal("Data integrity check failed")
+			}
+		})
+	}
+}
+
+func TestReadAt(t *testing.T) {
+	data := testData(10000)
+	stream := writeStream(t, data, 1000)
+
+	r, err := NewReader(bytes.NewReader(stream), int64(len(stream)))
+	if err != nil {
+		t.Fatalf("NewReader() failed: %v", err)
+	}
+	defer r.Close()
+
+	if r.NumFrames() != 10 {
+		t.Fatalf("NumFrames() = %d, want 10", r.NumFrames())
+	}
+
+	ranges := []struct{ off, n int }{
+		{0, 10},
+		{995, 10},    // spans two frames
+		{1500, 3000}, // spans four frames
+		{9990, 10},   // ends exactly at EOF
+		{0, 10000},
+	}
+	for _, rg := range ranges {
+		buf := make([]byte, rg.n)
+		n, err := r.ReadAt(buf, int64(rg.off))
+		if err != nil {
+			t.Fatalf("ReadAt(%d, %d) failed: %v", rg.off, rg.n, err)
+		}
+		if !bytes.Equal(buf[:n], data[rg.off:rg.off+rg.n]) {
+			t.Fatalf("ReadAt(%d, %d) returned wrong data", rg.off, rg.n)
+		}
+	}
+
+	buf := make([]byte, 20)
+	n, err := r.ReadAt(buf, 9990)
+	if err != io.EOF || n != 10 {
+		t.Fatalf("ReadAt past end = (%d, %v), want (10, EOF)", n, err)
+	}
+	if _, err := r.ReadAt(buf, 20000); err != io.EOF {
+		t.Fatalf("ReadAt beyond size = %v, want EOF", err)
+	}
+}
+
+func TestSeek(t *testing.T) {
+	data := testData(5000)
+	stream := writeStream(t, data, 512)
+
+	r, err := NewReader(bytes.NewReader(stream), int64(len(stream)))
+	if err != nil {
+		t.Fatalf("NewReader() failed: %v", err)
+	}
+	defer r.Close()
+
+	if _, err := r.Seek(-100, io.SeekEnd); err != nil {
+		t.Fatalf("Seek() failed: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() failed: %v", err)
+	}
+	if !bytes.Equal(got, data[4900:]) {
+		t.Fatal("Read after SeekEnd returned wrong data")
+	}
+
+	if _, err := r.Seek(1000, io.SeekStart); err != nil {
+		t.Fatalf("Seek() failed: %v", err)
+	}
+	pos, err := r.Seek(24, io.SeekCurrent)
+	if err != nil || pos != 1024 {
+		t.Fatalf("Seek(24, SeekCurrent) = (%d, %v), want (1024, nil)", pos, err)
+	}
+	buf := make([]byte, 100)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatalf("ReadFull() failed: %v", err)
+	}
+	if !bytes.Equal(buf, data[1024:1124]) {
+		t.Fatal("Read after SeekCurrent returned wrong data")
+	}
+
+	if _, err := r.Seek(-1, io.SeekStart); err == nil {
+		t.Fatal("Seek() to a negative position should fail")
+	}
+}
+
+func TestInvalidStream(t *testing.T) {
+	stream := writeStream(t, testData(3000), 1000)
+
+	testCases := []struct {
+		name   string
+		stream []byte
+	}{
+		{name: "too short", stream: stream[:4]},
+		{name: "bad magic", stream: append(append([]byte{}, stream[:len(stream)-1]...), 0)},
+		{name: "truncated data", stream: stream[10:]},
+		{name: "huge frame count", stream: withUint32(stream, len(stream)-footerSize, 1<<30)},
+		{name: "huge compressed size", stream: withUint32(stream, len(stream)-footerSize-3*entrySize, 0xFFFFFFFF)},
+		{name: "huge decompressed size", stream: withUint32(stream, len(stream)-footerSize-3*entrySize+4, 0xFFFFFFFF)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewReader(bytes.NewReader(tc.stream), int64(len(tc.stream)))
+			if !errors.Is(err, ErrInvalidFormat) {
+				t.Fatalf("NewReader() = %v, want ErrInvalidFormat", err)
+			}
+		})
+	}
+}
+
+// withUint32 returns a copy of stream with the little-endian uint32 at off
+// set to v.
+func withUint32(stream []byte, off int, v uint32) []byte {
+	out := append([]byte{}, stream...)
+	binary.LittleEndian.PutUint32(out[off:], v)
+	return out
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	stream := writeStream(t, testData(3000), 1000)
+
+	// Corrupt the checksum of the first jump table entry.
+	tableStart := len(stream) - footerSize - 3*entrySize
+	stream[tableStart+8] ^= 0xFF
+
+	r, err := NewReader(bytes.NewReader(stream), int64(len(stream)))
+	if err != nil {
+		t.Fatalf("NewReader() failed: %v", err)
+	}
+	defer r.Close()
+
+	if _, err := r.ReadAt(make([]byte, 10), 0); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("ReadAt() = %v, want ErrChecksumMismatch", err)
+	}
+	// Other frames remain readable.
+	if _, err := r.ReadAt(make([]byte, 10), 1500); err != nil {
+		t.Fatalf("ReadAt() on intact frame failed: %v", err)
+	}
+}
+
+func BenchmarkReadAt(b *testing.B) {
+	data := testData(8 << 20)
+	var out bytes.Buffer
+	w, err := NewWriter(&out, 256<<10)
+	if err != nil {
+		b.Fatalf("NewWriter() failed: %v", err)
+	}
+	w.Write(data)
+	if err := w.Close(); err != nil {
+		b.Fatalf("Close() failed: %v", err)
+	}
+	stream := out.Bytes()
+
+	r, err := NewReader(bytes.NewReader(stream), int64(len(stream)))
+	if err != nil {
+		b.Fatalf("NewReader() failed: %v", err)
+	}
+	defer r.Close()
+
+	buf := make([]byte, 4096)
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		off := int64(i*7919*4096) % (int64(len(data)) - int64(len(buf)))
+		if _, err := r.ReadAt(buf, off); err != nil {
+			b.Fatalf("ReadAt() failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package seekable
+
+import (
+	"errors"
+	"io"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+// maxFrameSize bounds the frame size so that both the decompressed and the
+// compressed size of every frame fit in the jump table's uint32 fields.
+const maxFrameSize = 1 << 30
+
+// maxCompressedSize bounds the compressed size of a frame, leaving generous
+// room for frames of incompressible data to grow.
+const maxCompressedSize = maxFrameSize + maxFrameSize/8
+
+// Writer compresses data into a seekable stream.
+//
+// Data is buffered until a full frame has accumulated, then compressed as an
+// independent OpenZL frame and written to the underlying writer. Close must be
+// called to flush the final frame and write the jump table.
+//
+// Thread Safety: A Writer is not safe for concurrent use.
+type Writer struct {
+	w         io.Writer
+	ctx       *openzl.Context
+	frameSize int
+	buf       []byte
+	entries   []entry
+	err       error
+	closed    bool
+}
+
+// NewWriter creates a Writer that writes a seekable stream to w.
+//
+// frameSize is the number of decompressed bytes stored in each frame; smaller
+// frames make random access cheaper at the cost of compression ratio. A
+// non-positive frameSize selects DefaultFrameSize.
+func NewWriter(w io.Writer, frameSize int) (*Writer, error) {
+	if frameSize <= 0 {
+		frameSize = DefaultFrameSize
+	}
+	if frameSize > maxFrameSize {
+		return nil, errors.New("seekable: frame size too large")
+	}
+	ctx, err := openzl.NewContext()
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{
+		w:         w,
+		ctx:       ctx,
+		frameSize: frameSize,
+		buf:       make([]byte, 0, frameSize),
+	}, nil
+}
+
+// Write buffers p, compressing and writing out every completed frame.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("seekable: write to closed writer")
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+	written := 0
+	for len(p) > 0 {
+		n := w.frameSize - len(w.buf)
+		if n > len(p) {
+			n = len(p)
+		}
+		w.buf = append(w.buf, p[:n]...)
+		p = p[n:]
+		written += n
+		if len(w.buf) == w.frameSize {
+			if err := w.Flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// Flush ends the current frame early, compressing and writing any buffered
+// data. Flushing an empty buffer is a no-op.
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.buf) == 0 {
+		return nil
+	}
+	compressed, err := w.ctx.Compress(w.buf)
+	if err != nil {
+		w.err = err
+		return err
+	}
+	if len(compressed) > maxCompressedSize {
+		w.err = errors.New("seekable: compressed frame too large")
+		return w.err
+	}
+	if _, err := w.w.Write(compressed); err != nil {
+		w.err = err
+		return err
+	}
+	w.entries = append(w.entries, entry{
+		compressedSize:   uint32(len(compressed)),
+		decompressedSize: uint32(len(w.buf)),
+		checksum:         checksum(w.buf),
+	})
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// Close flushes the final frame, writes the jump table and releases the
+// compression context. It does not close the underlying writer.
+//
+// It is safe to call Close multiple times.
+func (w *Writer) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	defer w.ctx.Close()
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if _, err := w.w.Write(appendJumpTable(nil, w.entries)); err != nil {
+		w.err = err
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package openzl
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/gus3inov/openzl-go/internal/copenzl"
+)
+
+// Stats describes a single compression performed by CompressWithStats.
+//
+// Streams and Codecs are recorded with OpenZL's introspection hooks, like
+// CompressExplain, which gives the full tree. They stay zero when the library
+// is built without introspection support, and for empty input.
+type Stats struct {
+	InputSize  int            // Uncompressed size in bytes
+	OutputSize int            // Compressed frame size in bytes
+	Elapsed    time.Duration  // Time spent compressing
+	Graph      Graph          // Graph compression started with
+	Level      int            // Compression level; 0 is the library default
+	Streams    int            // Streams stored in the frame
+	Codecs     map[string]int // Number of times each codec ran, by name
+}
+
+// Ratio returns InputSize divided by OutputSize, or 0 if the output is empty.
+func (s Stats) Ratio() float64 {
+	if s.OutputSize == 0 {
+		return 0
+	}
+	return float64(s.InputSize) / float64(s.OutputSize)
+}
+
+// CompressWithStats compresses data like Compress and also reports statistics
+// about the compression.
+func (c *Context) CompressWithStats(data []byte) ([]byte, Stats, error) {
+	if c.ctx == nil {
+		return nil, Stats{}, &Error{Code: -1, Message: "context is closed"}
+	}
+	ob := observe(c.observer, context.Background(), OpCompress)
+	start := time.Now()
+	out, nodes, err := copenzl.OpenZLCompressExplain(c.ctx, data)
+	if err == copenzl.ErrNoIntrospection {
+		// Nothing was compressed; do without the breakdown.
+		out, err = copenzl.OpenZLCompress(c.ctx, data)
+	}
+	elapsed := time.Since(start)
+	stats := Stats{
+		InputSize:  len(data),
+		OutputSize: len(out),
+		Elapsed:    elapsed,
+		Graph:      c.graph,
+		Level:      c.level,
+	}
+	stats.Streams, stats.Codecs = countNodes(nodes)
+	c.counters.compressed(len(data), len(out), elapsed, err)
+	ob.add(len(data), len(out))
+	ob.end(err)
+	if err != nil {
+		return nil, Stats{}, err
+	}
+	return out, stats, nil
+}
+
+// countNodes returns the number of streams stored in a frame, those no codec
+// processed further, and how often each codec ran, from the nodes recorded by
+// OpenZLCompressExplain. It returns 0 and nil if nothing was recorded.
+func countNodes(nodes []copenzl.ExplainNode) (streams int, codecs map[string]int) {
+	if len(nodes) == 0 {
+		return 0, nil
+	}
+	codecs = make(map[string]int)
+	// Parents are recorded before their children, so walking backwards
+	// visits every node after all of its descendants.
+	encoded := make([]bool, len(nodes))
+	for i := len(nodes) - 1; i >= 0; i-- {
+		n := nodes[i]
+		switch n.Kind {
+		case copenzl.ExplainCodec:
+			codecs[n.Name]++
+			encoded[i] = true
+		case copenzl.ExplainStream:
+			if !encoded[i] {
+				streams++
+			}
+		}
+		if encoded[i] && n.Parent >= 0 && n.Parent < i {
+			encoded[n.Parent] = true
+		}
+	}
+	return streams, codecs
+}
+
+// Counters are cumulative totals over every operation performed with a
+// Context, including typed compression and decompression.
+type Counters struct {
+	Compressions   int64         // Successful compressions
+	Decompressions int64         // Successful decompressions
+	Errors         int64         // Failed compressions and decompressions
+	BytesIn        int64         // Uncompressed bytes compressed
+	BytesOut       int64         // Compressed bytes produced
+	BytesDecoded   int64         // Compressed bytes decompressed
+	BytesRestored  int64         // Uncompressed bytes produced by decompression
+	CompressTime   time.Duration // Total time spent compressing
+	DecompressTime time.Duration // Total time spent decompressing
+}
+
+// Ratio returns BytesIn divided by BytesOut, or 0 if nothing was compressed.
+func (c Counters) Ratio() float64 {
+	if c.BytesOut == 0 {
+		return 0
+	}
+	return float64(c.BytesIn) / float64(c.BytesOut)
+}
+
+// Counters returns the cumulative counters of the context.
+//
+// Unlike other Context methods, Counters and ResetCounters may be called
+// concurrently with operations on the context, so a monitoring goroutine can
+// sample them. They remain available after Close.
+func (c *Context) Counters() Counters {
+	return c.counters.snapshot()
+}
+
+// ResetCounters sets the cumulative counters of the context to zero.
+func (c *Context) ResetCounters() {
+	c.counters.reset()
+}
+
+// counters is the concurrency-safe storage behind Counters.
+type counters struct {
+	compressions   atomic.Int64
+	decompressions atomic.Int64
+	errors         atomic.Int64
+	bytesIn        atomic.Int64
+	bytesOut       atomic.Int64
+	bytesDecoded   atomic.Int64
+	bytesRestored  atomic.Int64
+	compressTime   atomic.Int64
+	decompressTime atomic.Int64
+}
+
+func (c *counters) compressed(in, out int, elapsed time.Duration, err error) {
+	c.compressedN(1, in, out, elapsed, err)
+}
+
+// compressedN records n compressions performed together, as by
+// CompressBatch. A failed batch counts as one error.
+func (c *counters) compressedN(n, in, out int, elapsed time.Duration, err error) {
+	if err != nil {
+		c.errors.Add(1)
+		return
+	}
+	c.compressions.Add(int64(n))
+	c.bytesIn.Add(int64(in))
+	c.bytesOut.Add(int64(out))
+	c.compressTime.Add(int64(elapsed))
+}
+
+func (c *counters) decompressed(in, out int, elapsed time.Duration, err error) {
+	c.decompressedN(1, in, out, elapsed, err)
+}
+
+// decompressedN records n decompressions performed together.
+func (c *counters) decompressedN(n, in, out int, elapsed time.Duration, err error) {
+	if err != nil {
+		c.errors.Add(1)
+		return
+	}
+	c.decompressions.Add(int64(n))
+	c.bytesDecoded.Add(int64(in))
+	c.bytesRestored.Add(int64(out))
+	c.decompressTime.Add(int64(elapsed))
+}
+
+func (c *counters) snapshot() Counters {
+	return Counters{
+		Compressions:   c.compressions.Load(),
+		Decompressions: c.decompressions.Load(),
+		Errors:         c.errors.Load(),
+		BytesIn:        c.bytesIn.Load(),
+		BytesOut:       c.bytesOut.Load(),
+		BytesDecoded:   c.bytesDecoded.Load(),
+		BytesRestored:  c.bytesRestored.Load(),
+		CompressTime:   time.Duration(c.compressTime.Load()),
+		DecompressTime: time.Duration(c.decompressTime.Load()),
+	}
+}
+
+func (c *counters) reset() {
+	for _, v := range []*atomic.Int64{
+		&c.compressions, &c.decompressions, &c.errors,
+		&c.bytesIn, &c.bytesOut, &c.bytesDecoded, &c.bytesRestored,
+		&c.compressTime, &c.decompressTime,
+	} {
+		v.Store(0)
+	}
+}
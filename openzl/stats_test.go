@@ -0,0 +1,135 @@
+package openzl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gus3inov/openzl-go/internal/copenzl"
+)
+
+func TestCompressWithStats(t *testing.T) {
+	ctx, err := NewContext(WithGraph(GraphZstd), WithLevel(3))
+	if err != nil {
+		t.Fatalf("NewContext() failed: %v", err)
+	}
+	defer ctx.Close()
+
+	data := bytes.Repeat([]byte("statistics are reported alongside the frame "), 500)
+	compressed, stats, err := ctx.CompressWithStats(data)
+	if err != nil {
+		t.Fatalf("CompressWithStats() failed: %v", err)
+	}
+	if stats.InputSize != len(data) || stats.OutputSize != len(compressed) {
+		t.Fatalf("Stats sizes = %d/%d, want %d/%d", stats.InputSize, stats.OutputSize, len(data), len(compressed))
+	}
+	if stats.Graph != GraphZstd || stats.Level != 3 {
+		t.Fatalf("Stats graph/level = %s/%d, want zstd/3", stats.Graph, stats.Level)
+	}
+	if stats.Codecs != nil {
+		ran := 0
+		for _, n := range stats.Codecs {
+			ran += n
+		}
+		if stats.Streams < 1 || ran < 1 {
+			t.Fatalf("Stats reports %d streams and %d codec runs, want at least one each", stats.Streams, ran)
+		}
+	}
+	if stats.Ratio() <= 1 {
+		t.Fatalf("Stats.Ratio() = %.2f, want > 1 for repetitive data", stats.Ratio())
+	}
+
+	decompressed, err := ctx.Decompress(compressed)
+	if err != nil || !bytes.Equal(decompressed, data) {
+		t.Fatalf("Decompress() of CompressWithStats output failed: %v", err)
+	}
+
+	_, stats, err = ctx.CompressWithStats(nil)
+	if err != nil {
+		t.Fatalf("CompressWithStats(nil) failed: %v", err)
+	}
+	if stats.Streams != 0 || stats.Codecs != nil || stats.Ratio() != 0 {
+		t.Fatalf("CompressWithStats(nil) = %+v, want no streams", stats)
+	}
+
+	ctx.Close()
+	if _, _, err := ctx.CompressWithStats(data); err == nil || err.Error() != "context is closed" {
+		t.Fatalf("CompressWithStats() on closed context = %v, want 'context is closed'", err)
+	}
+}
+
+func TestCountNodes(t *testing.T) {
+	// A graph runs a codec producing two streams; the first is stored, the
+	// second goes through a graph running another codec, whose stream goes
+	// through a graph that stores it without running a codec.
+	nodes := []copenzl.ExplainNode{
+		{Kind: copenzl.ExplainGraph, Parent: -1, Name: "zl.generic"},
+		{Kind: copenzl.ExplainCodec, Parent: 0, Name: "zl.tokenize"},
+		{Kind: copenzl.ExplainStream, Parent: 1},
+		{Kind: copenzl.ExplainStream, Parent: 1},
+		{Kind: copenzl.ExplainGraph, Parent: 3, Name: "zl.compress"},
+		{Kind: copenzl.ExplainCodec, Parent: 4, Name: "zl.huffman"},
+		{Kind: copenzl.ExplainStream, Parent: 5},
+		{Kind: copenzl.ExplainGraph, Parent: 6, Name: "zl.store"},
+	}
+	streams, codecs := countNodes(nodes)
+	if streams != 2 {
+		t.Errorf("countNodes() = %d streams, want 2", streams)
+	}
+	if len(codecs) != 2 || codecs["zl.tokenize"] != 1 || codecs["zl.huffman"] != 1 {
+		t.Errorf("countNodes() codecs = %v", codecs)
+	}
+	if streams, codecs := countNodes(nil); streams != 0 || codecs != nil {
+		t.Errorf("countNodes(nil) = %d, %v, want 0, nil", streams, codecs)
+	}
+}
+
+func TestCounters(t *testing.T) {
+	ctx, err := NewContext()
+	if err != nil {
+		t.Fatalf("NewContext() failed: %v", err)
+	}
+	defer ctx.Close()
+
+	data := bytes.Repeat([]byte("per-context counters "), 200)
+	var frameBytes int64
+	for i := 0; i < 3; i++ {
+		compressed, err := ctx.Compress(data)
+		if err != nil {
+			t.Fatalf("Compress() failed: %v", err)
+		}
+		frameBytes += int64(len(compressed))
+		if _, err := ctx.Decompress(compressed); err != nil {
+			t.Fatalf("Decompress() failed: %v", err)
+		}
+	}
+	if _, err := ctx.Decompress([]byte("not a frame")); err == nil {
+		t.Fatal("Decompress() of garbage should fail")
+	}
+
+	c := ctx.Counters()
+	want := Counters{
+		Compressions:   3,
+		Decompressions: 3,
+		Errors:         1,
+		BytesIn:        3 * int64(len(data)),
+		BytesOut:       frameBytes,
+		BytesDecoded:   frameBytes,
+		BytesRestored:  3 * int64(len(data)),
+		CompressTime:   c.CompressTime,
+		DecompressTime: c.DecompressTime,
+	}
+	if c != want {
+		t.Fatalf("Counters() = %+v, want %+v", c, want)
+	}
+	if c.CompressTime <= 0 || c.DecompressTime <= 0 {
+		t.Fatalf("Counters() times = %v/%v, want > 0", c.CompressTime, c.DecompressTime)
+	}
+	if c.Ratio() <= 1 {
+		t.Fatalf("Counters.Ratio() = %.2f, want > 1", c.Ratio())
+	}
+
+	ctx.ResetCounters()
+	if c := ctx.Counters(); c != (Counters{}) {
+		t.Fatalf("Counters() after ResetCounters() = %+v, want zero", c)
+	}
+}
@@ -0,0 +1,674 @@
+package openzl
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	"github.com/gus3inov/openzl-go/internal/copenzl"
+)
+
+// Stream format
+//
+// Streams produced by Writer and CompressParallel are a sequence of
+// independent OpenZL frames, each preceded by a block header:
+//
+//	magic "OZLF" | version (1 byte)
+//	compressed size (uint32 LE) | uncompressed size (uint32 LE) | frame
+//	...
+//	end marker (8 zero bytes)
+//
+// Because every frame is independent, blocks can be compressed and
+// decompressed concurrently while still being written and read in order.
+
+var streamMagic = [4]byte{'O', 'Z', 'L', 'F'}
+
+const (
+	streamVersion    = 1
+	streamHeaderSize = len(streamMagic) + 1
+	blockHeaderSize  = 8
+)
+
+// maxFrameSize bounds the compressed size of a block, leaving generous room
+// for the worst-case expansion of a maxChunkSize chunk. Together with the
+// maxChunkSize bound on the uncompressed size it keeps a crafted block header
+// from triggering huge allocations.
+const maxFrameSize = maxChunkSize + maxChunkSize/8
+
+// ErrInvalidStream is returned when stream data is not in the format written
+// by Writer or CompressParallel, or is truncated or corrupted.
+var ErrInvalidStream = &Error{Code: -1, Message: "invalid stream"}
+
+var errWriterClosed = &Error{Code: -1, Message: "writer is closed"}
+var errReaderClosed = &Error{Code: -1, Message: "reader is closed"}
+
+func appendStreamHeader(dst []byte) []byte {
+	dst = append(dst, streamMagic[:]...)
+	return append(dst, streamVersion)
+}
+
+func appendBlockHeader(dst []byte, compressedSize, rawSize int) []byte {
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(compressedSize))
+	return binary.LittleEndian.AppendUint32(dst, uint32(rawSize))
+}
+
+// IsStream reports whether prefix, the start of some data, begins with the
+// header of a stream written by Writer or CompressParallel. The header is
+// shorter than 16 bytes.
+func IsStream(prefix []byte) bool {
+	return checkStreamHeader(prefix) == nil
+}
+
+func checkStreamHeader(p []byte) error {
+	if len(p) < streamHeaderSize ||
+		[4]byte(p[:4]) != streamMagic ||
+		p[4] != streamVersion {
+		return ErrInvalidStream
+	}
+	return nil
+}
+
+// checkBlockSizes validates the sizes in a block header before anything is
+// allocated for the block.
+func checkBlockSizes(compressedSize, rawSize uint32) error {
+	if compressedSize > maxFrameSize || rawSize > maxChunkSize {
+		return ErrInvalidStream
+	}
+	return nil
+}
+
+// decompressBlock decompresses the frame of a block, checking that the frame
+// header agrees with the block header before the output is allocated.
+func decompressBlock(ctx *Context, frame []byte, rawSize int) ([]byte, error) {
+	size, err := DecompressedSize(frame)
+	if err != nil {
+		return nil, err
+	}
+	if size != rawSize {
+		return nil, ErrInvalidStream
+	}
+	data, err := ctx.Decompress(frame)
+	if err == nil && len(data) != rawSize {
+		err = ErrInvalidStream
+	}
+	return data, err
+}
+
+// readBlock reads the next block from r. It returns io.EOF when the end
+// marker is reached.
+func readBlock(r io.Reader) (frame []byte, rawSize int, err error) {
+	var hdr [blockHeaderSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, 0, err
+	}
+	compressedSize := binary.LittleEndian.Uint32(hdr[0:])
+	rawSize = int(binary.LittleEndian.Uint32(hdr[4:]))
+	if err := checkBlockSizes(compressedSize, uint32(rawSize)); err != nil {
+		return nil, 0, err
+	}
+	if compressedSize == 0 {
+		if rawSize != 0 {
+			return nil, 0, ErrInvalidStream
+		}
+		return nil, 0, io.EOF
+	}
+	frame = make([]byte, compressedSize)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, 0, err
+	}
+	return frame, rawSize, nil
+}
+
+// chunk is a unit of work handed between the caller, the worker goroutines
+// and the ordered output stage in concurrent mode.
+type chunk struct {
+	src     []byte
+	dst     []byte
+	rawSize int
+	err     error
+	ready   chan struct{}
+}
+
+// Writer compresses data written to it into the OpenZL stream format.
+//
+// Input is split into chunks (see WithChunkSize), each compressed as an
+// independent frame. With WithConcurrency(n) for n > 1, chunks are compressed
+// on n goroutines, each owning its own Context, while frames are still written
+// to the underlying writer in order.
+//
+// Close must be called to flush buffered data and write the end marker.
+//
+// Thread Safety: A Writer is not safe for concurrent use.
+type Writer struct {
+	w           io.Writer
+	o           options
+	cctx        context.Context // checked between chunks
+	progress    *progress
+	ob          *observation
+	buf         []byte
+	closed      bool
+	wroteHeader bool
+
+	mu  sync.Mutex // guards err in concurrent mode
+	err error
+
+	// Sequential mode.
+	ctx *Context
+
+	// Concurrent mode.
+	jobs     chan *chunk
+	queue    chan *chunk
+	inflight sync.WaitGroup
+	workers  sync.WaitGroup
+	done     chan struct{}
+}
+
+// NewWriter returns a Writer that compresses data into w.
+//
+// Each Writer owns its Contexts, or borrows them from the Pool given with
+// WithPool; they are released by Close.
+func NewWriter(w io.Writer, opts ...Option) (*Writer, error) {
+	return NewWriterContext(context.Background(), w, opts...)
+}
+
+// NewWriterContext is like NewWriter but the Writer stops compressing once
+// ctx is done: the next chunk is not compressed, and Write, Flush and Close
+// return ctx.Err(). A chunk already being compressed runs to completion, and
+// the Writer's contexts are released by Close as usual.
+func NewWriterContext(ctx context.Context, w io.Writer, opts ...Option) (*Writer, error) {
+	o := newOptions(opts)
+	zw := &Writer{
+		w:        w,
+		o:        o,
+		cctx:     ctx,
+		progress: newProgress(o, false),
+		buf:      make([]byte, 0, o.chunkSize),
+	}
+
+	if o.concurrency <= 1 {
+		ctx, err := o.acquire(copenzl.ModeCompress)
+		if err != nil {
+			return nil, err
+		}
+		zw.ctx = ctx
+		zw.ob = observe(o.observer, zw.cctx, OpWrite)
+		return zw, nil
+	}
+
+	ctxs, err := newContexts(o, copenzl.ModeCompress)
+	if err != nil {
+		return nil, err
+	}
+	zw.jobs = make(chan *chunk, o.concurrency)
+	zw.queue = make(chan *chunk, o.concurrency)
+	zw.done = make(chan struct{})
+	for _, ctx := range ctxs {
+		zw.workers.Add(1)
+		go zw.compressLoop(ctx)
+	}
+	zw.ob = observe(o.observer, zw.cctx, OpWrite)
+	go zw.writeLoop()
+	return zw, nil
+}
+
+// newContexts acquires one context per unit of concurrency, releasing all of
+// them if any fails.
+func newContexts(o options, mode int) ([]*Context, error) {
+	ctxs := make([]*Context, 0, o.concurrency)
+	for i := 0; i < o.concurrency; i++ {
+		ctx, err := o.acquire(mode)
+		if err != nil {
+			for _, c := range ctxs {
+				o.release(c)
+			}
+			return nil, err
+		}
+		ctxs = append(ctxs, ctx)
+	}
+	return ctxs, nil
+}
+
+func (w *Writer) compressLoop(ctx *Context) {
+	defer w.workers.Done()
+	defer w.o.release(ctx)
+	for c := range w.jobs {
+		if c.err = w.cctx.Err(); c.err == nil {
+			c.dst, c.err = ctx.Compress(c.src)
+		}
+		close(c.ready)
+	}
+}
+
+// writeLoop writes compressed chunks in submission order.
+func (w *Writer) writeLoop() {
+	defer close(w.done)
+	for c := range w.queue {
+		<-c.ready
+		if w.getErr() == nil {
+			err := c.err
+			if err == nil {
+				err = w.writeBlock(c.dst, c.rawSize)
+			}
+			if err != nil {
+				w.setErr(err)
+			}
+		}
+		w.inflight.Done()
+	}
+}
+
+func (w *Writer) getErr() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+func (w *Writer) setErr(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// writeBlock writes a block, preceded by the stream header if this is the
+// first output. It is only called from one goroutine at a time.
+func (w *Writer) writeBlock(frame []byte, rawSize int) error {
+	hdr := make([]byte, 0, streamHeaderSize+blockHeaderSize)
+	if !w.wroteHeader {
+		hdr = appendStreamHeader(hdr)
+		w.wroteHeader = true
+	}
+	hdr = appendBlockHeader(hdr, len(frame), rawSize)
+	if _, err := w.w.Write(hdr); err != nil {
+		return err
+	}
+	if _, err := w.w.Write(frame); err != nil {
+		return err
+	}
+	w.progress.add(rawSize, len(hdr)+len(frame))
+	w.ob.add(rawSize, len(hdr)+len(frame))
+	return nil
+}
+
+// Write compresses p, buffering input until a full chunk is available.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errWriterClosed
+	}
+	if err := w.getErr(); err != nil {
+		return 0, err
+	}
+	written := 0
+	for len(p) > 0 {
+		n := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+		if len(w.buf) == cap(w.buf) {
+			if err := w.emit(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// emit compresses the buffered chunk, or hands it to the workers in
+// concurrent mode.
+func (w *Writer) emit() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	if err := w.cctx.Err(); err != nil {
+		w.setErr(err)
+		return err
+	}
+	if w.ctx != nil {
+		frame, err := w.ctx.Compress(w.buf)
+		if err == nil {
+			err = w.writeBlock(frame, len(w.buf))
+		}
+		if err != nil {
+			w.setErr(err)
+			return err
+		}
+		w.buf = w.buf[:0]
+		return nil
+	}
+
+	c := &chunk{src: w.buf, rawSize: len(w.buf), ready: make(chan struct{})}
+	w.inflight.Add(1)
+	w.queue <- c
+	w.jobs <- c
+	w.buf = make([]byte, 0, w.o.chunkSize)
+	return w.getErr()
+}
+
+// Flush compresses any buffered data and waits until every pending frame has
+// been written to the underlying writer.
+func (w *Writer) Flush() error {
+	if w.closed {
+		return errWriterClosed
+	}
+	if err := w.getErr(); err != nil {
+		return err
+	}
+	if err := w.cctx.Err(); err != nil {
+		w.setErr(err)
+		return err
+	}
+	if err := w.emit(); err != nil {
+		return err
+	}
+	if w.ctx == nil {
+		w.inflight.Wait()
+	}
+	return w.getErr()
+}
+
+// Close flushes buffered data, writes the end marker and releases the
+// Writer's contexts. It does not close the underlying writer.
+//
+// It is safe to call Close multiple times.
+func (w *Writer) Close() error {
+	if w.closed {
+		return w.getErr()
+	}
+	err := w.Flush()
+	w.closed = true
+
+	if w.ctx != nil {
+		w.o.release(w.ctx)
+	} else {
+		close(w.jobs)
+		close(w.queue)
+		w.workers.Wait()
+		<-w.done
+	}
+
+	if err != nil {
+		w.ob.end(err)
+		return err
+	}
+	var tail []byte
+	if !w.wroteHeader {
+		tail = appendStreamHeader(tail)
+		w.wroteHeader = true
+	}
+	tail = appendBlockHeader(tail, 0, 0)
+	if _, err := w.w.Write(tail); err != nil {
+		w.setErr(err)
+		w.ob.end(err)
+		return err
+	}
+	w.progress.finish(0, len(tail))
+	w.ob.add(0, len(tail))
+	w.ob.end(nil)
+	return nil
+}
+
+// Reader decompresses a stream produced by Writer or CompressParallel.
+//
+// With WithConcurrency(n) for n > 1, up to n frames are read ahead and
+// decompressed concurrently, each on a goroutine owning its own Context.
+//
+// Thread Safety: A Reader is not safe for concurrent use.
+type Reader struct {
+	r        io.Reader
+	o        options
+	cctx     context.Context // checked between frames
+	progress *progress
+	ob       *observation
+	buf      []byte // decompressed data not yet returned
+	err      error
+	closed   bool
+
+	// Sequential mode.
+	ctx *Context
+
+	// Concurrent mode.
+	queue chan *chunk
+	quit  chan struct{}
+}
+
+// NewReader reads the stream header from r and returns a Reader that
+// decompresses the rest of the stream.
+func NewReader(r io.Reader, opts ...Option) (*Reader, error) {
+	return NewReaderContext(context.Background(), r, opts...)
+}
+
+// NewReaderContext is like NewReader but the Reader stops decompressing once
+// ctx is done: Read returns ctx.Err() instead of the next frame. A frame
+// already being decompressed runs to completion.
+func NewReaderContext(ctx context.Context, r io.Reader, opts ...Option) (*Reader, error) {
+	o := newOptions(opts)
+
+	hdr := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrInvalidStream
+		}
+		return nil, err
+	}
+	if err := checkStreamHeader(hdr); err != nil {
+		return nil, err
+	}
+
+	zr := &Reader{r: r, o: o, cctx: ctx, progress: newProgress(o, true)}
+	if zr.progress != nil {
+		zr.progress.p.BytesIn = int64(streamHeaderSize)
+	}
+	if o.concurrency <= 1 {
+		ctx, err := o.acquire(copenzl.ModeDecompress)
+		if err != nil {
+			return nil, err
+		}
+		zr.ctx = ctx
+		zr.ob = observeRead(o, zr.cctx)
+		return zr, nil
+	}
+
+	ctxs, err := newContexts(o, copenzl.ModeDecompress)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make(chan *chunk, o.concurrency)
+	zr.queue = make(chan *chunk, o.concurrency)
+	zr.quit = make(chan struct{})
+	for _, ctx := range ctxs {
+		go zr.decompressLoop(ctx, jobs)
+	}
+	go zr.readLoop(jobs)
+	zr.ob = observeRead(o, zr.cctx)
+	return zr, nil
+}
+
+// observeRead starts observing a stream whose header has been read.
+func observeRead(o options, ctx context.Context) *observation {
+	ob := observe(o.observer, ctx, OpRead)
+	ob.add(streamHeaderSize, 0)
+	return ob
+}
+
+func (r *Reader) decompressLoop(ctx *Context, jobs <-chan *chunk) {
+	defer r.o.release(ctx)
+	for c := range jobs {
+		if c.err = r.cctx.Err(); c.err == nil {
+			c.dst, c.err = decompressBlock(ctx, c.src, c.rawSize)
+		}
+		close(c.ready)
+	}
+}
+
+// readLoop reads frames from the underlying reader and queues them, in order,
+// for decompression. A final chunk carrying io.EOF or the read error
+// terminates the queue.
+func (r *Reader) readLoop(jobs chan<- *chunk) {
+	defer close(jobs)
+	for {
+		frame, rawSize, err := readBlock(r.r)
+		c := &chunk{src: frame, rawSize: rawSize, err: err, ready: make(chan struct{})}
+		if err != nil {
+			close(c.ready)
+		}
+		select {
+		case r.queue <- c:
+		case <-r.quit:
+			return
+		case <-r.cctx.Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+		select {
+		case jobs <- c:
+		case <-r.quit:
+			return
+		case <-r.cctx.Done():
+			return
+		}
+	}
+}
+
+// Read reads decompressed data into p.
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.closed {
+		return 0, errReaderClosed
+	}
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.next()
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// next loads the next decompressed frame into r.buf or sets r.err.
+func (r *Reader) next() {
+	if err := r.cctx.Err(); err != nil {
+		r.err = err
+		return
+	}
+	var frameSize int
+	if r.ctx != nil {
+		frame, rawSize, err := readBlock(r.r)
+		if err == nil {
+			r.buf, err = decompressBlock(r.ctx, frame, rawSize)
+		}
+		r.err, frameSize = err, len(frame)
+	} else {
+		var c *chunk
+		select {
+		case c = <-r.queue:
+		case <-r.cctx.Done():
+			r.err = r.cctx.Err()
+			return
+		}
+		<-c.ready
+		r.buf, r.err, frameSize = c.dst, c.err, len(c.src)
+	}
+
+	switch r.err {
+	case nil:
+		r.progress.add(blockHeaderSize+frameSize, len(r.buf))
+		r.ob.add(blockHeaderSize+frameSize, len(r.buf))
+	case io.EOF:
+		r.progress.finish(blockHeaderSize, 0)
+		r.ob.add(blockHeaderSize, 0)
+		r.ob.end(nil)
+	default:
+		r.ob.end(r.err)
+	}
+}
+
+// Close releases the Reader's contexts. It does not close the underlying
+// reader. It is safe to call Close multiple times.
+func (r *Reader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	r.buf = nil
+	r.ob.end(nil) // the stream was abandoned before its end
+	if r.ctx != nil {
+		r.o.release(r.ctx)
+		return nil
+	}
+	close(r.quit)
+	return nil
+}
+
+// BlockInfo describes a single frame of a stream.
+type BlockInfo struct {
+	CompressedSize   int // Size of the OpenZL frame, excluding the block header
+	DecompressedSize int // Size of the frame's decompressed data
+}
+
+// StreamInfo describes a stream without decompressing it.
+type StreamInfo struct {
+	Version          int         // Stream format version
+	Blocks           []BlockInfo // Frames in stream order
+	CompressedSize   int64       // Total stream size, including headers
+	DecompressedSize int64       // Total decompressed size
+}
+
+// InspectStream reads the headers of the stream in r and returns its layout.
+// Frames are skipped, not decompressed.
+func InspectStream(r io.Reader) (*StreamInfo, error) {
+	hdr := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrInvalidStream
+		}
+		return nil, err
+	}
+	if err := checkStreamHeader(hdr); err != nil {
+		return nil, err
+	}
+
+	info := &StreamInfo{Version: int(hdr[4]), CompressedSize: int64(streamHeaderSize)}
+	var bh [blockHeaderSize]byte
+	for {
+		if _, err := io.ReadFull(r, bh[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil, ErrInvalidStream
+			}
+			return nil, err
+		}
+		info.CompressedSize += blockHeaderSize
+		compressedSize := binary.LittleEndian.Uint32(bh[0:])
+		rawSize := binary.LittleEndian.Uint32(bh[4:])
+		if err := checkBlockSizes(compressedSize, rawSize); err != nil {
+			return nil, err
+		}
+		b := BlockInfo{CompressedSize: int(compressedSize), DecompressedSize: int(rawSize)}
+		if b.CompressedSize == 0 {
+			if b.DecompressedSize != 0 {
+				return nil, ErrInvalidStream
+			}
+			return info, nil
+		}
+		n, err := io.CopyN(io.Discard, r, int64(b.CompressedSize))
+		if err != nil {
+			if err == io.EOF {
+				return nil, ErrInvalidStream
+			}
+			return nil, err
+		}
+		info.CompressedSize += n
+		info.DecompressedSize += int64(b.DecompressedSize)
+		info.Blocks = append(info.Blocks, b)
+	}
+}
@@ -0,0 +1,447 @@
+package openzl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func streamTestData(n int) []byte {
+	var buf bytes.Buffer
+	for i := 0; buf.Len() < n; i++ {
+		fmt.Fprintf(&buf, "line %06d: OpenZL streaming test data\n", i)
+	}
+	return buf.Bytes()[:n]
+}
+
+func TestWriterReaderRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name        string
+		size        int
+		concurrency int
+	}{
+		{name: "empty sequential", size: 0, concurrency: 1},
+		{name: "empty concurrent", size: 0, concurrency: 4},
+		{name: "sub-chunk sequential", size: 100, concurrency: 1},
+		{name: "multi-chunk sequential", size: 10000, concurrency: 1},
+		{name: "multi-chunk concurrent", size: 10000, concurrency: 4},
+		{name: "exact chunks concurrent", size: 8192, concurrency: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := streamTestData(tc.size)
+
+			var stream bytes.Buffer
+			w, err := NewWriter(&stream, WithChunkSize(1024), WithConcurrency(tc.concurrency))
+			if err != nil {
+				t.Fatalf("NewWriter() failed: %v", err)
+			}
+			// Write in uneven pieces to exercise chunk boundaries.
+			for p := data; len(p) > 0; {
+				n := min(333, len(p))
+				if _, err := w.Write(p[:n]); err != nil {
+					t.Fatalf("Write() failed: %v", err)
+				}
+				p = p[n:]
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() failed: %v", err)
+			}
+
+			r, err := NewReader(bytes.NewReader(stream.Bytes()), WithConcurrency(tc.concurrency))
+			if err != nil {
+				t.Fatalf("NewReader() failed: %v", err)
+			}
+			defer r.Close()
+
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("ReadAll() failed: %v", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatal("Data integrity check failed")
+			}
+		})
+	}
+}
+
+func TestWriterFlush(t *testing.T) {
+	var stream bytes.Buffer
+	w, err := NewWriter(&stream, WithConcurrency(2))
+	if err != nil {
+		t.Fatalf("NewWriter() failed: %v", err)
+	}
+	defer w.Close()
+
+	if _, err := w.Write([]byte("flushed data")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() failed: %v", err)
+	}
+	if stream.Len() <= streamHeaderSize+blockHeaderSize {
+		t.Fatalf("Flush() did not write a frame, stream has %d bytes", stream.Len())
+	}
+}
+
+func TestWriterClosed(t *testing.T) {
+	w, err := NewWriter(io.Discard)
+	if err != nil {
+		t.Fatalf("NewWriter() failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Double Close() failed: %v", err)
+	}
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Fatal("Write() after Close() should fail")
+	}
+}
+
+func TestCompressParallel(t *testing.T) {
+	data := streamTestData(100000)
+
+	compressed, err := CompressParallel(data, WithChunkSize(4096), WithConcurrency(4))
+	if err != nil {
+		t.Fatalf("CompressParallel() failed: %v", err)
+	}
+
+	decompressed, err := DecompressParallel(compressed, WithConcurrency(4))
+	if err != nil {
+		t.Fatalf("DecompressParallel() failed: %v", err)
+	}
+	if !bytes.Equal(decompressed, data) {
+		t.Fatal("Data integrity check failed")
+	}
+
+	// The parallel output is an ordinary stream.
+	r, err := NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("NewReader() failed: %v", err)
+	}
+	defer r.Close()
+	streamed, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() failed: %v", err)
+	}
+	if !bytes.Equal(streamed, data) {
+		t.Fatal("Reader returned different data than DecompressParallel")
+	}
+}
+
+func TestCompressParallelEmpty(t *testing.T) {
+	compressed, err := CompressParallel(nil)
+	if err != nil {
+		t.Fatalf("CompressParallel(nil) failed: %v", err)
+	}
+	decompressed, err := DecompressParallel(compressed)
+	if err != nil {
+		t.Fatalf("DecompressParallel() failed: %v", err)
+	}
+	if len(decompressed) != 0 {
+		t.Fatalf("DecompressParallel() should return empty result, got %d bytes", len(decompressed))
+	}
+}
+
+func TestIsStream(t *testing.T) {
+	stream, err := CompressParallel([]byte("is this a stream?"))
+	if err != nil {
+		t.Fatalf("CompressParallel() failed: %v", err)
+	}
+	if !IsStream(stream) || !IsStream(stream[:16]) {
+		t.Error("IsStream() = false for a stream")
+	}
+	ctx, err := NewContext()
+	if err != nil {
+		t.Fatalf("NewContext() failed: %v", err)
+	}
+	defer ctx.Close()
+	frame, err := ctx.Compress([]byte("a bare frame"))
+	if err != nil {
+		t.Fatalf("Compress() failed: %v", err)
+	}
+	for _, p := range [][]byte{nil, stream[:3], frame} {
+		if IsStream(p) {
+			t.Errorf("IsStream(%q) = true", p)
+		}
+	}
+}
+
+func TestInvalidStream(t *testing.T) {
+	data := streamTestData(5000)
+	compressed, err := CompressParallel(data, WithChunkSize(1024))
+	if err != nil {
+		t.Fatalf("CompressParallel() failed: %v", err)
+	}
+
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "bad magic", data: append([]byte("XXXX"), compressed[4:]...)},
+		{name: "truncated", data: compressed[:len(compressed)-20]},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := DecompressParallel(tc.data); err == nil {
+				t.Fatal("DecompressParallel() should fail")
+			}
+
+			r, err := NewReader(bytes.NewReader(tc.data))
+			if err != nil {
+				return
+			}
+			defer r.Close()
+			if _, err := io.ReadAll(r); err == nil {
+				t.Fatal("ReadAll() should fail")
+			}
+		})
+	}
+}
+
+func TestInspectStream(t *testing.T) {
+	data := streamTestData(5000)
+	compressed, err := CompressParallel(data, WithChunkSize(2048))
+	if err != nil {
+		t.Fatalf("CompressParallel() failed: %v", err)
+	}
+
+	info, err := InspectStream(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("InspectStream() failed: %v", err)
+	}
+	if len(info.Blocks) != 3 {
+		t.Fatalf("InspectStream() found %d blocks, want 3", len(info.Blocks))
+	}
+	if info.DecompressedSize != int64(len(data)) {
+		t.Fatalf("DecompressedSize = %d, want %d", info.DecompressedSize, len(data))
+	}
+	if info.CompressedSize != int64(len(compressed)) {
+		t.Fatalf("CompressedSize = %d, want %d", info.CompressedSize, len(compressed))
+	}
+
+	if _, err := InspectStream(bytes.NewReader(compressed[:len(compressed)-4])); err == nil {
+		t.Fatal("InspectStream() on truncated stream should fail")
+	}
+}
+
+func BenchmarkCompressParallel(b *testing.B) {
+	data := bytes.Repeat([]byte("Parallel benchmark data for OpenZL multi-core compression. "), 200000)
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := CompressParallel(data); err != nil {
+			b.Fatalf("CompressParallel() failed: %v", err)
+		}
+	}
+}
+
+func TestHostileBlockHeaders(t *testing.T) {
+	// 64 one-byte frames, each claiming 4 GiB of output.
+	bomb := appendStreamHeader(nil)
+	for i := 0; i < 64; i++ {
+		bomb = appendBlockHeader(bomb, 1, 0xFFFFFFFF)
+		bomb = append(bomb, 0)
+	}
+	bomb = appendBlockHeader(bomb, 0, 0)
+
+	// A single header claiming a 4 GiB frame.
+	huge := appendBlockHeader(appendStreamHeader(nil), 0xFFFFFFFF, 1)
+
+	valid, err := CompressParallel(streamTestData(3000), WithChunkSize(1024))
+	if err != nil {
+		t.Fatalf("CompressParallel() failed: %v", err)
+	}
+
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "raw size bomb", data: bomb},
+		{name: "huge frame", data: huge},
+		{name: "trailing bytes", data: append(valid[:len(valid):len(valid)], 0)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := DecompressParallel(tc.data); err != ErrInvalidStream {
+				t.Fatalf("DecompressParallel() = %v, want %v", err, ErrInvalidStream)
+			}
+		})
+	}
+
+	for _, data := range [][]byte{bomb, huge} {
+		for _, concurrency := range []int{1, 4} {
+			r, err := NewReader(bytes.NewReader(data), WithConcurrency(concurrency))
+			if err != nil {
+				t.Fatalf("NewReader() failed: %v", err)
+			}
+			if _, err := io.ReadAll(r); err != ErrInvalidStream {
+				t.Fatalf("ReadAll() = %v, want %v", err, ErrInvalidStream)
+			}
+			r.Close()
+		}
+		if _, err := InspectStream(bytes.NewReader(data)); err != ErrInvalidStream {
+			t.Fatalf("InspectStream() = %v, want %v", err, ErrInvalidStream)
+		}
+	}
+}
+
+func TestStreamContextCanceled(t *testing.T) {
+	data := streamTestData(10000)
+	compressed, err := CompressParallel(data, WithChunkSize(1024))
+	if err != nil {
+		t.Fatalf("CompressParallel() failed: %v", err)
+	}
+
+	for _, concurrency := range []int{1, 4} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			pool := NewPool()
+			defer pool.Close()
+			opts := []Option{WithChunkSize(1024), WithConcurrency(concurrency), WithPool(pool)}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			w, err := NewWriterContext(ctx, io.Discard, opts...)
+			if err != nil {
+				t.Fatalf("NewWriterContext() failed: %v", err)
+			}
+			if _, err := w.Write(data[:2048]); err != nil {
+				t.Fatalf("Write() failed: %v", err)
+			}
+			cancel()
+			if _, err := w.Write(data[2048:]); !errors.Is(err, context.Canceled) {
+				t.Fatalf("Write() after cancel = %v, want %v", err, context.Canceled)
+			}
+			if err := w.Close(); !errors.Is(err, context.Canceled) {
+				t.Fatalf("Close() after cancel = %v, want %v", err, context.Canceled)
+			}
+
+			ctx, cancel = context.WithCancel(context.Background())
+			r, err := NewReaderContext(ctx, bytes.NewReader(compressed), opts...)
+			if err != nil {
+				t.Fatalf("NewReaderContext() failed: %v", err)
+			}
+			if _, err := io.ReadFull(r, make([]byte, 1024)); err != nil {
+				t.Fatalf("Read() failed: %v", err)
+			}
+			cancel()
+			if _, err := io.ReadAll(r); !errors.Is(err, context.Canceled) {
+				t.Fatalf("Read() after cancel = %v, want %v", err, context.Canceled)
+			}
+			r.Close()
+
+			// The contexts went back to the pool and are still usable.
+			zctx, err := pool.Get()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer pool.Put(zctx)
+			out, err := zctx.Compress(data)
+			if err != nil {
+				t.Fatalf("Compress() with a reused context failed: %v", err)
+			}
+			if got, err := zctx.Decompress(out); err != nil || !bytes.Equal(got, data) {
+				t.Fatalf("Decompress() with a reused context failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestParallelContextCanceled(t *testing.T) {
+	data := streamTestData(10000)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := CompressParallelContext(ctx, data, WithChunkSize(1024)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("CompressParallelContext() = %v, want %v", err, context.Canceled)
+	}
+	compressed, err := CompressParallel(data, WithChunkSize(1024))
+	if err != nil {
+		t.Fatalf("CompressParallel() failed: %v", err)
+	}
+	if _, err := DecompressParallelContext(ctx, compressed); !errors.Is(err, context.Canceled) {
+		t.Fatalf("DecompressParallelContext() = %v, want %v", err, context.Canceled)
+	}
+
+	zctx, err := NewContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zctx.Close()
+	if _, err := zctx.CompressContext(ctx, data); !errors.Is(err, context.Canceled) {
+		t.Fatalf("CompressContext() = %v, want %v", err, context.Canceled)
+	}
+	if _, err := zctx.DecompressContext(ctx, compressed); !errors.Is(err, context.Canceled) {
+		t.Fatalf("DecompressContext() = %v, want %v", err, context.Canceled)
+	}
+	if _, err := zctx.CompressContext(context.Background(), data); err != nil {
+		t.Fatalf("CompressContext() failed: %v", err)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	data := streamTestData(10000)
+
+	for _, concurrency := range []int{1, 4} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			var reports []Progress
+			record := WithProgress(func(p Progress) { reports = append(reports, p) })
+			opts := []Option{WithChunkSize(1024), WithConcurrency(concurrency), record, WithProgressInterval(0)}
+
+			var stream bytes.Buffer
+			w, err := NewWriter(&stream, opts...)
+			if err != nil {
+				t.Fatalf("NewWriter() failed: %v", err)
+			}
+			if _, err := w.Write(data); err != nil {
+				t.Fatalf("Write() failed: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() failed: %v", err)
+			}
+			checkProgress(t, reports, int64(len(data)), int64(stream.Len()))
+
+			reports = nil
+			r, err := NewReader(bytes.NewReader(stream.Bytes()), opts...)
+			if err != nil {
+				t.Fatalf("NewReader() failed: %v", err)
+			}
+			defer r.Close()
+			if _, err := io.ReadAll(r); err != nil {
+				t.Fatalf("ReadAll() failed: %v", err)
+			}
+			checkProgress(t, reports, int64(stream.Len()), int64(len(data)))
+		})
+	}
+}
+
+// checkProgress verifies that reports grow steadily to a final report with
+// the given totals, with one report per 1024-byte chunk of 10000 bytes.
+func checkProgress(t *testing.T, reports []Progress, in, out int64) {
+	t.Helper()
+	if len(reports) != 11 {
+		t.Fatalf("got %d progress reports, want 11", len(reports))
+	}
+	for i := 1; i < len(reports); i++ {
+		if reports[i].BytesIn < reports[i-1].BytesIn || reports[i].BytesOut < reports[i-1].BytesOut {
+			t.Fatalf("progress went backwards: %+v after %+v", reports[i], reports[i-1])
+		}
+		if reports[i-1].Done {
+			t.Fatalf("report %d has Done set before the final report", i-1)
+		}
+	}
+	last := reports[len(reports)-1]
+	if !last.Done || last.BytesIn != in || last.BytesOut != out || last.Ratio <= 0 {
+		t.Fatalf("final report = %+v, want Done with %d bytes in and %d out", last, in, out)
+	}
+}
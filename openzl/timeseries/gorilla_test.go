@@ -0,0 +1,143 @@
+package timeseries
+
+import (
+	"math"
+	"math/bits"
+)
+
+// A reference Gorilla encoder (Pelkonen et al., VLDB 2015) that the
+// benchmarks compare against: delta-of-delta timestamps in variable-width
+// buckets and XOR-coded float values.
+
+type bitWriter struct {
+	buf  []byte
+	nbit uint
+}
+
+func (w *bitWriter) write(v uint64, n uint) {
+	for n > 0 {
+		if w.nbit%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		free := 8 - w.nbit%8
+		take := min(free, n)
+		chunk := byte(v>>(n-take)) & (1<<take - 1)
+		w.buf[len(w.buf)-1] |= chunk << (free - take)
+		w.nbit += take
+		n -= take
+	}
+}
+
+type bitReader struct {
+	buf []byte
+	pos uint
+}
+
+func (r *bitReader) read(n uint) uint64 {
+	var v uint64
+	for i := uint(0); i < n; i++ {
+		bit := r.buf[r.pos/8] >> (7 - r.pos%8) & 1
+		v = v<<1 | uint64(bit)
+		r.pos++
+	}
+	return v
+}
+
+func gorillaEncode(timestamps []int64, values []float64) []byte {
+	var w bitWriter
+	var prevT, prevDelta int64
+	var prevV uint64
+	var leading, trailing uint = 64, 0
+	for i, t := range timestamps {
+		v := math.Float64bits(values[i])
+		if i == 0 {
+			w.write(uint64(t), 64)
+			w.write(v, 64)
+			prevT, prevV = t, v
+			continue
+		}
+
+		delta := t - prevT
+		dod := delta - prevDelta
+		switch {
+		case dod == 0:
+			w.write(0, 1)
+		case dod >= -64 && dod <= 63:
+			w.write(0b10, 2)
+			w.write(uint64(dod), 7)
+		case dod >= -256 && dod <= 255:
+			w.write(0b110, 3)
+			w.write(uint64(dod), 9)
+		case dod >= -2048 && dod <= 2047:
+			w.write(0b1110, 4)
+			w.write(uint64(dod), 12)
+		default:
+			w.write(0b1111, 4)
+			w.write(uint64(dod), 64)
+		}
+		prevT, prevDelta = t, delta
+
+		xor := v ^ prevV
+		prevV = v
+		if xor == 0 {
+			w.write(0, 1)
+			continue
+		}
+		lz, tz := uint(min(bits.LeadingZeros64(xor), 31)), uint(bits.TrailingZeros64(xor))
+		if leading != 64 && lz >= leading && tz >= trailing {
+			w.write(0b10, 2)
+			w.write(xor>>trailing, 64-leading-trailing)
+			continue
+		}
+		leading, trailing = lz, tz
+		w.write(0b11, 2)
+		w.write(uint64(lz), 5)
+		w.write(uint64(64-lz-tz-1), 6) // Lengths 1..64 stored as 0..63
+		w.write(xor>>tz, 64-lz-tz)
+	}
+	return w.buf
+}
+
+func gorillaDecode(buf []byte, n int) ([]int64, []float64) {
+	if n == 0 {
+		return nil, nil
+	}
+	r := bitReader{buf: buf}
+	timestamps := make([]int64, n)
+	values := make([]float64, n)
+	t, v := int64(r.read(64)), r.read(64)
+	timestamps[0], values[0] = t, math.Float64frombits(v)
+	var delta int64
+	var leading, trailing uint
+	signExtend := func(x uint64, width uint) int64 {
+		return int64(x<<(64-width)) >> (64 - width)
+	}
+	for i := 1; i < n; i++ {
+		var dod int64
+		switch {
+		case r.read(1) == 0:
+		case r.read(1) == 0:
+			dod = signExtend(r.read(7), 7)
+		case r.read(1) == 0:
+			dod = signExtend(r.read(9), 9)
+		case r.read(1) == 0:
+			dod = signExtend(r.read(12), 12)
+		default:
+			dod = int64(r.read(64))
+		}
+		delta += dod
+		t += delta
+		timestamps[i] = t
+
+		if r.read(1) == 1 {
+			if r.read(1) == 1 {
+				leading = uint(r.read(5))
+				length := uint(r.read(6)) + 1
+				trailing = 64 - leading - length
+			}
+			v ^= r.read(64-leading-trailing) << trailing
+		}
+		values[i] = math.Float64frombits(v)
+	}
+	return timestamps, values
+}
@@ -0,0 +1,232 @@
+// Package timeseries encodes timestamp/value pairs into compact OpenZL
+// frames.
+//
+// Timestamps are delta-of-delta coded, so regularly sampled series reduce to
+// runs of zeros, and passed to OpenZL as a numeric input together with the
+// values. Float values are stored as their IEEE 754 bit patterns; integer
+// values, typically counters, are delta coded. Both inputs go into a single
+// frame, where OpenZL's numeric graphs model them:
+//
+//	frame, err := timeseries.EncodeFloats(timestamps, values)
+//	if err != nil {
+//		return err
+//	}
+//	timestamps, values, err = timeseries.DecodeFloats(frame)
+//
+// All arithmetic wraps, so any int64 timestamps and values round-trip
+// exactly, as do NaN payloads and negative zeros.
+package timeseries
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+// ErrInvalidFrame is returned when a frame was not produced by this package
+// or is corrupt.
+var ErrInvalidFrame = errors.New("timeseries: invalid frame")
+
+// Kind is the type of the values in a frame.
+type Kind byte
+
+const (
+	KindFloat Kind = iota + 1 // float64 values
+	KindInt                   // int64 values
+)
+
+// String returns the name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case KindFloat:
+		return "float"
+	case KindInt:
+		return "int"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+const formatVersion = 1
+
+// Input order within a frame.
+const (
+	inputMeta = iota
+	inputTimestamps
+	inputValues
+	numInputs
+)
+
+// Option configures encoding and decoding.
+type Option func(*config)
+
+type config struct {
+	pool  *openzl.Pool
+	graph openzl.Graph
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithPool makes encoding and decoding take contexts from p. The pool's
+// options then decide how frames are compressed, and WithGraph is ignored.
+func WithPool(p *openzl.Pool) Option {
+	return func(c *config) {
+		c.pool = p
+	}
+}
+
+// WithGraph selects the graph of the context created when no pool is set.
+// Numeric graphs such as openzl.GraphBitpack or openzl.GraphFieldLZ suit
+// regular series.
+func WithGraph(g openzl.Graph) Option {
+	return func(c *config) {
+		c.graph = g
+	}
+}
+
+// EncodeFloats compresses a series of float values. timestamps and values
+// must have the same length; timestamps need not be sorted.
+func EncodeFloats(timestamps []int64, values []float64, opts ...Option) ([]byte, error) {
+	if len(timestamps) != len(values) {
+		return nil, fmt.Errorf("timeseries: %d timestamps but %d values", len(timestamps), len(values))
+	}
+	return encode(KindFloat, timestamps, openzl.Float64Input(values), newConfig(opts))
+}
+
+// EncodeInts compresses a series of integer values. timestamps and values
+// must have the same length; timestamps need not be sorted.
+func EncodeInts(timestamps []int64, values []int64, opts ...Option) ([]byte, error) {
+	if len(timestamps) != len(values) {
+		return nil, fmt.Errorf("timeseries: %d timestamps but %d values", len(timestamps), len(values))
+	}
+	return encode(KindInt, timestamps, openzl.Int64Input(delta(values)), newConfig(opts))
+}
+
+func encode(kind Kind, timestamps []int64, values openzl.Input, c *config) ([]byte, error) {
+	ctx, release, err := openzl.Borrow(c.pool, openzl.WithGraph(c.graph))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return ctx.CompressInputs(
+		openzl.SerialInput([]byte{formatVersion, byte(kind)}),
+		openzl.Int64Input(deltaOfDelta(timestamps)),
+		values,
+	)
+}
+
+// DecodeFloats decompresses a frame produced by EncodeFloats.
+func DecodeFloats(frame []byte, opts ...Option) ([]int64, []float64, error) {
+	timestamps, values, err := decode(frame, KindFloat, newConfig(opts))
+	if err != nil {
+		return nil, nil, err
+	}
+	floats, err := values.Float64s()
+	if err != nil {
+		return nil, nil, ErrInvalidFrame
+	}
+	return timestamps, floats, nil
+}
+
+// DecodeInts decompresses a frame produced by EncodeInts.
+func DecodeInts(frame []byte, opts ...Option) ([]int64, []int64, error) {
+	timestamps, values, err := decode(frame, KindInt, newConfig(opts))
+	if err != nil {
+		return nil, nil, err
+	}
+	ints, err := values.Int64s()
+	if err != nil {
+		return nil, nil, ErrInvalidFrame
+	}
+	return timestamps, undelta(ints), nil
+}
+
+// KindOf returns the kind of values stored in frame, which tells whether to
+// call DecodeFloats or DecodeInts.
+func KindOf(frame []byte, opts ...Option) (Kind, error) {
+	inputs, err := decompress(frame, newConfig(opts))
+	if err != nil {
+		return 0, err
+	}
+	return parseMeta(inputs)
+}
+
+func decompress(frame []byte, c *config) ([]openzl.Input, error) {
+	ctx, release, err := openzl.Borrow(c.pool, openzl.WithGraph(c.graph))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return ctx.DecompressInputs(frame)
+}
+
+func parseMeta(inputs []openzl.Input) (Kind, error) {
+	if len(inputs) != numInputs || inputs[inputMeta].Type != openzl.TypeSerial {
+		return 0, ErrInvalidFrame
+	}
+	meta := inputs[inputMeta].Data
+	if len(meta) != 2 || meta[0] != formatVersion {
+		return 0, ErrInvalidFrame
+	}
+	kind := Kind(meta[1])
+	if kind != KindFloat && kind != KindInt {
+		return 0, ErrInvalidFrame
+	}
+	return kind, nil
+}
+
+func decode(frame []byte, want Kind, c *config) ([]int64, openzl.Input, error) {
+	inputs, err := decompress(frame, c)
+	if err != nil {
+		return nil, openzl.Input{}, err
+	}
+	kind, err := parseMeta(inputs)
+	if err != nil {
+		return nil, openzl.Input{}, err
+	}
+	if kind != want {
+		return nil, openzl.Input{}, fmt.Errorf("timeseries: frame holds %s values, not %s", kind, want)
+	}
+	dods, err := inputs[inputTimestamps].Int64s()
+	if err != nil || inputs[inputValues].Len() != len(dods) {
+		return nil, openzl.Input{}, ErrInvalidFrame
+	}
+	return undeltaOfDelta(dods), inputs[inputValues], nil
+}
+
+// delta returns the first value followed by the difference of each value
+// from the previous one.
+func delta(values []int64) []int64 {
+	out := make([]int64, len(values))
+	var prev int64
+	for i, v := range values {
+		out[i] = v - prev
+		prev = v
+	}
+	return out
+}
+
+func undelta(deltas []int64) []int64 {
+	var prev int64
+	for i, d := range deltas {
+		prev += d
+		deltas[i] = prev
+	}
+	return deltas
+}
+
+// deltaOfDelta returns delta(delta(values)): a constant sampling interval
+// becomes a run of zeros after the first two entries.
+func deltaOfDelta(values []int64) []int64 {
+	return delta(delta(values))
+}
+
+func undeltaOfDelta(dods []int64) []int64 {
+	return undelta(undelta(dods))
+}
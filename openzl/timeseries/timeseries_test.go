@@ -0,0 +1,191 @@
+package timeseries
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/gus3inov/openzl-go/openzl"
+)
+
+// sampleSeries returns n points sampled every 15s with occasional jitter,
+// with a slowly varying gauge and a monotonic counter.
+func sampleSeries(n int) ([]int64, []float64, []int64) {
+	rng := rand.New(rand.NewSource(1))
+	timestamps := make([]int64, n)
+	gauge := make([]float64, n)
+	counter := make([]int64, n)
+	t, c := int64(1700000000000), int64(0)
+	for i := range timestamps {
+		t += 15000
+		if rng.Intn(10) == 0 {
+			t += int64(rng.Intn(200)) - 100
+		}
+		c += int64(rng.Intn(50))
+		timestamps[i] = t
+		gauge[i] = math.Round((50+10*math.Sin(float64(i)/100))*100) / 100
+		counter[i] = c
+	}
+	return timestamps, gauge, counter
+}
+
+func TestRoundTrip(t *testing.T) {
+	timestamps, gauge, counter := sampleSeries(1000)
+
+	edge := []int64{math.MinInt64, math.MaxInt64, 0, -1, math.MaxInt64, math.MinInt64}
+	edgeFloats := []float64{math.NaN(), math.Inf(1), math.Copysign(0, -1), math.SmallestNonzeroFloat64, -math.MaxFloat64, 1}
+	edgeFloats[0] = math.Float64frombits(0x7ff8000000000123) // NaN with a payload
+
+	for _, tc := range []struct {
+		name       string
+		timestamps []int64
+		floats     []float64
+		ints       []int64
+	}{
+		{name: "empty", timestamps: []int64{}, floats: []float64{}, ints: []int64{}},
+		{name: "single", timestamps: []int64{42}, floats: []float64{1.5}, ints: []int64{-7}},
+		{name: "sample", timestamps: timestamps, floats: gauge, ints: counter},
+		{name: "unsorted", timestamps: []int64{5, 3, 9, 1}, floats: []float64{1, 2, 3, 4}, ints: []int64{4, 3, 2, 1}},
+		{name: "extremes", timestamps: edge, floats: edgeFloats, ints: edge},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			frame, err := EncodeFloats(tc.timestamps, tc.floats)
+			if err != nil {
+				t.Fatalf("EncodeFloats() failed: %v", err)
+			}
+			ts, floats, err := DecodeFloats(frame)
+			if err != nil {
+				t.Fatalf("DecodeFloats() failed: %v", err)
+			}
+			if !reflect.DeepEqual(ts, tc.timestamps) && len(ts)+len(tc.timestamps) > 0 {
+				t.Fatal("DecodeFloats() returned different timestamps")
+			}
+			for i := range floats {
+				if math.Float64bits(floats[i]) != math.Float64bits(tc.floats[i]) {
+					t.Fatalf("value %d = %v, want %v", i, floats[i], tc.floats[i])
+				}
+			}
+
+			frame, err = EncodeInts(tc.timestamps, tc.ints)
+			if err != nil {
+				t.Fatalf("EncodeInts() failed: %v", err)
+			}
+			if kind, err := KindOf(frame); err != nil || kind != KindInt {
+				t.Fatalf("KindOf() = %v, %v, want %v", kind, err, KindInt)
+			}
+			ts, ints, err := DecodeInts(frame)
+			if err != nil {
+				t.Fatalf("DecodeInts() failed: %v", err)
+			}
+			if len(ts)+len(tc.timestamps) > 0 && (!reflect.DeepEqual(ts, tc.timestamps) || !reflect.DeepEqual(ints, tc.ints)) {
+				t.Fatal("DecodeInts() returned a different series")
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := EncodeFloats([]int64{1, 2}, []float64{1}); err == nil {
+		t.Fatal("EncodeFloats() with mismatched lengths should fail")
+	}
+	if _, err := EncodeInts([]int64{1}, nil); err == nil {
+		t.Fatal("EncodeInts() with mismatched lengths should fail")
+	}
+
+	frame, err := EncodeFloats([]int64{1}, []float64{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := DecodeInts(frame); err == nil {
+		t.Fatal("DecodeInts() of a float frame should fail")
+	}
+
+	ctx, err := openzl.NewContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+	plain, err := ctx.Compress([]byte("not a series"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := DecodeFloats(plain); err != ErrInvalidFrame {
+		t.Fatalf("DecodeFloats() of a plain frame = %v, want %v", err, ErrInvalidFrame)
+	}
+}
+
+func TestDeltaOfDelta(t *testing.T) {
+	got := deltaOfDelta([]int64{100, 110, 120, 130, 145})
+	if want := []int64{100, -90, 0, 0, 5}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("deltaOfDelta() = %v, want %v", got, want)
+	}
+}
+
+func TestGorillaReference(t *testing.T) {
+	timestamps, gauge, _ := sampleSeries(1000)
+	ts, values := gorillaDecode(gorillaEncode(timestamps, gauge), len(timestamps))
+	if !reflect.DeepEqual(ts, timestamps) || !reflect.DeepEqual(values, gauge) {
+		t.Fatal("reference Gorilla encoder does not round-trip")
+	}
+}
+
+// The benchmarks report bytes per point alongside throughput so that the
+// OpenZL encoding can be compared with Gorilla on the same series.
+
+func BenchmarkEncodeFloats(b *testing.B) {
+	timestamps, gauge, _ := sampleSeries(100000)
+	pool := openzl.NewPool()
+	defer pool.Close()
+
+	b.SetBytes(int64(16 * len(timestamps)))
+	var size int
+	for i := 0; i < b.N; i++ {
+		frame, err := EncodeFloats(timestamps, gauge, WithPool(pool))
+		if err != nil {
+			b.Fatal(err)
+		}
+		size = len(frame)
+	}
+	b.ReportMetric(float64(size)/float64(len(timestamps)), "bytes/point")
+}
+
+func BenchmarkDecodeFloats(b *testing.B) {
+	timestamps, gauge, _ := sampleSeries(100000)
+	pool := openzl.NewPool()
+	defer pool.Close()
+	frame, err := EncodeFloats(timestamps, gauge, WithPool(pool))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(16 * len(timestamps)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := DecodeFloats(frame, WithPool(pool)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGorillaEncode(b *testing.B) {
+	timestamps, gauge, _ := sampleSeries(100000)
+
+	b.SetBytes(int64(16 * len(timestamps)))
+	var size int
+	for i := 0; i < b.N; i++ {
+		size = len(gorillaEncode(timestamps, gauge))
+	}
+	b.ReportMetric(float64(size)/float64(len(timestamps)), "bytes/point")
+}
+
+func BenchmarkGorillaDecode(b *testing.B) {
+	timestamps, gauge, _ := sampleSeries(100000)
+	buf := gorillaEncode(timestamps, gauge)
+
+	b.SetBytes(int64(16 * len(timestamps)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		gorillaDecode(buf, len(timestamps))
+	}
+}
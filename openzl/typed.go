@@ -0,0 +1,246 @@
+package openzl
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gus3inov/openzl-go/internal/copenzl"
+)
+
+// Type describes how OpenZL interprets an input.
+//
+// Telling OpenZL the shape of the data lets it pick format-aware transforms:
+// numeric inputs can be delta-coded or bit-packed, fixed-size records can be
+// split into fields, and strings can be tokenised.
+type Type int
+
+const (
+	TypeSerial  Type = copenzl.TypeSerial  // Opaque bytes
+	TypeStruct  Type = copenzl.TypeStruct  // Fixed-size records of Width bytes
+	TypeNumeric Type = copenzl.TypeNumeric // Little-endian integers of Width bytes (1, 2, 4 or 8)
+	TypeString  Type = copenzl.TypeString  // Variable-length strings
+)
+
+// String returns the name of the type.
+func (t Type) String() string {
+	switch t {
+	case TypeSerial:
+		return "serial"
+	case TypeStruct:
+		return "struct"
+	case TypeNumeric:
+		return "numeric"
+	case TypeString:
+		return "string"
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
+// MarshalText returns the name of the type, so that types appear by name in
+// JSON.
+func (t Type) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// Input is one typed input of a multi-input frame, or one output recovered
+// from it by DecompressInputs.
+type Input struct {
+	Type    Type
+	Data    []byte   // Element data, concatenated
+	Width   int      // Element width in bytes, for TypeStruct and TypeNumeric
+	Lengths []uint32 // Length of each string, for TypeString
+}
+
+// SerialInput returns an input of opaque bytes.
+func SerialInput(data []byte) Input {
+	return Input{Type: TypeSerial, Data: data}
+}
+
+// StructInput returns an input of fixed-size records. len(data) must be a
+// multiple of width.
+func StructInput(data []byte, width int) Input {
+	return Input{Type: TypeStruct, Data: data, Width: width}
+}
+
+// NumericInput returns an input of little-endian integers of the given width
+// (1, 2, 4 or 8 bytes). len(data) must be a multiple of width.
+func NumericInput(data []byte, width int) Input {
+	return Input{Type: TypeNumeric, Data: data, Width: width}
+}
+
+// Int64Input returns a numeric input holding values.
+func Int64Input(values []int64) Input {
+	data := make([]byte, 8*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint64(data[8*i:], uint64(v))
+	}
+	return NumericInput(data, 8)
+}
+
+// Float64Input returns a numeric input holding the IEEE 754 bit patterns of
+// values.
+func Float64Input(values []float64) Input {
+	data := make([]byte, 8*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint64(data[8*i:], math.Float64bits(v))
+	}
+	return NumericInput(data, 8)
+}
+
+// StringInput returns an input holding strs.
+func StringInput(strs [][]byte) Input {
+	size := 0
+	for _, s := range strs {
+		size += len(s)
+	}
+	in := Input{Type: TypeString, Data: make([]byte, 0, size), Lengths: make([]uint32, len(strs))}
+	for i, s := range strs {
+		in.Data = append(in.Data, s...)
+		in.Lengths[i] = uint32(len(s))
+	}
+	return in
+}
+
+// Len returns the number of elements in the input: bytes for TypeSerial,
+// records or integers for TypeStruct and TypeNumeric, strings for TypeString.
+func (in Input) Len() int {
+	switch in.Type {
+	case TypeStruct, TypeNumeric:
+		if in.Width == 0 {
+			return 0
+		}
+		return len(in.Data) / in.Width
+	case TypeString:
+		return len(in.Lengths)
+	}
+	return len(in.Data)
+}
+
+// Int64s decodes a numeric input of width 8.
+func (in Input) Int64s() ([]int64, error) {
+	if in.Type != TypeNumeric || in.Width != 8 {
+		return nil, fmt.Errorf("input is %s of width %d, not 64-bit numeric", in.Type, in.Width)
+	}
+	values := make([]int64, len(in.Data)/8)
+	for i := range values {
+		values[i] = int64(binary.LittleEndian.Uint64(in.Data[8*i:]))
+	}
+	return values, nil
+}
+
+// Float64s decodes a numeric input of width 8 holding IEEE 754 bit patterns.
+func (in Input) Float64s() ([]float64, error) {
+	if in.Type != TypeNumeric || in.Width != 8 {
+		return nil, fmt.Errorf("input is %s of width %d, not 64-bit numeric", in.Type, in.Width)
+	}
+	values := make([]float64, len(in.Data)/8)
+	for i := range values {
+		values[i] = math.Float64frombits(binary.LittleEndian.Uint64(in.Data[8*i:]))
+	}
+	return values, nil
+}
+
+// Strings splits a string input into its elements. The returned slices alias
+// in.Data.
+func (in Input) Strings() ([][]byte, error) {
+	if in.Type != TypeString {
+		return nil, fmt.Errorf("input is %s, not string", in.Type)
+	}
+	strs := make([][]byte, len(in.Lengths))
+	off := 0
+	for i, l := range in.Lengths {
+		end := off + int(l)
+		if end > len(in.Data) {
+			return nil, fmt.Errorf("string lengths exceed data size")
+		}
+		strs[i] = in.Data[off:end:end]
+		off = end
+	}
+	return strs, nil
+}
+
+// validate checks that the input's data is consistent with its type, so that
+// no bytes are silently dropped or read out of bounds by the library.
+func (in Input) validate() error {
+	switch in.Type {
+	case TypeSerial:
+		return nil
+	case TypeStruct, TypeNumeric:
+		if in.Width <= 0 {
+			return &Error{Code: -1, Message: fmt.Sprintf("%s input has invalid width %d", in.Type, in.Width)}
+		}
+		if len(in.Data)%in.Width != 0 {
+			return &Error{Code: -1, Message: fmt.Sprintf("%s input of %d bytes is not a multiple of width %d", in.Type, len(in.Data), in.Width)}
+		}
+		return nil
+	case TypeString:
+		total := 0
+		for _, l := range in.Lengths {
+			total += int(l)
+		}
+		if total != len(in.Data) {
+			return &Error{Code: -1, Message: fmt.Sprintf("string lengths sum to %d, want %d", total, len(in.Data))}
+		}
+		return nil
+	}
+	return &Error{Code: -1, Message: fmt.Sprintf("unknown input type %s", in.Type)}
+}
+
+// CompressInputs compresses one or more typed inputs into a single frame.
+//
+// Inputs are compressed together, so related columns of a dataset can share
+// one frame while each is still modelled according to its type. The frame is
+// decoded with DecompressInputs, which returns the inputs in the same order.
+func (c *Context) CompressInputs(inputs ...Input) ([]byte, error) {
+	if c.ctx == nil {
+		return nil, &Error{Code: -1, Message: "context is closed"}
+	}
+	if len(inputs) == 0 {
+		return nil, &Error{Code: -1, Message: "no inputs"}
+	}
+	bufs := make([]copenzl.TypedBuffer, len(inputs))
+	size := 0
+	for i, in := range inputs {
+		if err := in.validate(); err != nil {
+			return nil, err
+		}
+		bufs[i] = copenzl.TypedBuffer{Type: int(in.Type), Data: in.Data, Width: in.Width, Lengths: in.Lengths}
+		size += len(in.Data)
+	}
+	ob := observe(c.observer, context.Background(), OpCompressInputs)
+	start := time.Now()
+	out, err := copenzl.OpenZLCompressTyped(c.ctx, bufs)
+	c.counters.compressed(size, len(out), time.Since(start), err)
+	ob.add(size, len(out))
+	ob.end(err)
+	return out, err
+}
+
+// DecompressInputs decompresses a frame produced by CompressInputs (or
+// Compress) into its typed outputs, in input order.
+func (c *Context) DecompressInputs(frame []byte) ([]Input, error) {
+	if c.ctx == nil {
+		return nil, &Error{Code: -1, Message: "context is closed"}
+	}
+	ob := observe(c.observer, context.Background(), OpDecompressInputs)
+	start := time.Now()
+	bufs, err := copenzl.OpenZLDecompressTyped(c.ctx, frame)
+	size := 0
+	for _, b := range bufs {
+		size += len(b.Data)
+	}
+	c.counters.decompressed(len(frame), size, time.Since(start), err)
+	ob.add(len(frame), size)
+	ob.end(err)
+	if err != nil {
+		return nil, err
+	}
+	inputs := make([]Input, len(bufs))
+	for i, b := range bufs {
+		inputs[i] = Input{Type: Type(b.Type), Data: b.Data, Width: b.Width, Lengths: b.Lengths}
+	}
+	return inputs, nil
+}
@@ -0,0 +1,123 @@
+package openzl
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestCompressInputs(t *testing.T) {
+	ctx, err := NewContext()
+	if err != nil {
+		t.Fatalf("NewContext() failed: %v", err)
+	}
+	defer ctx.Close()
+
+	timestamps := make([]int64, 1000)
+	values := make([]float64, 1000)
+	for i := range timestamps {
+		timestamps[i] = 1700000000000 + int64(i)*1000
+		values[i] = float64(i%17) * 0.25
+	}
+	records := bytes.Repeat([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, 100)
+	names := [][]byte{[]byte("alpha"), []byte(""), []byte("gamma"), []byte("delta")}
+
+	inputs := []Input{
+		Int64Input(timestamps),
+		Float64Input(values),
+		StructInput(records, 12),
+		StringInput(names),
+		SerialInput([]byte("trailing serial payload")),
+	}
+
+	compressed, err := ctx.CompressInputs(inputs...)
+	if err != nil {
+		t.Fatalf("CompressInputs() failed: %v", err)
+	}
+
+	outputs, err := ctx.DecompressInputs(compressed)
+	if err != nil {
+		t.Fatalf("DecompressInputs() failed: %v", err)
+	}
+	if len(outputs) != len(inputs) {
+		t.Fatalf("DecompressInputs() returned %d outputs, want %d", len(outputs), len(inputs))
+	}
+	for i, out := range outputs {
+		in := inputs[i]
+		if out.Type != in.Type || !bytes.Equal(out.Data, in.Data) || out.Len() != in.Len() {
+			t.Fatalf("output %d (%s) does not match input", i, out.Type)
+		}
+	}
+
+	gotTimestamps, err := outputs[0].Int64s()
+	if err != nil {
+		t.Fatalf("Int64s() failed: %v", err)
+	}
+	if !reflect.DeepEqual(gotTimestamps, timestamps) {
+		t.Fatal("Int64s() returned wrong values")
+	}
+	gotValues, err := outputs[1].Float64s()
+	if err != nil {
+		t.Fatalf("Float64s() failed: %v", err)
+	}
+	if !reflect.DeepEqual(gotValues, values) {
+		t.Fatal("Float64s() returned wrong values")
+	}
+	gotNames, err := outputs[3].Strings()
+	if err != nil {
+		t.Fatalf("Strings() failed: %v", err)
+	}
+	if !reflect.DeepEqual(gotNames, names) {
+		t.Fatalf("Strings() = %q, want %q", gotNames, names)
+	}
+}
+
+func TestCompressInputsErrors(t *testing.T) {
+	ctx, err := NewContext()
+	if err != nil {
+		t.Fatalf("NewContext() failed: %v", err)
+	}
+
+	if _, err := ctx.CompressInputs(); err == nil {
+		t.Fatal("CompressInputs() without inputs should fail")
+	}
+	invalid := []struct {
+		name  string
+		input Input
+	}{
+		{name: "zero width", input: NumericInput([]byte{1, 2, 3, 4}, 0)},
+		{name: "zero struct width", input: StructInput([]byte{1, 2, 3, 4}, 0)},
+		{name: "partial numeric element", input: NumericInput(make([]byte, 10), 4)},
+		{name: "partial struct record", input: StructInput(make([]byte, 10), 3)},
+		{name: "string lengths too long", input: Input{Type: TypeString, Data: []byte("abc"), Lengths: []uint32{2, 2}}},
+		{name: "string lengths too short", input: Input{Type: TypeString, Data: []byte("abcd"), Lengths: []uint32{1}}},
+		{name: "unknown type", input: Input{Type: Type(42), Data: []byte("x")}},
+	}
+	for _, tc := range invalid {
+		_, err := ctx.CompressInputs(SerialInput([]byte("ok")), tc.input)
+		if _, ok := err.(*Error); !ok {
+			t.Fatalf("CompressInputs() with %s = %v, want *Error", tc.name, err)
+		}
+	}
+
+	ctx.Close()
+	if _, err := ctx.CompressInputs(SerialInput([]byte("x"))); err == nil || err.Error() != "context is closed" {
+		t.Fatalf("CompressInputs() on closed context = %v, want 'context is closed'", err)
+	}
+	if _, err := ctx.DecompressInputs([]byte("x")); err == nil || err.Error() != "context is closed" {
+		t.Fatalf("DecompressInputs() on closed context = %v, want 'context is closed'", err)
+	}
+}
+
+func TestInputAccessorsRejectWrongType(t *testing.T) {
+	in := SerialInput([]byte("12345678"))
+	if _, err := in.Int64s(); err == nil {
+		t.Fatal("Int64s() on serial input should fail")
+	}
+	if _, err := in.Float64s(); err == nil {
+		t.Fatal("Float64s() on serial input should fail")
+	}
+	if _, err := in.Strings(); err == nil {
+		t.Fatal("Strings() on serial input should fail")
+	}
+}
@@ -56,7 +56,11 @@
 package openzl
 
 import (
+	"context"
+	"time"
+
 	"github.com/gus3inov/openzl-go/internal/copenzl"
+	"github.com/gus3inov/openzl-go/openzl/purezl"
 )
 
 // Error represents an OpenZL error with detailed information.
@@ -70,6 +74,10 @@ func (e *Error) Error() string {
 	return e.Message
 }
 
+// ErrUnsupported is returned when the package is built without cgo and a
+// frame cannot be decoded by the pure-Go decoder; see package purezl.
+var ErrUnsupported = purezl.ErrUnsupported
+
 // Context represents an OpenZL compression/decompression context.
 //
 // A Context manages the state needed for compression and decompression operations.
@@ -83,19 +91,59 @@ func (e *Error) Error() string {
 // Performance: Reusing a context can improve performance by ~27% compared to creating
 // a new context for each operation.
 type Context struct {
-	ctx *copenzl.OpenZLContext
+	ctx      *copenzl.OpenZLContext
+	level    int
+	graph    Graph
+	observer Observer
+	counters counters
+
+	detectedGraph bool // set by WithDetectedGraph
 }
 
 // NewContext creates a new OpenZL context.
 //
 // The context must be closed when no longer needed to free associated resources.
+// Compression parameters such as WithLevel and WithGraph apply to every
+// compression performed with the context; other options are ignored.
 // Returns an error if the context could not be created.
-func NewContext() (*Context, error) {
-	ctx, err := copenzl.NewOpenZLContext()
+func NewContext(opts ...Option) (*Context, error) {
+	return newContext(newOptions(opts))
+}
+
+func newContext(o options) (*Context, error) {
+	return newContextMode(o, copenzl.ModeCompress|copenzl.ModeDecompress)
+}
+
+// newContextMode creates a context holding native state for the given
+// copenzl.Mode* flags only. Compression options are not applied to
+// decompress-only contexts.
+func newContextMode(o options, mode int) (*Context, error) {
+	ctx, err := copenzl.NewOpenZLContextMode(mode)
 	if err != nil {
 		return nil, err
 	}
-	return &Context{ctx: ctx}, nil
+	if mode&copenzl.ModeCompress == 0 {
+		return &Context{ctx: ctx, observer: o.observer}, nil
+	}
+	if o.level != 0 {
+		if err := ctx.SetLevel(o.level); err != nil {
+			ctx.Close()
+			return nil, err
+		}
+	}
+	if o.graph != GraphDefault {
+		if err := ctx.SetGraph(int(o.graph)); err != nil {
+			ctx.Close()
+			return nil, err
+		}
+	}
+	if o.formatVersion != 0 {
+		if err := ctx.SetFormatVersion(o.formatVersion); err != nil {
+			ctx.Close()
+			return nil, err
+		}
+	}
+	return &Context{ctx: ctx, level: o.level, graph: o.graph, observer: o.observer, detectedGraph: o.detectedGraph}, nil
 }
 
 // Close closes the OpenZL context and frees associated resources.
@@ -115,10 +163,20 @@ func (c *Context) Close() error {
 // The compression uses OpenZL's default compression settings. For empty input,
 // returns empty output. Returns an error if compression fails.
 func (c *Context) Compress(data []byte) ([]byte, error) {
+	return c.compress(context.Background(), data)
+}
+
+func (c *Context) compress(ctx context.Context, data []byte) ([]byte, error) {
 	if c.ctx == nil {
 		return nil, &Error{Code: -1, Message: "context is closed"}
 	}
-	return copenzl.OpenZLCompress(c.ctx, data)
+	ob := observe(c.observer, ctx, OpCompress)
+	start := time.Now()
+	out, err := copenzl.OpenZLCompress(c.ctx, data)
+	c.counters.compressed(len(data), len(out), time.Since(start), err)
+	ob.add(len(data), len(out))
+	ob.end(err)
+	return out, err
 }
 
 // Decompress decompresses the given data using the context.
@@ -126,8 +184,48 @@ func (c *Context) Compress(data []byte) ([]byte, error) {
 // The data must have been compressed with a compatible OpenZL compressor.
 // Returns an error if decompression fails or if the compressed data is invalid.
 func (c *Context) Decompress(data []byte) ([]byte, error) {
+	return c.decompress(context.Background(), data)
+}
+
+func (c *Context) decompress(ctx context.Context, data []byte) ([]byte, error) {
 	if c.ctx == nil {
 		return nil, &Error{Code: -1, Message: "context is closed"}
 	}
-	return copenzl.OpenZLDecompress(c.ctx, data)
+	ob := observe(c.observer, ctx, OpDecompress)
+	start := time.Now()
+	out, err := copenzl.OpenZLDecompress(c.ctx, data)
+	c.counters.decompressed(len(data), len(out), time.Since(start), err)
+	ob.add(len(data), len(out))
+	ob.end(err)
+	return out, err
+}
+
+// CompressContext is like Compress but returns ctx.Err() without compressing
+// if ctx is already done.
+//
+// A single frame is compressed by one native call, which cannot be
+// interrupted once it has started. To bound the time until cancellation
+// takes effect on large inputs, use CompressParallelContext or
+// NewWriterContext, which check ctx between chunks.
+func (c *Context) CompressContext(ctx context.Context, data []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.compress(ctx, data)
+}
+
+// DecompressContext is like Decompress but returns ctx.Err() without
+// decompressing if ctx is already done. As with CompressContext, the native
+// call itself is not interrupted.
+func (c *Context) DecompressContext(ctx context.Context, data []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.decompress(ctx, data)
+}
+
+// DecompressedSize returns the decompressed size recorded in the header of an
+// OpenZL frame, without decompressing it.
+func DecompressedSize(frame []byte) (int, error) {
+	return copenzl.OpenZLDecompressedSize(frame)
 }
@@ -0,0 +1,129 @@
+package openzl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/gus3inov/openzl-go/internal/copenzl"
+)
+
+// BindingVersion is the version of these Go bindings.
+const BindingVersion = "0.2.0-dev"
+
+// VersionInfo describes the bindings and the OpenZL library they use.
+type VersionInfo struct {
+	Binding string // BindingVersion
+
+	// Version of the OpenZL library the bindings were compiled against. All
+	// zero in builds without cgo.
+	Major, Minor, Patch int
+
+	// Range of frame format versions the library reads and writes. Frames
+	// written by a library whose format version is outside this range
+	// cannot be decompressed.
+	MinFormatVersion, MaxFormatVersion int
+}
+
+// Version returns the versions of the bindings, the linked OpenZL library
+// and the frame formats it supports.
+func Version() VersionInfo {
+	v := VersionInfo{Binding: BindingVersion}
+	v.Major, v.Minor, v.Patch = copenzl.LibraryVersion()
+	v.MinFormatVersion, v.MaxFormatVersion = copenzl.FormatVersions()
+	return v
+}
+
+// Library returns the library version as "major.minor.patch", or "none" in
+// builds without cgo.
+func (v VersionInfo) Library() string {
+	if !copenzl.Native {
+		return "none"
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// String returns a one-line summary suitable for logs and bug reports.
+func (v VersionInfo) String() string {
+	if !copenzl.Native {
+		return fmt.Sprintf("openzl-go %s (no native library)", v.Binding)
+	}
+	return fmt.Sprintf("openzl-go %s, OpenZL %s, format versions %d-%d",
+		v.Binding, v.Library(), v.MinFormatVersion, v.MaxFormatVersion)
+}
+
+// FeatureSet reports what the linked library can do.
+type FeatureSet struct {
+	Native bool     // Compression is available; false in builds without cgo
+	Graphs []Graph  // Graphs that round-tripped a probe, as plain bytes or as a numeric input
+	Codecs []string // Codecs the library ran on the probes, sorted by name; empty without introspection
+	Types  []Type   // Input types accepted by CompressInputs
+}
+
+// Features probes the linked library and reports its capabilities. It
+// trial-compresses a small sample with every graph, so it is best called
+// once, for example at startup.
+func Features() FeatureSet {
+	f := FeatureSet{Native: copenzl.Native}
+	if !f.Native {
+		return f
+	}
+	codecs := make(map[string]bool)
+	for _, g := range Graphs() {
+		if probeGraph(g, codecs) {
+			f.Graphs = append(f.Graphs, g)
+		}
+	}
+	for name := range codecs {
+		f.Codecs = append(f.Codecs, name)
+	}
+	sort.Strings(f.Codecs)
+	f.Types = []Type{TypeSerial, TypeStruct, TypeNumeric, TypeString}
+	return f
+}
+
+// probeGraph reports whether g compresses a sample and restores it, trying
+// it as plain bytes and then, for graphs that only accept typed inputs, as a
+// numeric input. Selecting a graph alone proves nothing, as the library only
+// validates it when compressing. The codecs seen running are added to codecs.
+func probeGraph(g Graph, codecs map[string]bool) bool {
+	ctx, err := NewContext(WithGraph(g))
+	if err != nil {
+		return false
+	}
+	defer ctx.Close()
+
+	// Slowly increasing integers suit every graph.
+	probe := make([]byte, 0, 4096)
+	for i := 0; len(probe) < cap(probe); i++ {
+		probe = binary.LittleEndian.AppendUint32(probe, uint32(i*3))
+	}
+
+	frame, e, err := ctx.CompressExplain(probe)
+	if errors.Is(err, ErrExplainUnsupported) {
+		frame, err = ctx.Compress(probe)
+	}
+	if err == nil {
+		if e != nil {
+			addCodecs(e.Nodes, codecs)
+		}
+		got, err := ctx.Decompress(frame)
+		return err == nil && bytes.Equal(got, probe)
+	}
+	if frame, err = ctx.CompressInputs(NumericInput(probe, 4)); err != nil {
+		return false
+	}
+	inputs, err := ctx.DecompressInputs(frame)
+	return err == nil && len(inputs) == 1 && bytes.Equal(inputs[0].Data, probe)
+}
+
+func addCodecs(nodes []*ExplainNode, codecs map[string]bool) {
+	for _, n := range nodes {
+		if n.Kind == NodeCodec && n.Name != "" {
+			codecs[n.Name] = true
+		}
+		addCodecs(n.Children, codecs)
+	}
+}
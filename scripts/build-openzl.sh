@@ -3,10 +3,22 @@
 # build-openzl.sh - Build OpenZL as a shared library
 # This script builds the OpenZL library from source and creates shared libraries
 # that can be used by the Go bindings via cgo
+#
+# With --static it builds static libraries instead and collects them in
+# build/static, for binaries built with -tags openzl_static.
 
 set -e
 
-echo "Building OpenZL shared library..."
+STATIC=0
+if [ "$1" = "--static" ]; then
+    STATIC=1
+fi
+
+if [ $STATIC -eq 1 ]; then
+    echo "Building OpenZL static library..."
+else
+    echo "Building OpenZL shared library..."
+fi
 
 # Check if submodule is initialized
 if [ ! -d "third_party/openzl/.git" ]; then
@@ -31,9 +43,17 @@ cd build
 
 # Configure CMake
 echo "Configuring CMake..."
+SHARED=ON
+if [ $STATIC -eq 1 ]; then
+    SHARED=OFF
+fi
+# Introspection hooks back Context.CompressExplain; while no hooks are
+# attached they cost a pointer check.
 cmake .. \
     -DCMAKE_BUILD_TYPE=Release \
-    -DBUILD_SHARED_LIBS=ON \
+    -DCMAKE_C_FLAGS="-DZL_ALLOW_INTROSPECTION=1" \
+    -DBUILD_SHARED_LIBS=$SHARED \
+    -DCMAKE_POSITION_INDEPENDENT_CODE=ON \
     -DCMAKE_INSTALL_PREFIX=../install \
     -G Ninja
 
@@ -52,6 +72,27 @@ else
     ninja
 fi
 
+if [ $STATIC -eq 1 ]; then
+    # Collect the archives, and nothing else, where link_static.go looks.
+    echo "Collecting static libraries..."
+    rm -rf static
+    mkdir -p static
+    for lib in openzl zstd; do
+        LIB_CANDIDATE=$(find . -name "lib${lib}.a" -not -path "./static/*" | head -n 1)
+        if [ -z "$LIB_CANDIDATE" ]; then
+            echo "✗ lib${lib}.a not found!"
+            exit 1
+        fi
+        cp "$LIB_CANDIDATE" static/
+        echo "✓ Static library: third_party/openzl/build/static/lib${lib}.a"
+    done
+    cd ../../..
+    echo ""
+    echo "OpenZL static build completed successfully!"
+    echo "Build with: go build -tags openzl_static ./..."
+    exit 0
+fi
+
 # Verify the shared library was created
 echo "Verifying build..."
 if [[ "$OSTYPE" == "darwin"* ]]; then